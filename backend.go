@@ -1,7 +1,33 @@
 package proxy
 
+// BackendType selects the protocol used to call a backend's endpoints
+type BackendType string
+
+// BackendType constants
+const (
+	// HTTP backends map each endpoint to a REST call (default)
+	HTTP BackendType = "http"
+
+	// GRAPHQL backends POST each endpoint's query to a single GraphQL endpoint
+	// Body parameters become the query's variables
+	GRAPHQL BackendType = "graphql"
+
+	// GRPC backends invoke unary gRPC methods discovered through server reflection
+	// Body parameters become fields of the request message
+	GRPC BackendType = "grpc"
+)
+
 // Backend defines the target HTTP backend configuration
 type Backend struct {
+	// Name identifies the backend so endpoints of other backends can target it with
+	// backend_ref, sharing its base URL, headers and session. Optional
+	Name string `json:"name,omitempty" yaml:"name,omitempty"`
+
+	// Type selects how endpoints are called: http (default), graphql or grpc
+	// For grpc backends, BaseURL is the target address, e.g. "localhost:50051";
+	// prefix it with "grpcs://" to connect over TLS
+	Type BackendType `json:"type,omitempty" yaml:"type,omitempty"`
+
 	// BaseURL is the base URL for all endpoints in this backend
 	BaseURL string `json:"base_url" yaml:"base_url"`
 
@@ -10,7 +36,55 @@ type Backend struct {
 	// Common uses: authentication tokens, API keys, content-type specifications
 	DefaultHeaders []*Header `json:"default_headers" yaml:"default_headers"`
 
+	// CookieJar keeps cookies the backend sets, such as a session cookie, and sends them
+	// on later requests to any of its endpoints
+	CookieJar bool `json:"cookie_jar,omitempty" yaml:"cookie_jar,omitempty"`
+
+	// Login is called once before the first request to establish a session, and again
+	// when a request is rejected with 401. It implies CookieJar
+	Login *Login `json:"login,omitempty" yaml:"login,omitempty"`
+
+	// MaxConcurrency caps how many requests to all of this backend's endpoints run at once
+	// Default: unlimited
+	MaxConcurrency int `json:"max_concurrency,omitempty" yaml:"max_concurrency,omitempty"`
+
+	// AllowPrivateTargets lets path parameters that fill in the base URL's host, e.g.
+	// "https://{host}/api", reach loopback, private and link-local addresses. Off by
+	// default, so a model can't send requests to internal services or cloud metadata
+	AllowPrivateTargets bool `json:"allow_private_targets,omitempty" yaml:"allow_private_targets,omitempty"`
+
+	// Redirects selects how the backend's redirects are followed: same_host (default)
+	// follows them but drops credential headers when a redirect leads to another host or
+	// scheme, follow uses Go's default handling, which only drops Authorization and
+	// Cookie on redirects to another domain, and none returns the 3xx response as is
+	Redirects RedirectPolicy `json:"redirects,omitempty" yaml:"redirects,omitempty"`
+
+	// HealthCheck probes the backend when the proxy starts and logs whether it is reachable
+	HealthCheck *HealthCheck `json:"health_check,omitempty" yaml:"health_check,omitempty"`
+
 	// Endpoints defines all the MCP endpoints for this backend
-	// Each endpoint will use this backend's BaseURL and DefaultHeaders
+	// Each endpoint will use this backend's BaseURL and DefaultHeaders unless it sets backend_ref
+	// Named backends may leave this empty and serve only endpoints that reference them
 	Endpoints []Endpoint `json:"endpoints" yaml:"endpoints"`
 }
+
+// findBackend returns the backend with the given name, or nil if there is none
+func (cfg *Config) findBackend(name string) *Backend {
+	for _, backend := range cfg.Backends {
+		if backend.Name == name {
+			return backend
+		}
+	}
+	return nil
+}
+
+// endpointBackend returns the backend an endpoint calls: the one named by its
+// backend_ref, or the backend it is nested under
+func (cfg *Config) endpointBackend(backend *Backend, endpoint *Endpoint) *Backend {
+	if endpoint.BackendRef != "" {
+		if target := cfg.findBackend(endpoint.BackendRef); target != nil {
+			return target
+		}
+	}
+	return backend
+}
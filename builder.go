@@ -0,0 +1,178 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// requestBuilder builds backend requests from an endpoint's parameters and headers.
+// The tool, resource and prompt handlers embed it so they build requests alike.
+type requestBuilder struct {
+	endpoint *Endpoint
+	backend  *Backend
+
+	// transformers are the registered transformers the endpoint names, if any
+	transformers transformers
+}
+
+// callContext applies the endpoint's per-call settings to ctx: retries of non-idempotent
+// methods and of responses whose body reports an error, the response size limit and one
+// idempotency key for every attempt. The returned stats collect the call's retry counts.
+func (b *requestBuilder) callContext(ctx context.Context) (context.Context, *requestStats) {
+	ctx, stats := withRequestStats(ctx)
+	if b.endpoint.RetryNonIdempotent {
+		ctx = withNonIdempotentRetries(ctx)
+	}
+	ctx = withRetryOnBody(ctx, b.endpoint.RetryOnBody)
+	ctx = withResponseLimit(ctx, b.endpoint.MaxResponseBytes)
+	if b.endpoint.IdempotencyKey {
+		ctx = withIdempotencyKey(ctx, b.endpoint.IdempotencyHeader)
+	}
+	return ctx, stats
+}
+
+// buildURL appends the endpoint's path to the backend's BaseURL and substitutes its path
+// parameters. Constant parameters use their configured value; dynamic ones come from
+// arguments, and a URL they fill in is checked with checkTargetURL.
+func (b *requestBuilder) buildURL(arguments map[string]any) (string, error) {
+	target := b.backend.BaseURL + b.endpoint.Path
+	dynamic := false
+
+	for _, param := range b.endpoint.PathParameters {
+		var value any
+		var exists bool
+
+		if param.ValueType == CONSTANT {
+			value = param.Value
+			exists = param.Value != ""
+		} else {
+			value, exists = arguments[param.Identifier]
+		}
+
+		if !exists && param.Required {
+			return "", fmt.Errorf("required path parameter '%s' not provided", param.Identifier)
+		}
+		if exists {
+			placeholder := fmt.Sprintf("{%s}", param.Identifier)
+			replacement := fmt.Sprintf("%v", value)
+			if param.ValueType != CONSTANT {
+				// Escape argument values so a slash stays inside its segment
+				replacement = url.PathEscape(replacement)
+				dynamic = true
+			}
+			target = strings.ReplaceAll(target, placeholder, replacement)
+		}
+	}
+
+	if dynamic {
+		if err := checkTargetURL(b.backend, target); err != nil {
+			return "", err
+		}
+	}
+
+	return target, nil
+}
+
+// buildQueryParams constructs query parameters from arguments
+func (b *requestBuilder) buildQueryParams(arguments map[string]any) (string, error) {
+	values := url.Values{}
+
+	for _, param := range b.endpoint.QueryParameters {
+		var value any
+		var exists bool
+
+		if param.ValueType == CONSTANT {
+			// Use the predefined value for constant parameters
+			value = param.Value
+			exists = param.Value != ""
+		} else {
+			// Use the value from arguments for dynamic parameters
+			value, exists = arguments[param.Identifier]
+		}
+
+		if exists {
+			addQueryValue(values, param, value)
+		} else if param.Required {
+			return "", fmt.Errorf("required query parameter '%s' not provided", param.Identifier)
+		}
+	}
+
+	return values.Encode(), nil
+}
+
+// buildRequestBody constructs the JSON request body
+func (b *requestBuilder) buildRequestBody(arguments map[string]any) ([]byte, error) {
+	// With an input schema, the arguments themselves are the body
+	if b.endpoint.InputSchema != nil {
+		body := schemaBody(b.endpoint, arguments)
+		if len(body) == 0 {
+			return nil, nil
+		}
+		return json.Marshal(body)
+	}
+
+	if len(b.endpoint.BodyParams) == 0 {
+		return nil, nil
+	}
+
+	// A raw_body parameter is sent verbatim as the whole body
+	if param := rawBodyParam(b.endpoint); param != nil {
+		return buildRawBody(param, arguments)
+	}
+
+	// An array body is the single body parameter's value, unwrapped
+	if b.endpoint.ArrayBody {
+		return buildArrayBody(b.endpoint.BodyParams[0], arguments)
+	}
+
+	body := make(map[string]any)
+	for _, param := range b.endpoint.BodyParams {
+		var value any
+		var exists bool
+
+		if param.ValueType == CONSTANT {
+			// Use the predefined value for constant parameters
+			value = param.Value
+			exists = param.Value != ""
+		} else {
+			// Use the value from arguments for dynamic parameters
+			value, exists = arguments[param.Identifier]
+		}
+
+		if exists {
+			body[param.Identifier] = value
+		} else if param.Required {
+			return nil, fmt.Errorf("required body parameter '%s' not provided", param.Identifier)
+		}
+	}
+
+	if len(body) == 0 {
+		return nil, nil
+	}
+
+	return json.Marshal(body)
+}
+
+// addHeaders adds headers to the HTTP request
+func (b *requestBuilder) addHeaders(req *http.Request, arguments map[string]any) {
+	// Add default headers from backend
+	for _, header := range b.backend.DefaultHeaders {
+		req.Header.Set(header.Name, header.Value)
+	}
+
+	// Add endpoint-specific headers; dynamic values are filled from the arguments
+	for _, header := range b.endpoint.Headers {
+		if value, ok := resolveHeaderValue(header, arguments); ok {
+			req.Header.Set(header.Name, value)
+		}
+	}
+
+	// Set content type for JSON if we have body parameters
+	if len(b.endpoint.BodyParams) > 0 || b.endpoint.InputSchema != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+}
@@ -0,0 +1,85 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// errNotStarted is returned by the in-process client helpers before Start or ServeStdio
+var errNotStarted = errors.New("proxy is not started")
+
+// callClient returns the in-process client the helpers call the MCP server with
+func (s *Proxy) callClient() (*client.Client, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.inProcess == nil {
+		return nil, errNotStarted
+	}
+	return s.inProcess, nil
+}
+
+// ListTools returns every tool the proxy serves, through its in-process MCP client.
+// The proxy must have been started with Start or ServeStdio.
+func (s *Proxy) ListTools(ctx context.Context) ([]mcp.Tool, error) {
+	c, err := s.callClient()
+	if err != nil {
+		return nil, err
+	}
+
+	var tools []mcp.Tool
+	var request mcp.ListToolsRequest
+	for {
+		result, err := c.ListTools(ctx, request)
+		if err != nil {
+			return nil, err
+		}
+		tools = append(tools, result.Tools...)
+		if result.NextCursor == "" {
+			return tools, nil
+		}
+		request.Params.Cursor = result.NextCursor
+	}
+}
+
+// CallTool calls a tool with arguments through the proxy's in-process MCP client. A
+// tool that fails returns a result with IsError set rather than an error.
+func (s *Proxy) CallTool(ctx context.Context, name string, arguments map[string]any) (*mcp.CallToolResult, error) {
+	c, err := s.callClient()
+	if err != nil {
+		return nil, err
+	}
+
+	var request mcp.CallToolRequest
+	request.Params.Name = name
+	request.Params.Arguments = arguments
+	return c.CallTool(ctx, request)
+}
+
+// ReadResource reads a resource, or a resource template filled in as uri, through the
+// proxy's in-process MCP client
+func (s *Proxy) ReadResource(ctx context.Context, uri string) (*mcp.ReadResourceResult, error) {
+	c, err := s.callClient()
+	if err != nil {
+		return nil, err
+	}
+
+	var request mcp.ReadResourceRequest
+	request.Params.URI = uri
+	return c.ReadResource(ctx, request)
+}
+
+// GetPrompt renders a prompt with arguments through the proxy's in-process MCP client
+func (s *Proxy) GetPrompt(ctx context.Context, name string, arguments map[string]string) (*mcp.GetPromptResult, error) {
+	c, err := s.callClient()
+	if err != nil {
+		return nil, err
+	}
+
+	var request mcp.GetPromptRequest
+	request.Params.Name = name
+	request.Params.Arguments = arguments
+	return c.GetPrompt(ctx, request)
+}
@@ -0,0 +1,138 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const callsConfig = `
+mcp: {}
+backends:
+  - base_url: %s
+    endpoints:
+      - name: get_user
+        capability: tool
+        mode: client
+        method: GET
+        path: /users/{user_id}
+        path_parameters:
+          - identifier: user_id
+            data_type: string
+            value_type: dynamic
+            required: true
+      - name: status
+        capability: resource
+        method: GET
+        path: /status
+      - name: greeting
+        capability: prompt
+        messages:
+          - role: user
+            content: "Say hello to {name}"
+        body_params:
+          - identifier: name
+            data_type: string
+            value_type: dynamic
+            required: true
+`
+
+func newCallsBackend(t *testing.T) *httptest.Server {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"path":%q}`, r.URL.Path)
+	}))
+	t.Cleanup(backend.Close)
+	return backend
+}
+
+func TestCallHelpers(t *testing.T) {
+	backend := newCallsBackend(t)
+	s := newTestProxy(t, fmt.Sprintf(callsConfig, backend.URL))
+	ctx := context.Background()
+
+	tools, err := s.ListTools(ctx)
+	if err != nil {
+		t.Fatalf("ListTools failed: %v", err)
+	}
+	if len(tools) != 1 || tools[0].Name != "get_user" {
+		t.Errorf("ListTools returned %v, want get_user", tools)
+	}
+
+	text, isError := toolText(t, s, "get_user", map[string]any{"user_id": "42"})
+	if isError || !strings.Contains(text, `/users/42`) {
+		t.Errorf("CallTool returned %q (error %v), want the backend response for /users/42", text, isError)
+	}
+
+	resource, err := s.ReadResource(ctx, "proxy://status")
+	if err != nil {
+		t.Fatalf("ReadResource failed: %v", err)
+	}
+	contents, ok := resource.Contents[0].(mcp.TextResourceContents)
+	if !ok || !strings.Contains(contents.Text, `/status`) {
+		t.Errorf("ReadResource returned %v, want the backend response for /status", resource.Contents)
+	}
+
+	prompt, err := s.GetPrompt(ctx, "greeting", map[string]string{"name": "Ada"})
+	if err != nil {
+		t.Fatalf("GetPrompt failed: %v", err)
+	}
+	message, ok := prompt.Messages[0].Content.(mcp.TextContent)
+	if !ok || message.Text != "Say hello to Ada" {
+		t.Errorf("GetPrompt returned %v, want the rendered message", prompt.Messages)
+	}
+}
+
+func TestCallHelpersBeforeStart(t *testing.T) {
+	cfg, err := ParseConfigFromBytes([]byte(fmt.Sprintf(callsConfig, "http://localhost")))
+	if err != nil {
+		t.Fatalf("failed to parse config: %v", err)
+	}
+	s, err := NewServerFromConfig(cfg, WithLogger(discardLogger()))
+	if err != nil {
+		t.Fatalf("failed to create proxy: %v", err)
+	}
+	defer s.Close()
+
+	if _, err := s.ListTools(context.Background()); !errors.Is(err, errNotStarted) {
+		t.Errorf("ListTools before Start returned %v, want errNotStarted", err)
+	}
+}
+
+func TestCallHelpersWithoutInternalClient(t *testing.T) {
+	backend := newCallsBackend(t)
+	cfg, err := ParseConfigFromBytes([]byte(fmt.Sprintf(callsConfig, backend.URL)))
+	if err != nil {
+		t.Fatalf("failed to parse config: %v", err)
+	}
+	s, err := NewServerFromConfig(cfg,
+		WithLogger(discardLogger()),
+		WithAddr("127.0.0.1:0"),
+		WithInternalClient(false),
+	)
+	if err != nil {
+		t.Fatalf("failed to create proxy: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer s.Close()
+	defer cancel()
+
+	if err := s.Start(ctx); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	if s.Client() != nil {
+		t.Error("Client() is set without the internal client")
+	}
+
+	text, isError := toolText(t, s, "get_user", map[string]any{"user_id": "7"})
+	if isError || !strings.Contains(text, `/users/7`) {
+		t.Errorf("CallTool returned %q (error %v), want the backend response for /users/7", text, isError)
+	}
+}
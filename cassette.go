@@ -0,0 +1,169 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+// defaultRedactedHeaders are never written to cassettes
+var defaultRedactedHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie", "X-Api-Key"}
+
+// cassette is a recorded backend interaction stored as JSON
+type cassette struct {
+	Request  cassetteRequest  `json:"request"`
+	Response cassetteResponse `json:"response"`
+}
+
+type cassetteRequest struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Header http.Header `json:"header,omitempty"`
+	Body   []byte      `json:"body,omitempty"`
+}
+
+type cassetteResponse struct {
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       []byte      `json:"body,omitempty"`
+}
+
+// cassetteRecorder records backend responses to, or replays them from, a directory
+// of cassettes keyed by method, URL and a hash of the request body
+type cassetteRecorder struct {
+	dir    string
+	replay bool
+	secret secretKeys
+}
+
+// do replays the recorded response for req, or sends req with send and records the
+// response. Recorded responses are read up to the response limit of ctx.
+func (r *cassetteRecorder) do(ctx context.Context, req *http.Request, send func() (*http.Response, error)) (*http.Response, error) {
+	body, err := readRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+	path := filepath.Join(r.dir, cassetteKey(req.Method, req.URL.String(), body)+".json")
+
+	if r.replay {
+		return r.load(path, req)
+	}
+
+	resp, err := send()
+	if err != nil {
+		return nil, err
+	}
+
+	respBody, err := io.ReadAll(limitBody(resp.Body, responseLimitFromContext(ctx)))
+	resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	recordedURL, _ := r.secret.redactURL(req.URL.String())
+	recorded := cassette{
+		Request: cassetteRequest{
+			Method: req.Method,
+			URL:    recordedURL,
+			Header: r.redact(req.Header),
+			Body:   body,
+		},
+		Response: cassetteResponse{
+			StatusCode: resp.StatusCode,
+			Header:     r.redact(resp.Header),
+			Body:       respBody,
+		},
+	}
+	if err := r.save(path, &recorded); err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// load reads a cassette and rebuilds its response
+func (r *cassetteRecorder) load(path string, req *http.Request) (*http.Response, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("no recorded response for %s %s", req.Method, req.URL)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cassette: %w", err)
+	}
+
+	var recorded cassette
+	if err := json.Unmarshal(data, &recorded); err != nil {
+		return nil, fmt.Errorf("invalid cassette %s: %w", path, err)
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", recorded.Response.StatusCode, http.StatusText(recorded.Response.StatusCode)),
+		StatusCode:    recorded.Response.StatusCode,
+		Header:        recorded.Response.Header,
+		Body:          io.NopCloser(bytes.NewReader(recorded.Response.Body)),
+		ContentLength: int64(len(recorded.Response.Body)),
+		Request:       req,
+	}, nil
+}
+
+// save writes a cassette to path
+func (r *cassetteRecorder) save(path string, recorded *cassette) error {
+	data, err := json.MarshalIndent(recorded, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode cassette: %w", err)
+	}
+
+	if err := os.MkdirAll(r.dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create cassette directory: %w", err)
+	}
+
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write cassette: %w", err)
+	}
+
+	return nil
+}
+
+// redact returns a copy of header with secret values replaced
+func (r *cassetteRecorder) redact(header http.Header) http.Header {
+	redacted := header.Clone()
+	for name := range redacted {
+		if r.secret.has(name) {
+			redacted[name] = []string{"REDACTED"}
+		}
+	}
+	return redacted
+}
+
+// readRequestBody returns a copy of the request body without consuming it
+func readRequestBody(req *http.Request) ([]byte, error) {
+	if req.GetBody == nil {
+		return nil, nil
+	}
+
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, fmt.Errorf("failed to copy request body: %w", err)
+	}
+	defer body.Close()
+
+	return io.ReadAll(body)
+}
+
+// cassetteKey identifies a request by method, URL and body hash
+func cassetteKey(method, url string, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(method + " " + url + "\n"))
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))[:16]
+}
@@ -0,0 +1,85 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const cassetteConfig = `
+backends:
+  - base_url: %s
+    endpoints:
+      - name: search
+        capability: tool
+        mode: client
+        method: GET
+        path: /search
+        query_parameters:
+          - identifier: q
+            data_type: string
+            value_type: dynamic
+          - identifier: api_key
+            data_type: string
+            value_type: dynamic
+      - name: export
+        capability: tool
+        mode: client
+        method: GET
+        path: /export
+        max_response_bytes: 16
+`
+
+func TestRecordRedactsURL(t *testing.T) {
+	backend := newEchoBackend(t)
+	dir := t.TempDir()
+	s := newTestProxy(t, fmt.Sprintf(cassetteConfig, backend.URL), WithRecord(dir))
+
+	if text, isError := toolText(t, s, "search", map[string]any{"q": "shoes", "api_key": "s3cret"}); isError {
+		t.Fatalf("search failed: %s", text)
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil || len(files) != 1 {
+		t.Fatalf("found cassettes %v (%v), want 1", files, err)
+	}
+	data, err := os.ReadFile(files[0])
+	if err != nil {
+		t.Fatalf("failed to read cassette: %v", err)
+	}
+	var recorded cassette
+	if err := json.Unmarshal(data, &recorded); err != nil {
+		t.Fatalf("invalid cassette: %v", err)
+	}
+
+	if strings.Contains(recorded.Request.URL, "s3cret") {
+		t.Errorf("recorded URL %s contains the api_key value", recorded.Request.URL)
+	}
+	if !strings.Contains(recorded.Request.URL, "q=shoes") {
+		t.Errorf("recorded URL %s lost the q parameter", recorded.Request.URL)
+	}
+}
+
+func TestRecordLimitsResponse(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, strings.Repeat("x", 1024))
+	}))
+	defer backend.Close()
+
+	dir := t.TempDir()
+	s := newTestProxy(t, fmt.Sprintf(cassetteConfig, backend.URL), WithRecord(dir))
+
+	result, err := s.CallTool(context.Background(), "export", nil)
+	if err == nil && !result.IsError {
+		t.Error("export of a response over max_response_bytes succeeded, want an error")
+	}
+	if files, _ := filepath.Glob(filepath.Join(dir, "*.json")); len(files) != 0 {
+		t.Errorf("recorded %d cassettes of a response over the limit, want none", len(files))
+	}
+}
@@ -3,32 +3,69 @@ package proxy
 import (
 	"context"
 	"fmt"
+	"io"
+	"log/slog"
+	"net"
 	"net/http"
+	"net/http/cookiejar"
+	"slices"
 	"sync"
+	"sync/atomic"
 	"time"
+
+	"github.com/google/uuid"
 )
 
 type ClientConfig struct {
-	Timeout         time.Duration
-	MaxRetries      int
-	RetryDelay      time.Duration
-	MaxIdleConns    int
-	MaxConnsPerHost int
+	Timeout             time.Duration
+	ConnectTimeout      time.Duration
+	TLSHandshakeTimeout time.Duration
+	MaxRetries          int
+	RetryDelay          time.Duration
+	MaxIdleConns        int
+	MaxConnsPerHost     int
+
+	// CookieJar keeps cookies set by responses and sends them on later requests
+	CookieJar bool
+
+	// DefaultHeaders are set on every request that doesn't already carry them
+	DefaultHeaders http.Header
+
+	// RequestIDHeader carries the request ID of the MCP call being served. Default: not sent
+	RequestIDHeader string
+
+	// Redirects selects how redirects are followed. Default: same_host
+	Redirects RedirectPolicy
+
+	// SecretHeaders are removed, along with Authorization, Cookie and other credential
+	// headers, from requests redirected to another host under the same_host policy
+	SecretHeaders []string
+
+	// BlockPrivateTargets refuses connections and redirects to loopback, private and
+	// link-local addresses, for backends whose host is filled in from arguments
+	BlockPrivateTargets bool
+
+	// Logger receives debug records for retried requests. Default: slog.Default()
+	Logger *slog.Logger
 }
 
 func DefaultClientConfig() *ClientConfig {
 	return &ClientConfig{
-		Timeout:         30 * time.Second,
-		MaxRetries:      3,
-		RetryDelay:      1 * time.Second,
-		MaxIdleConns:    100,
-		MaxConnsPerHost: 10,
+		Timeout:             30 * time.Second,
+		ConnectTimeout:      10 * time.Second,
+		TLSHandshakeTimeout: 10 * time.Second,
+		MaxRetries:          3,
+		RetryDelay:          1 * time.Second,
+		MaxIdleConns:        100,
+		MaxConnsPerHost:     10,
 	}
 }
 
 type HTTPClient struct {
-	client *http.Client
-	config *ClientConfig
+	client  *http.Client
+	config  *ClientConfig
+	logger  *slog.Logger
+	session *backendSession
 }
 
 func NewHTTPClient(config *ClientConfig) *HTTPClient {
@@ -36,21 +73,45 @@ func NewHTTPClient(config *ClientConfig) *HTTPClient {
 		config = DefaultClientConfig()
 	}
 
+	dialer := &net.Dialer{
+		Timeout:   config.ConnectTimeout,
+		KeepAlive: 30 * time.Second,
+	}
+	redirect := checkRedirect(config.Redirects, config.SecretHeaders)
+	if config.BlockPrivateTargets {
+		dialer.Control = refusePrivateDial
+		redirect = checkPublicRedirect(redirect)
+	}
+
 	transport := &http.Transport{
+		DialContext:         dialer.DialContext,
+		TLSHandshakeTimeout: config.TLSHandshakeTimeout,
 		MaxIdleConns:        config.MaxIdleConns,
 		MaxIdleConnsPerHost: config.MaxConnsPerHost,
 		IdleConnTimeout:     90 * time.Second,
 		DisableCompression:  false,
 	}
 
+	// config.Timeout is applied per request by withClientTimeout, so a tighter or looser
+	// context deadline isn't overridden by a fixed client timeout
 	client := &http.Client{
-		Timeout:   config.Timeout,
-		Transport: transport,
+		Transport:     transport,
+		CheckRedirect: redirect,
+	}
+	if config.CookieJar {
+		// cookiejar.New only fails for a broken public suffix list, and none is used
+		client.Jar, _ = cookiejar.New(nil)
+	}
+
+	logger := config.Logger
+	if logger == nil {
+		logger = slog.Default()
 	}
 
 	return &HTTPClient{
 		client: client,
 		config: config,
+		logger: logger,
 	}
 }
 
@@ -63,39 +124,354 @@ func (c *HTTPClient) DoWithCircuitBreaker(ctx context.Context, req *http.Request
 		return nil, fmt.Errorf("circuit breaker is open")
 	}
 
+	setDefaultHeaders(req, c.config.DefaultHeaders)
+	setRequestIDHeader(ctx, req, c.config.RequestIDHeader)
+
+	ctx, cancel := c.withClientTimeout(ctx)
+	resp, err := c.doWithSession(ctx, req)
+	recordResult(cb, resp, err)
+	if err != nil {
+		cancel()
+		return resp, err
+	}
+
+	// Release the client timeout once the body has been consumed
+	resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// DoHedged sends the request and, if no response arrives within hedgeAfter, sends an
+// identical second request and returns whichever completes first. The slower request is
+// cancelled. Only safe methods (GET, HEAD, OPTIONS) are hedged; other requests and a
+// zero hedgeAfter fall back to DoWithCircuitBreaker. The circuit breaker records a single
+// result per logical request.
+func (c *HTTPClient) DoHedged(ctx context.Context, req *http.Request, cb *CircuitBreaker, hedgeAfter time.Duration) (*http.Response, error) {
+	if hedgeAfter <= 0 || !isSafeMethod(req.Method) {
+		return c.DoWithCircuitBreaker(ctx, req, cb)
+	}
+
+	if cb != nil && !cb.CanExecute() {
+		return nil, fmt.Errorf("circuit breaker is open")
+	}
+
+	setDefaultHeaders(req, c.config.DefaultHeaders)
+	setRequestIDHeader(ctx, req, c.config.RequestIDHeader)
+
+	ctx, cancelTimeout := c.withClientTimeout(ctx)
+
+	type result struct {
+		resp    *http.Response
+		err     error
+		cancel  context.CancelFunc
+		attempt int
+	}
+
+	results := make(chan result, 2)
+	var cancels []context.CancelFunc
+	launch := func() {
+		attemptCtx, cancel := context.WithCancel(ctx)
+		attempt := len(cancels)
+		cancels = append(cancels, cancel)
+		attemptReq, err := cloneRequest(attemptCtx, req)
+		if err != nil {
+			results <- result{err: err, cancel: cancel, attempt: attempt}
+			return
+		}
+		go func() {
+			resp, err := c.doWithSession(attemptCtx, attemptReq)
+			results <- result{resp: resp, err: err, cancel: cancel, attempt: attempt}
+		}()
+	}
+
+	launch()
+	inFlight := 1
+
+	timer := time.NewTimer(hedgeAfter)
+	defer timer.Stop()
+
+	var first result
+	select {
+	case first = <-results:
+		inFlight--
+	case <-timer.C:
+		launch()
+		inFlight++
+		first = <-results
+		inFlight--
+	case <-ctx.Done():
+		// The attempt observes ctx cancellation and will drain below
+		first = result{err: ctx.Err(), cancel: func() {}, attempt: -1}
+	}
+
+	// Prefer a successful response if the first one to arrive failed
+	if first.err != nil && inFlight > 0 && ctx.Err() == nil {
+		second := <-results
+		inFlight--
+		first.cancel()
+		first = second
+	}
+
+	// Cancel and drain any request still in flight
+	if inFlight > 0 {
+		for attempt, cancel := range cancels {
+			if attempt != first.attempt {
+				cancel()
+			}
+		}
+		go func(remaining int) {
+			for i := 0; i < remaining; i++ {
+				loser := <-results
+				if loser.resp != nil {
+					loser.resp.Body.Close()
+				}
+			}
+		}(inFlight)
+	}
+
+	recordResult(cb, first.resp, first.err)
+
+	if first.err != nil {
+		first.cancel()
+		cancelTimeout()
+		return nil, first.err
+	}
+
+	// Release the winner's context once its body has been consumed
+	first.resp.Body = &cancelOnClose{ReadCloser: first.resp.Body, cancel: func() {
+		first.cancel()
+		cancelTimeout()
+	}}
+	return first.resp, nil
+}
+
+// doWithRetries sends the request, retrying on transport errors, 5xx responses and
+// responses whose body matches the context's retry_on_body rule. Requests with
+// non-idempotent methods are sent once unless canRetry allows more.
+func (c *HTTPClient) doWithRetries(ctx context.Context, req *http.Request) (*http.Response, error) {
 	req = req.WithContext(ctx)
 
+	// Attach the call's idempotency key before the first attempt so retries resend it
+	if key := idempotencyKeyFromContext(ctx); key != nil && !isIdempotentMethod(req.Method) && req.Header.Get(key.header) == "" {
+		req.Header.Set(key.header, key.value)
+	}
+
+	// Only retry requests that are safe to send twice
+	maxRetries := c.config.MaxRetries
+	if !canRetry(ctx, req) {
+		maxRetries = 0
+	}
+
+	// Retries are drawn from the backend's shared budget, which every request pays into
+	budget := retryBudgetFromContext(ctx)
+	budget.deposit()
+
 	var resp *http.Response
 	var err error
 
-	for attempt := 0; attempt <= c.config.MaxRetries; attempt++ {
+	attempts := 0
+	for attempt := 0; attempt <= maxRetries; attempt++ {
+		attempts++
+
+		// Rewind the body for retries
+		if attempt > 0 && req.GetBody != nil {
+			body, bodyErr := req.GetBody()
+			if bodyErr != nil {
+				return nil, fmt.Errorf("failed to rewind request body: %w", bodyErr)
+			}
+			req.Body = body
+		}
+
+		if attempt > 0 {
+			if stats := requestStatsFromContext(ctx); stats != nil {
+				stats.retries.Add(1)
+			}
+		}
+
 		resp, err = c.client.Do(req)
 
 		if err == nil && resp.StatusCode < 500 {
-			if cb != nil {
-				cb.RecordSuccess()
+			// A soft error reported in the body fails the attempt like a 5xx would
+			if err = checkRetryOnBody(ctx, resp); err == nil {
+				return resp, nil
 			}
-			return resp, nil
+			resp.Body.Close()
+			resp = nil
+		}
+
+		// Give up once the caller's or the endpoint's deadline has passed
+		if attempt == maxRetries || ctx.Err() != nil {
+			break
+		}
+
+		// Stop retrying while the backend's retry budget is spent
+		if !budget.withdraw() {
+			c.logger.Debug("Retry budget exhausted, not retrying backend request", "method", req.Method, "url", req.URL.String(), "attempt", attempt+1)
+			break
 		}
 
 		if resp != nil {
+			c.logger.Debug("Retrying backend request", "method", req.Method, "url", req.URL.String(), "attempt", attempt+1, "status", resp.StatusCode)
 			resp.Body.Close()
+		} else {
+			c.logger.Debug("Retrying backend request", "method", req.Method, "url", req.URL.String(), "attempt", attempt+1, "error", err)
 		}
-
-		if attempt < c.config.MaxRetries {
-			time.Sleep(c.config.RetryDelay * time.Duration(attempt+1))
+		select {
+		case <-time.After(c.config.RetryDelay * time.Duration(attempt+1)):
+		case <-ctx.Done():
+			return nil, fmt.Errorf("request failed after %d attempts: %w", attempts, ctx.Err())
 		}
 	}
 
-	if cb != nil {
+	if err != nil {
+		return nil, fmt.Errorf("request failed after %d attempts: %w", attempts, err)
+	}
+
+	return resp, nil
+}
+
+type nonIdempotentRetriesKey struct{}
+
+// withNonIdempotentRetries returns a context whose requests are retried even when
+// their method isn't idempotent
+func withNonIdempotentRetries(ctx context.Context) context.Context {
+	return context.WithValue(ctx, nonIdempotentRetriesKey{}, true)
+}
+
+// canRetry reports whether a failed request may be sent again. Idempotent methods
+// always may; POST, PATCH and other methods may when the context opts in or the
+// request carries an idempotency key the backend can deduplicate on.
+func canRetry(ctx context.Context, req *http.Request) bool {
+	if isIdempotentMethod(req.Method) || req.Header.Get(defaultIdempotencyHeader) != "" {
+		return true
+	}
+	if key := idempotencyKeyFromContext(ctx); key != nil && req.Header.Get(key.header) != "" {
+		return true
+	}
+	allowed, _ := ctx.Value(nonIdempotentRetriesKey{}).(bool)
+	return allowed
+}
+
+// defaultIdempotencyHeader is the header idempotency keys are sent in unless configured otherwise
+const defaultIdempotencyHeader = "Idempotency-Key"
+
+// idempotencyKey is a key shared by every attempt of one logical request
+type idempotencyKey struct {
+	header string
+	value  string
+}
+
+type idempotencyKeyKey struct{}
+
+// withIdempotencyKey returns a context carrying a new random key. Non-idempotent requests
+// made with it send the key in header, or Idempotency-Key when header is empty, and may
+// be retried since the backend can deduplicate them.
+func withIdempotencyKey(ctx context.Context, header string) context.Context {
+	if header == "" {
+		header = defaultIdempotencyHeader
+	}
+	return context.WithValue(ctx, idempotencyKeyKey{}, &idempotencyKey{header: header, value: uuid.NewString()})
+}
+
+// idempotencyKeyFromContext returns the idempotency key attached to ctx, if any
+func idempotencyKeyFromContext(ctx context.Context) *idempotencyKey {
+	key, _ := ctx.Value(idempotencyKeyKey{}).(*idempotencyKey)
+	return key
+}
+
+// requestStats collects details about how a logical request was carried out
+type requestStats struct {
+	retries atomic.Int64
+}
+
+type requestStatsKey struct{}
+
+// withClientTimeout bounds a request whose context has no deadline by the client's
+// Timeout. A context deadline, such as an endpoint's response timeout or the MCP
+// caller's own, bounds the request alone so a fixed client timeout never overrides it.
+func (c *HTTPClient) withClientTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if _, ok := ctx.Deadline(); ok || c.config.Timeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, c.config.Timeout)
+}
+
+// withRequestStats returns a context that collects request stats for requests made with it
+func withRequestStats(ctx context.Context) (context.Context, *requestStats) {
+	stats := &requestStats{}
+	return context.WithValue(ctx, requestStatsKey{}, stats), stats
+}
+
+// requestStatsFromContext returns the request stats attached to ctx, if any
+func requestStatsFromContext(ctx context.Context) *requestStats {
+	stats, _ := ctx.Value(requestStatsKey{}).(*requestStats)
+	return stats
+}
+
+// recordResult records the outcome of a logical request on the circuit breaker
+func recordResult(cb *CircuitBreaker, resp *http.Response, err error) {
+	if cb == nil {
+		return
+	}
+
+	if err == nil && resp.StatusCode < 500 {
+		cb.RecordSuccess()
+	} else {
 		cb.RecordFailure()
 	}
+}
 
-	if err != nil {
-		return nil, fmt.Errorf("request failed after %d attempts: %w", c.config.MaxRetries+1, err)
+// isSafeMethod reports whether a request can be duplicated without side effects
+func isSafeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
 	}
+	return false
+}
 
-	return resp, nil
+// isIdempotentMethod reports whether sending a request more than once has the same
+// effect as sending it once
+func isIdempotentMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// setDefaultHeaders sets each default header the request doesn't already carry
+func setDefaultHeaders(req *http.Request, defaults http.Header) {
+	for name, values := range defaults {
+		if _, exists := req.Header[name]; !exists {
+			req.Header[name] = slices.Clone(values)
+		}
+	}
+}
+
+// cloneRequest copies a request for an independent attempt, including a fresh body
+func cloneRequest(ctx context.Context, req *http.Request) (*http.Request, error) {
+	clone := req.Clone(ctx)
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, fmt.Errorf("failed to copy request body: %w", err)
+		}
+		clone.Body = body
+	}
+	return clone, nil
+}
+
+// cancelOnClose calls cancel when the response body is closed, to cancel a request
+// context or release a concurrency slot
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelOnClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
 }
 
 func (c *HTTPClient) Close() error {
@@ -104,12 +480,12 @@ func (c *HTTPClient) Close() error {
 }
 
 type CircuitBreaker struct {
-	mu            sync.RWMutex
-	failureCount  int
-	lastFailTime  time.Time
-	maxFailures   int
-	resetTimeout  time.Duration
-	state         string
+	mu           sync.RWMutex
+	failureCount int
+	lastFailTime time.Time
+	maxFailures  int
+	resetTimeout time.Duration
+	state        string
 }
 
 func NewCircuitBreaker(maxFailures int, resetTimeout time.Duration) *CircuitBreaker {
@@ -135,6 +511,32 @@ func (cb *CircuitBreaker) CanExecute() bool {
 	return false
 }
 
+// CircuitBreakerSnapshot describes a circuit breaker's state at one point in time
+type CircuitBreakerSnapshot struct {
+	// State is "closed", "open", or "half-open" once an open breaker's reset timeout
+	// has passed and it lets a trial request through
+	State        string    `json:"state"`
+	FailureCount int       `json:"failure_count"`
+	LastFailure  time.Time `json:"last_failure,omitzero"`
+}
+
+// Snapshot returns the breaker's current state
+func (cb *CircuitBreaker) Snapshot() CircuitBreakerSnapshot {
+	cb.mu.RLock()
+	defer cb.mu.RUnlock()
+
+	state := cb.state
+	if state == "open" && time.Since(cb.lastFailTime) > cb.resetTimeout {
+		state = "half-open"
+	}
+
+	return CircuitBreakerSnapshot{
+		State:        state,
+		FailureCount: cb.failureCount,
+		LastFailure:  cb.lastFailTime,
+	}
+}
+
 func (cb *CircuitBreaker) RecordSuccess() {
 	cb.mu.Lock()
 	defer cb.mu.Unlock()
@@ -143,6 +545,12 @@ func (cb *CircuitBreaker) RecordSuccess() {
 	cb.state = "closed"
 }
 
+// Reset closes the breaker and clears its failure count, as after a successful request.
+// The last failure time is kept for reporting.
+func (cb *CircuitBreaker) Reset() {
+	cb.RecordSuccess()
+}
+
 func (cb *CircuitBreaker) RecordFailure() {
 	cb.mu.Lock()
 	defer cb.mu.Unlock()
@@ -156,16 +564,26 @@ func (cb *CircuitBreaker) RecordFailure() {
 }
 
 type ClientManager struct {
-	clients        map[string]*HTTPClient
-	defaultClient  *HTTPClient
-	circuitBreaker *CircuitBreaker
+	clients       map[string]*HTTPClient
+	defaultClient *HTTPClient
+	recorder      *cassetteRecorder
+	limits        map[string][]*concurrencyLimit
+
+	// hosts holds the circuit breaker, retry budget and counters of each backend host;
+	// fixedHosts are the hosts of configured backends, which are never evicted
+	hostsMu    sync.Mutex
+	hosts      map[string]*backendHost
+	fixedHosts map[string]bool
+	hostUses   uint64
 }
 
 func NewClientManager() *ClientManager {
 	return &ClientManager{
-		clients:        make(map[string]*HTTPClient),
-		defaultClient:  NewHTTPClient(DefaultClientConfig()),
-		circuitBreaker: NewCircuitBreaker(5, 30*time.Second),
+		clients:       make(map[string]*HTTPClient),
+		defaultClient: NewHTTPClient(DefaultClientConfig()),
+		limits:        make(map[string][]*concurrencyLimit),
+		hosts:         make(map[string]*backendHost),
+		fixedHosts:    make(map[string]bool),
 	}
 }
 
@@ -180,9 +598,85 @@ func (cm *ClientManager) SetClient(name string, config *ClientConfig) {
 	cm.clients[name] = NewHTTPClient(config)
 }
 
+// AddClient uses client for requests made under name
+func (cm *ClientManager) AddClient(name string, client *HTTPClient) {
+	cm.clients[name] = client
+}
+
+// SetDefaultClient replaces the client used for endpoints without a named client
+func (cm *ClientManager) SetDefaultClient(config *ClientConfig) {
+	cm.defaultClient.Close()
+	cm.defaultClient = NewHTTPClient(config)
+}
+
+// SetRecord records every backend response as a cassette in dir.
+// Values of the headers and URL query parameters named in redact are replaced in the
+// saved cassettes.
+func (cm *ClientManager) SetRecord(dir string, redact []string) {
+	cm.recorder = &cassetteRecorder{dir: dir, secret: newSecretKeys(redact)}
+}
+
+// SetReplay serves backend responses from the cassettes in dir instead of the network.
+// Requests without a recorded cassette fail.
+func (cm *ClientManager) SetReplay(dir string) {
+	cm.recorder = &cassetteRecorder{dir: dir, replay: true}
+}
+
+// addConcurrencyLimit makes requests under name wait for a slot of limit. A name can
+// have several limits, e.g. its endpoint's and its backend's, taken in the order added.
+func (cm *ClientManager) addConcurrencyLimit(name string, limit *concurrencyLimit) {
+	cm.limits[name] = append(cm.limits[name], limit)
+}
+
 func (cm *ClientManager) DoRequest(ctx context.Context, req *http.Request, clientName string) (*http.Response, error) {
 	client := cm.GetClient(clientName)
-	return client.DoWithCircuitBreaker(ctx, req, cm.circuitBreaker)
+	host := cm.host(req.URL.Host)
+	ctx = withRetryBudget(ctx, host.budget)
+	return cm.do(ctx, req, clientName, host, func() (*http.Response, error) {
+		return client.DoWithCircuitBreaker(ctx, req, host.breaker)
+	})
+}
+
+// DoHedgedRequest is like DoRequest but hedges safe requests after hedgeAfter
+func (cm *ClientManager) DoHedgedRequest(ctx context.Context, req *http.Request, clientName string, hedgeAfter time.Duration) (*http.Response, error) {
+	client := cm.GetClient(clientName)
+	host := cm.host(req.URL.Host)
+	ctx = withRetryBudget(ctx, host.budget)
+	return cm.do(ctx, req, clientName, host, func() (*http.Response, error) {
+		return client.DoHedged(ctx, req, host.breaker, hedgeAfter)
+	})
+}
+
+// do sends a request through the cassette recorder when recording or replaying, and
+// decompresses encoded responses the transport didn't. Requests under a concurrency
+// limit hold their slots until the response body is closed. The outcome is counted
+// against the backend host.
+func (cm *ClientManager) do(ctx context.Context, req *http.Request, clientName string, host *backendHost, send func() (*http.Response, error)) (*http.Response, error) {
+	release, err := acquireAll(ctx, cm.limits[clientName])
+	if err != nil {
+		return nil, err
+	}
+
+	var resp *http.Response
+	if cm.recorder == nil {
+		resp, err = send()
+	} else {
+		resp, err = cm.recorder.do(ctx, req, send)
+	}
+	host.count(resp, err)
+	if err != nil {
+		release()
+		return nil, err
+	}
+
+	if err := decodeContentEncoding(resp); err != nil {
+		resp.Body.Close()
+		release()
+		return nil, err
+	}
+
+	resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: release}
+	return resp, nil
 }
 
 func (cm *ClientManager) Close() error {
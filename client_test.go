@@ -0,0 +1,264 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestIdempotencyKeyReusedAcrossRetries(t *testing.T) {
+	var (
+		mu   sync.Mutex
+		keys []string
+	)
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		keys = append(keys, r.Header.Get("X-Request-Key"))
+		attempt := len(keys)
+		mu.Unlock()
+
+		// The first attempt of the first call fails, so it is retried
+		if attempt == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprint(w, `{"ok":true}`)
+	}))
+	defer backend.Close()
+
+	s := newTestProxy(t, fmt.Sprintf(`
+backends:
+  - base_url: %s
+    endpoints:
+      - name: create_order
+        capability: tool
+        mode: client
+        method: POST
+        path: /orders
+        idempotency_key: true
+        idempotency_header: X-Request-Key
+`, backend.URL))
+
+	for i := 0; i < 2; i++ {
+		if text, isError := toolText(t, s, "create_order", nil); isError {
+			t.Fatalf("call %d failed: %s", i, text)
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(keys) != 3 {
+		t.Fatalf("backend received %d requests, want 3", len(keys))
+	}
+	if keys[0] == "" || keys[0] != keys[1] {
+		t.Errorf("attempts of one call sent keys %q and %q, want the same key", keys[0], keys[1])
+	}
+	if keys[2] == "" || keys[2] == keys[0] {
+		t.Errorf("second call sent key %q, want a new key", keys[2])
+	}
+}
+
+func TestClientManagerEvictsArgumentHosts(t *testing.T) {
+	cm := NewClientManager()
+	cm.addFixedHost("api.example.com")
+	cm.host("api.example.com").breaker.RecordFailure()
+
+	for i := range maxArgumentHosts + 10 {
+		cm.host(fmt.Sprintf("host%d.example.com", i))
+	}
+
+	statuses := cm.Status()
+	if len(statuses) != maxArgumentHosts+1 {
+		t.Errorf("tracking %d hosts, want %d argument hosts and the configured one", len(statuses), maxArgumentHosts)
+	}
+
+	cm.hostsMu.Lock()
+	defer cm.hostsMu.Unlock()
+	if host, ok := cm.hosts["api.example.com"]; !ok || host.breaker.Snapshot().FailureCount != 1 {
+		t.Error("the configured host's state was evicted")
+	}
+	if _, ok := cm.hosts["host0.example.com"]; ok {
+		t.Error("the least recently used argument host was kept")
+	}
+	if _, ok := cm.hosts[fmt.Sprintf("host%d.example.com", maxArgumentHosts+9)]; !ok {
+		t.Error("the most recent argument host was evicted")
+	}
+}
+
+// roundTripFunc serves requests from a function instead of the network
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// closeNotifier is a response body that reports when it's closed
+type closeNotifier struct {
+	io.Reader
+	closed chan struct{}
+}
+
+func (b *closeNotifier) Close() error {
+	close(b.closed)
+	return nil
+}
+
+// newHedgeClient returns a client without retries whose requests are served by rt. Each
+// request's attempt number, starting at 1, is passed to rt.
+func newHedgeClient(rt func(attempt int64, req *http.Request) (*http.Response, error)) *HTTPClient {
+	config := DefaultClientConfig()
+	config.MaxRetries = 0
+	client := NewHTTPClient(config)
+
+	var attempts atomic.Int64
+	client.client.Transport = roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		return rt(attempts.Add(1), req)
+	})
+	return client
+}
+
+func hedgeResponse(status int, body io.ReadCloser) *http.Response {
+	return &http.Response{StatusCode: status, Header: make(http.Header), Body: body}
+}
+
+func TestDoHedgedCancelsAndDrainsSlowerRequest(t *testing.T) {
+	slowCancelled := make(chan struct{})
+	release := make(chan struct{})
+	slowBody := &closeNotifier{Reader: strings.NewReader("slow"), closed: make(chan struct{})}
+
+	client := newHedgeClient(func(attempt int64, req *http.Request) (*http.Response, error) {
+		if attempt == 1 {
+			// The first attempt answers only after the hedge has won and cancelled it
+			<-req.Context().Done()
+			close(slowCancelled)
+			<-release
+			return hedgeResponse(http.StatusOK, slowBody), nil
+		}
+		return hedgeResponse(http.StatusOK, io.NopCloser(strings.NewReader("fast"))), nil
+	})
+
+	req, _ := http.NewRequest(http.MethodGet, "http://backend.test/data", nil)
+	cb := NewCircuitBreaker(5, time.Minute)
+	resp, err := client.DoHedged(context.Background(), req, cb, 10*time.Millisecond)
+	if err != nil {
+		t.Fatalf("DoHedged failed: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != "fast" {
+		t.Errorf("DoHedged returned %q, want the hedged request's response", body)
+	}
+
+	select {
+	case <-slowCancelled:
+	case <-time.After(2 * time.Second):
+		t.Fatal("the slower request wasn't cancelled")
+	}
+
+	// The slower request's response is drained once it arrives
+	close(release)
+	select {
+	case <-slowBody.closed:
+	case <-time.After(2 * time.Second):
+		t.Fatal("the slower request's response body wasn't closed")
+	}
+}
+
+func TestDoHedgedPrefersSuccess(t *testing.T) {
+	hedgeStarted := make(chan struct{})
+	client := newHedgeClient(func(attempt int64, req *http.Request) (*http.Response, error) {
+		if attempt == 1 {
+			// Fail first, while the hedged request is still in flight
+			<-hedgeStarted
+			return nil, errors.New("connection reset")
+		}
+		close(hedgeStarted)
+		time.Sleep(20 * time.Millisecond)
+		return hedgeResponse(http.StatusOK, io.NopCloser(strings.NewReader("ok"))), nil
+	})
+
+	req, _ := http.NewRequest(http.MethodGet, "http://backend.test/data", nil)
+	cb := NewCircuitBreaker(5, time.Minute)
+	resp, err := client.DoHedged(context.Background(), req, cb, 10*time.Millisecond)
+	if err != nil {
+		t.Fatalf("DoHedged returned %v, want the hedged request's success", err)
+	}
+	resp.Body.Close()
+	if got := cb.Snapshot().FailureCount; got != 0 {
+		t.Errorf("circuit breaker has %d failures, want the request recorded as one success", got)
+	}
+}
+
+func TestDoHedgedRecordsOneResult(t *testing.T) {
+	client := newHedgeClient(func(attempt int64, req *http.Request) (*http.Response, error) {
+		time.Sleep(20 * time.Millisecond)
+		return nil, fmt.Errorf("attempt %d failed", attempt)
+	})
+
+	cb := NewCircuitBreaker(5, time.Minute)
+	for i := 1; i <= 2; i++ {
+		req, _ := http.NewRequest(http.MethodGet, "http://backend.test/data", nil)
+		if _, err := client.DoHedged(context.Background(), req, cb, 5*time.Millisecond); err == nil {
+			t.Fatal("DoHedged succeeded, want both attempts' failure")
+		}
+		if got := cb.Snapshot().FailureCount; got != i {
+			t.Errorf("circuit breaker has %d failures after %d hedged requests, want %d", got, i, i)
+		}
+	}
+}
+
+func TestRetryBudgetStopsAndResumesRetries(t *testing.T) {
+	config := DefaultClientConfig()
+	config.MaxRetries = 1
+	config.RetryDelay = time.Millisecond
+	client := NewHTTPClient(config)
+
+	var attempts atomic.Int64
+	var healthy atomic.Bool
+	client.client.Transport = roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		attempts.Add(1)
+		if healthy.Load() {
+			return hedgeResponse(http.StatusOK, io.NopCloser(strings.NewReader("ok"))), nil
+		}
+		return hedgeResponse(http.StatusServiceUnavailable, io.NopCloser(strings.NewReader("busy"))), nil
+	})
+
+	// Two retries' worth of tokens, plus what each request deposits
+	budget := &retryBudget{tokens: 2}
+	ctx := withRetryBudget(context.Background(), budget)
+	send := func() int64 {
+		t.Helper()
+		before := attempts.Load()
+		req, _ := http.NewRequest(http.MethodGet, "http://backend.test/data", nil)
+		resp, err := client.Do(ctx, req)
+		if err != nil {
+			t.Fatalf("Do failed: %v", err)
+		}
+		resp.Body.Close()
+		return attempts.Load() - before
+	}
+
+	for i, want := range []int64{2, 2, 1, 1} {
+		if got := send(); got != want {
+			t.Errorf("request %d made %d attempts, want %d", i+1, got, want)
+		}
+	}
+
+	// Every request pays into the budget, so retries resume once enough have been sent
+	healthy.Store(true)
+	for range 10 {
+		send()
+	}
+	healthy.Store(false)
+	if got := send(); got != 2 {
+		t.Errorf("request after deposits made %d attempts, want a retry again", got)
+	}
+}
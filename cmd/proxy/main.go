@@ -2,14 +2,22 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"log/slog"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"strings"
 	"syscall"
 
+	"github.com/mark3labs/mcp-go/mcp"
 	proxy "github.com/paulgrammer/mcp-proxy"
+	"gopkg.in/yaml.v3"
 )
 
 // A version string that can be set with
@@ -21,24 +29,56 @@ var Build string
 
 func main() {
 	// Define command-line flags
-	configPath := flag.String("config", "config.yml", "Path to the configuration file")
+	configPath := flag.String("config", "config.yml", "Path to the configuration file, or a directory or glob of files to merge")
 	version := flag.Bool("version", false, "Print version information and exit")
+	stdio := flag.Bool("stdio", false, "Serve MCP over stdin/stdout instead of HTTP")
+	mock := flag.Bool("mock", false, "Return mock responses instead of calling backends")
+	record := flag.String("record", "", "Record backend responses as cassettes in this directory")
+	replay := flag.String("replay", "", "Replay backend responses from cassettes in this directory")
+	toolAPI := flag.Bool("tool-api", false, "Serve POST /api/tools/{name} to call tools over plain HTTP")
+	printConfig := flag.Bool("print-config", false, "Print the configuration with defaults and environment variables applied, secrets redacted, and exit")
+	logLevel := flag.String("log-level", getEnvOrDefault("LOG_LEVEL", "info"), "Log level: debug, info, warn or error")
+	logFormat := flag.String("log-format", getEnvOrDefault("LOG_FORMAT", "text"), "Log format: text or json")
+	validate := flag.Bool("validate", false, "Check the configuration, print what it serves and exit without listening")
+	strictSetup := flag.Bool("strict-setup", true, "Fail startup when an endpoint can't be set up; with --strict-setup=false such endpoints are logged and skipped")
+	printSchema := flag.Bool("print-schema", false, "Print the JSON Schema of the configuration file and exit")
+	env := flag.String("env", os.Getenv(proxy.ConfigEnvVar), "Environment whose overlay, e.g. config.prod.yml, is merged over the configuration file")
 	flag.Parse()
 
+	// The overlay is selected through the environment, so every load of the
+	// configuration uses it, including reloads
+	if *env != "" {
+		os.Setenv(proxy.ConfigEnvVar, *env)
+	}
+
 	// Handle version flag
 	if *version {
-		if Build != "" {
-			fmt.Printf("mcp-proxy version %s\n", Build)
-		} else {
-			fmt.Println("mcp-proxy version unknown (development build)")
-		}
+		printVersion(*configPath)
+		os.Exit(0)
+	}
+
+	if *validate {
+		os.Exit(validateConfig(*configPath))
+	}
+	if *printConfig {
+		os.Exit(printEffectiveConfig(*configPath))
+	}
+	if *printSchema {
+		os.Stdout.Write(proxy.ConfigJSONSchema())
+		fmt.Println()
 		os.Exit(0)
 	}
 
-	// Set up structured logging first
-	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
-		Level: slog.LevelInfo,
-	}))
+	// Set up structured logging first; stdout carries the protocol in stdio mode
+	logOutput := os.Stdout
+	if *stdio {
+		logOutput = os.Stderr
+	}
+	logger, err := newLogger(logOutput, *logLevel, *logFormat)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
 	slog.SetDefault(logger)
 
 	// Set up context for graceful shutdown
@@ -57,8 +97,20 @@ func main() {
 	// Create proxy from configuration file
 	srv, err := proxy.NewServerFromConfigFile(*configPath,
 		proxy.WithAddr(getEnvOrDefault("SERVER_ADDR", ":8888")),
+		proxy.WithAdminAddr(os.Getenv("SERVER_ADMIN_ADDR")),
 		proxy.WithBaseURL(getEnvOrDefault("SERVER_BASE_URL", "http://localhost:8888")),
+		proxy.WithTransport(getEnvOrDefault("SERVER_TRANSPORT", proxy.TransportSSE)),
+		proxy.WithSSEPath(getEnvOrDefault("SERVER_SSE_PATH", "/sse")),
+		proxy.WithMessagePath(getEnvOrDefault("SERVER_MESSAGE_PATH", "/message")),
 		proxy.WithLogger(logger),
+		proxy.WithVersion(buildVersion()),
+		proxy.WithMockMode(*mock),
+		proxy.WithRecord(*record),
+		proxy.WithReplay(*replay),
+		proxy.WithToolAPI(*toolAPI),
+		proxy.WithStrictSetup(*strictSetup),
+		proxy.WithRedactLogKeys(getEnvList("LOG_REDACT_KEYS")...),
+		proxy.WithAllowedOrigins(getEnvList("CORS_ALLOWED_ORIGINS")...),
 	)
 	if err != nil {
 		logger.Error("Failed to create proxy from config", "error", err)
@@ -68,13 +120,171 @@ func main() {
 
 	logger.Info("Server created successfully with endpoints configured")
 
+	// Reload the configuration on SIGHUP; a bad configuration leaves the current one running
+	hupChan := make(chan os.Signal, 1)
+	signal.Notify(hupChan, syscall.SIGHUP)
+	go func() {
+		for range hupChan {
+			logger.Info("Received SIGHUP, reloading configuration", "file", *configPath)
+
+			cfg, err := proxy.ParseConfig(*configPath)
+			if err != nil {
+				logger.Error("Failed to reload configuration", "error", err)
+				continue
+			}
+			if err := srv.Reload(cfg); err != nil {
+				logger.Error("Failed to reload configuration", "error", err)
+			}
+		}
+	}()
+
+	if *stdio {
+		if err := srv.ServeStdio(ctx, os.Stdin, os.Stdout); err != nil {
+			logger.Error("Failed to serve stdio", "error", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	// Start proxy
 	if err := srv.Start(ctx); err != nil {
-		logger.Error("Failed to start proxy", "error", err)
+		if errors.Is(err, syscall.EADDRINUSE) {
+			logger.Error("Failed to start proxy: address already in use, set SERVER_ADDR or SERVER_ADMIN_ADDR to a free port", "error", err)
+		} else {
+			logger.Error("Failed to start proxy", "error", err)
+		}
 		os.Exit(1)
 	}
 }
 
+// newLogger creates the logger for the given level and format
+func newLogger(output io.Writer, level, format string) (*slog.Logger, error) {
+	var logLevel slog.Level
+	if err := logLevel.UnmarshalText([]byte(level)); err != nil {
+		return nil, fmt.Errorf("invalid log level '%s', must be one of: debug, info, warn, error", level)
+	}
+
+	options := &slog.HandlerOptions{Level: logLevel}
+	switch strings.ToLower(format) {
+	case "text":
+		return slog.New(slog.NewTextHandler(output, options)), nil
+	case "json":
+		return slog.New(slog.NewJSONHandler(output, options)), nil
+	default:
+		return nil, fmt.Errorf("invalid log format '%s', must be one of: text, json", format)
+	}
+}
+
+// validateConfig parses the configuration and builds every endpoint handler without
+// listening, then prints what the proxy would serve. It returns the exit code: 1 if
+// the configuration is invalid.
+func validateConfig(configPath string) int {
+	// Only warnings and errors from setup are worth showing
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn}))
+
+	cfg, err := proxy.ParseConfig(configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid configuration: %v\n", err)
+		return 1
+	}
+
+	srv, err := proxy.NewServerFromConfig(cfg, proxy.WithLogger(logger))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid configuration: %v\n", err)
+		return 1
+	}
+	defer srv.Close()
+
+	inventory := srv.Inventory()
+	fmt.Printf("Configuration %s is valid\n", configPath)
+	for _, group := range []struct {
+		label string
+		names []string
+	}{
+		{"Tools", inventory.Tools},
+		{"Resources", inventory.Resources},
+		{"Resource templates", inventory.ResourceTemplates},
+		{"Prompts", inventory.Prompts},
+	} {
+		fmt.Printf("%s (%d)\n", group.label, len(group.names))
+		for _, name := range group.names {
+			fmt.Printf("  %s\n", name)
+		}
+	}
+
+	warnings := proxy.ConfigWarnings(cfg)
+	fmt.Printf("Warnings (%d)\n", len(warnings))
+	for _, warning := range warnings {
+		fmt.Printf("  %s\n", warning)
+	}
+
+	return 0
+}
+
+// printEffectiveConfig prints the configuration as the proxy runs it, after defaults,
+// environment variable expansion and post-processing, with secret values redacted.
+// It returns the exit code: 1 if the configuration can't be loaded.
+func printEffectiveConfig(configPath string) int {
+	cfg, err := proxy.ParseConfig(configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid configuration: %v\n", err)
+		return 1
+	}
+
+	redacted, err := proxy.RedactConfig(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to redact configuration: %v\n", err)
+		return 1
+	}
+
+	data, err := yaml.Marshal(redacted)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to marshal configuration: %v\n", err)
+		return 1
+	}
+
+	os.Stdout.Write(data)
+	return 0
+}
+
+// printVersion prints the build, toolchain and protocol versions and the config path,
+// for bug reports
+func printVersion(configPath string) {
+	if Build != "" {
+		fmt.Printf("mcp-proxy version %s\n", Build)
+	} else {
+		fmt.Println("mcp-proxy version unknown (development build)")
+	}
+
+	fmt.Printf("Go version:       %s %s/%s\n", runtime.Version(), runtime.GOOS, runtime.GOARCH)
+	fmt.Printf("MCP protocol:     %s\n", mcp.LATEST_PROTOCOL_VERSION)
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, dep := range info.Deps {
+			if dep.Path == "github.com/mark3labs/mcp-go" {
+				fmt.Printf("mcp-go version:   %s\n", dep.Version)
+			}
+		}
+	}
+
+	path := proxy.ResolveConfigPath(configPath)
+	if matches, _ := filepath.Glob(path); len(matches) == 0 {
+		fmt.Printf("Config path:      %s (not found)\n", path)
+	} else {
+		fmt.Printf("Config path:      %s\n", path)
+	}
+	if env := os.Getenv(proxy.ConfigEnvVar); env != "" {
+		fmt.Printf("Config overlay:   %s\n", proxy.OverlayPath(path, env))
+	}
+}
+
+// buildVersion returns the build version, or "dev" for development builds
+func buildVersion() string {
+	if Build != "" {
+		return Build
+	}
+	return "dev"
+}
+
 // getEnvOrDefault returns the value of the environment variable or a default value
 func getEnvOrDefault(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
@@ -82,3 +292,14 @@ func getEnvOrDefault(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+// getEnvList returns the comma-separated values of the environment variable
+func getEnvList(key string) []string {
+	var values []string
+	for _, value := range strings.Split(os.Getenv(key), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
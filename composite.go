@@ -0,0 +1,320 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// compositeStep is a step with its templates parsed
+type compositeStep struct {
+	*Step
+	path    *template.Template
+	body    *template.Template
+	headers map[string]*template.Template
+
+	// backend is the backend named by the step's backend_ref, requested through the
+	// client registered as clientName; nil for steps calling the endpoint's backend
+	backend    *Backend
+	clientName string
+}
+
+// CompositeToolHandler handles tool execution by running an endpoint's steps in order.
+// It reuses the HTTP tool handler's schema and argument handling.
+type CompositeToolHandler struct {
+	*HTTPToolHandler
+	steps []*compositeStep
+}
+
+// NewCompositeToolHandler creates a new composite tool handler
+func NewCompositeToolHandler(endpoint *Endpoint, backend *Backend, logger *slog.Logger, clientManager *ClientManager) (*CompositeToolHandler, error) {
+	steps, err := parseCompositeSteps(endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	return &CompositeToolHandler{
+		HTTPToolHandler: NewHTTPToolHandler(endpoint, backend, logger, clientManager),
+		steps:           steps,
+	}, nil
+}
+
+// useStepBackends resolves the backend_ref of each step against cfg
+func (h *CompositeToolHandler) useStepBackends(cfg *Config) {
+	for _, step := range h.steps {
+		if step.BackendRef == "" {
+			continue
+		}
+		step.backend = cfg.findBackend(step.BackendRef)
+		step.clientName = stepClientName(h.endpoint.Name, step.Name)
+	}
+}
+
+// stepClientName is the client manager name a step calling another backend is sent under
+func stepClientName(endpoint, step string) string {
+	return endpoint + "/steps/" + step
+}
+
+// parseCompositeSteps parses the path, body and header templates of an endpoint's steps
+func parseCompositeSteps(endpoint *Endpoint) ([]*compositeStep, error) {
+	steps := make([]*compositeStep, 0, len(endpoint.Steps))
+	for _, step := range endpoint.Steps {
+		parsed := &compositeStep{Step: step, headers: make(map[string]*template.Template)}
+
+		var err error
+		if parsed.path, err = parseContentTemplate(step.Name+".path", step.Path); err != nil {
+			return nil, fmt.Errorf("step '%s' has invalid path: %w", step.Name, err)
+		}
+		if step.Body != "" {
+			if parsed.body, err = parseContentTemplate(step.Name+".body", step.Body); err != nil {
+				return nil, fmt.Errorf("step '%s' has invalid body: %w", step.Name, err)
+			}
+		}
+		for _, header := range step.Headers {
+			if parsed.headers[header.Name], err = parseContentTemplate(step.Name+"."+header.Name, header.Value); err != nil {
+				return nil, fmt.Errorf("step '%s' has invalid header '%s': %w", step.Name, header.Name, err)
+			}
+		}
+
+		// Referencing a missing argument or response field fails the step
+		parsed.path.Option("missingkey=error")
+		if parsed.body != nil {
+			parsed.body.Option("missingkey=error")
+		}
+		for _, header := range parsed.headers {
+			header.Option("missingkey=error")
+		}
+
+		steps = append(steps, parsed)
+	}
+
+	return steps, nil
+}
+
+// Handler executes the tool by running each step in order. Step responses are
+// decoded as JSON when possible and made available to later steps.
+func (h *CompositeToolHandler) Handler(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	// Tag logs and backend requests with the call's request ID
+	ctx, requestID := startRequest(ctx, req.Params.Meta)
+	call := *h
+	call.HTTPToolHandler = h.HTTPToolHandler.forRequest(requestID)
+	h = &call
+
+	// Validate and coerce arguments before making any request
+	arguments, err := validateArguments(h.endpoint, req.GetArguments())
+	if err != nil {
+		return toolErrorResult("Tool '%s' received invalid arguments: %v", h.endpoint.Name, err), nil
+	}
+
+	// Let the endpoint's request transformer rewrite the arguments
+	arguments, err = h.transformArguments(ctx, arguments)
+	if err != nil {
+		return nil, err
+	}
+
+	// Serve the configured mock instead of calling the backend
+	if h.endpoint.MockResponse != nil {
+		return h.HTTPToolHandler.handleResponse(newMockHTTPResponse(h.endpoint.MockResponse))
+	}
+
+	// Constant parameters are available to templates like arguments
+	for _, params := range [][]*Param{h.endpoint.PathParameters, h.endpoint.QueryParameters, h.endpoint.BodyParams} {
+		for _, param := range params {
+			if param.ValueType == CONSTANT && param.Value != "" {
+				arguments[param.Identifier] = param.Value
+			}
+		}
+	}
+
+	// Bound all steps, including reading responses, by the endpoint's response timeout
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(h.endpoint.ResponseTimeout))
+	defer cancel()
+
+	// Apply the endpoint's retry, limit and idempotency settings to the call
+	ctx, stats := h.callContext(ctx)
+
+	data := map[string]any{
+		"args":  arguments,
+		"steps": map[string]any{},
+	}
+
+	var resp *http.Response
+	var responseBody []byte
+	for _, step := range h.steps {
+		resp, responseBody, err = h.runStep(ctx, step, data)
+		if err != nil {
+			return nil, fmt.Errorf("step '%s' failed: %w", step.Name, err)
+		}
+
+		if !h.endpoint.isSuccessStatus(resp.StatusCode) {
+			h.logger.Error("Tool execution failed",
+				"tool", h.endpoint.Name,
+				"step", step.Name,
+				"status", resp.StatusCode,
+				"response", string(responseBody),
+			)
+
+			result := toolErrorResult("Tool '%s' failed at step '%s' with status %d: %s", h.endpoint.Name, step.Name, resp.StatusCode, string(responseBody))
+			h.addResponseHeaders(result, resp)
+			result.Meta = backendResultMeta(resp, stats)
+			return result, nil
+		}
+
+		var decoded any
+		if json.Unmarshal(responseBody, &decoded) != nil {
+			decoded = string(responseBody)
+		}
+		data["steps"].(map[string]any)[step.Name] = decoded
+	}
+
+	h.logger.Debug("Tool execution successful",
+		"tool", h.endpoint.Name,
+		"steps", len(h.steps),
+	)
+
+	// Let the endpoint's response transformer rewrite the final response
+	responseBody, err = h.transformBody(ctx, resp, responseBody)
+	if err != nil {
+		return nil, err
+	}
+
+	// Reshape the final response or reduce it to the configured fields
+	responseText := string(responseBody)
+	if transformed, ok := h.transformResponse(responseBody); ok {
+		responseText = transformed
+	}
+
+	mimeType := "text/plain"
+	if json.Valid([]byte(responseText)) {
+		mimeType = "application/json"
+	}
+
+	result := h.newSuccessResult(responseText, mimeType)
+	h.addResponseHeaders(result, resp)
+	result.Meta = backendResultMeta(resp, stats)
+
+	return result, nil
+}
+
+// runStep renders and sends a single step's request and reads its response
+func (h *CompositeToolHandler) runStep(ctx context.Context, step *compositeStep, data map[string]any) (*http.Response, []byte, error) {
+	// Arguments and responses are escaped as path segments, so a value can't add
+	// segments, a query or a fragment to the step's path
+	var path strings.Builder
+	if err := step.path.Execute(&path, escapePathValues(data)); err != nil {
+		return nil, nil, fmt.Errorf("failed to render path: %w", err)
+	}
+
+	var body []byte
+	if step.body != nil {
+		var rendered bytes.Buffer
+		if err := step.body.Execute(&rendered, data); err != nil {
+			return nil, nil, fmt.Errorf("failed to render body: %w", err)
+		}
+		if !json.Valid(rendered.Bytes()) {
+			return nil, nil, fmt.Errorf("rendered body is not valid JSON: %s", rendered.String())
+		}
+		if err := checkRequestSize(h.endpoint, rendered.Bytes()); err != nil {
+			return nil, nil, fmt.Errorf("request body too large: %w", err)
+		}
+		body = rendered.Bytes()
+	}
+
+	// Each step is its own logical request, so it gets its own idempotency key
+	if h.endpoint.IdempotencyKey {
+		ctx = withIdempotencyKey(ctx, h.endpoint.IdempotencyHeader)
+	}
+
+	// Steps call the endpoint's backend unless they reference another one
+	backend, clientName := h.backend, h.endpoint.Name
+	if step.backend != nil {
+		backend, clientName = step.backend, step.clientName
+	}
+
+	// Rendered paths hold arguments and responses, so they must not leave the backend
+	target := backend.BaseURL + path.String()
+	if err := checkTargetURL(backend, target); err != nil {
+		return nil, nil, err
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, string(step.Method), target, bytes.NewReader(body))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create HTTP request: %w", err)
+	}
+
+	// The step's backend default headers and the endpoint's headers apply to every step;
+	// the step's own headers override both
+	for _, header := range backend.DefaultHeaders {
+		httpReq.Header.Set(header.Name, header.Value)
+	}
+	for _, header := range h.endpoint.Headers {
+		if value, ok := resolveHeaderValue(header, data["args"].(map[string]any)); ok {
+			httpReq.Header.Set(header.Name, value)
+		}
+	}
+	for name, value := range step.headers {
+		var rendered strings.Builder
+		if err := value.Execute(&rendered, data); err != nil {
+			return nil, nil, fmt.Errorf("failed to render header '%s': %w", name, err)
+		}
+		httpReq.Header.Set(name, rendered.String())
+	}
+	if body != nil {
+		httpReq.Header.Set("Content-Type", "application/json")
+	}
+
+	h.logger.Debug("Making HTTP request for tool step",
+		"tool", h.endpoint.Name,
+		"step", step.Name,
+		"method", step.Method,
+		"url", httpReq.URL.String(),
+	)
+
+	resp, err := h.clientManager.DoHedgedRequest(ctx, httpReq, clientName, time.Duration(h.endpoint.HedgeAfter))
+	if err != nil {
+		return nil, nil, fmt.Errorf("HTTP request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var responseBody bytes.Buffer
+	if _, err := responseBody.ReadFrom(limitBody(resp.Body, h.endpoint.MaxResponseBytes)); err != nil {
+		return nil, nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	return resp, responseBody.Bytes(), nil
+}
+
+// escapePathValues returns a copy of data with every string escaped as a path segment.
+// Segments that are only dots are escaped too, since backends resolve them.
+func escapePathValues(data any) any {
+	switch value := data.(type) {
+	case string:
+		if strings.Trim(value, ".") == "" && value != "" {
+			return strings.ReplaceAll(value, ".", "%2E")
+		}
+		return url.PathEscape(value)
+	case map[string]any:
+		escaped := make(map[string]any, len(value))
+		for key, item := range value {
+			escaped[key] = escapePathValues(item)
+		}
+		return escaped
+	case []any:
+		escaped := make([]any, len(value))
+		for i, item := range value {
+			escaped[i] = escapePathValues(item)
+		}
+		return escaped
+	default:
+		return value
+	}
+}
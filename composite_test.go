@@ -0,0 +1,182 @@
+package proxy
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestCompositeStepBackendRef(t *testing.T) {
+	users := newEchoBackend(t)
+	billing := newEchoBackend(t)
+	s := newTestProxy(t, fmt.Sprintf(`
+backends:
+  - name: billing
+    base_url: %s
+    default_headers:
+      - name: X-Billing-Key
+        type: constant
+        value: billing-secret
+  - base_url: %s
+    default_headers:
+      - name: X-Users-Key
+        type: constant
+        value: users-secret
+    endpoints:
+      - name: invoice_user
+        capability: tool
+        mode: webhook
+        steps:
+          - name: user
+            method: GET
+            path: /users/1
+          - name: invoice
+            backend_ref: billing
+            method: POST
+            path: /invoices
+`, billing.URL, users.URL))
+
+	text, isError := toolText(t, s, "invoice_user", nil)
+	if isError {
+		t.Fatalf("invoice_user failed: %s", text)
+	}
+
+	requests := users.received()
+	if len(requests) != 1 || requests[0].URL.Path != "/users/1" {
+		t.Fatalf("users backend received %d requests, want GET /users/1", len(requests))
+	}
+	if got := requests[0].Header.Get("X-Users-Key"); got != "users-secret" {
+		t.Errorf("users step sent X-Users-Key %q, want users-secret", got)
+	}
+	if got := requests[0].Header.Get("X-Billing-Key"); got != "" {
+		t.Errorf("users step sent the billing key %q", got)
+	}
+
+	requests = billing.received()
+	if len(requests) != 1 || requests[0].URL.Path != "/invoices" {
+		t.Fatalf("billing backend received %d requests, want POST /invoices", len(requests))
+	}
+	if got := requests[0].Header.Get("X-Billing-Key"); got != "billing-secret" {
+		t.Errorf("invoice step sent X-Billing-Key %q, want billing-secret", got)
+	}
+	if got := requests[0].Header.Get("X-Users-Key"); got != "" {
+		t.Errorf("invoice step sent the users key %q", got)
+	}
+}
+
+func TestCompositeStepBackendRefMustExist(t *testing.T) {
+	_, err := ParseConfigFromBytes([]byte(`
+backends:
+  - base_url: http://localhost
+    endpoints:
+      - name: invoice_user
+        capability: tool
+        mode: webhook
+        steps:
+          - name: invoice
+            backend_ref: billing
+            method: POST
+            path: /invoices
+`))
+	if err == nil {
+		t.Fatal("config with an unknown step backend_ref parsed without error")
+	}
+}
+
+func TestCompositeStepPathEscapesValues(t *testing.T) {
+	backend := newEchoBackend(t)
+	s := newTestProxy(t, fmt.Sprintf(`
+backends:
+  - base_url: %s
+    endpoints:
+      - name: user_orders
+        capability: tool
+        mode: webhook
+        body_params:
+          - identifier: user_id
+            data_type: string
+            value_type: dynamic
+            required: true
+        steps:
+          - name: user
+            method: GET
+            path: "/users/{{.args.user_id}}"
+          - name: orders
+            method: GET
+            path: "/orders/{{.steps.user.path}}"
+`, backend.URL))
+
+	text, isError := toolText(t, s, "user_orders", map[string]any{"user_id": "1/../admin?role=x"})
+	if isError {
+		t.Fatalf("user_orders failed: %s", text)
+	}
+
+	requests := backend.received()
+	if len(requests) != 2 {
+		t.Fatalf("backend received %d requests, want 2", len(requests))
+	}
+	if got := requests[0].URL.Path; got != "/users/1/../admin?role=x" || requests[0].URL.RawQuery != "" {
+		t.Errorf("user step requested path %q query %q, want the argument as one escaped segment", got, requests[0].URL.RawQuery)
+	}
+	if got := requests[0].URL.RawPath; got != "/users/1%2F..%2Fadmin%3Frole=x" {
+		t.Errorf("user step requested raw path %q, want /users/1%%2F..%%2Fadmin%%3Frole=x", got)
+	}
+	if got := requests[1].URL.Path; got != "/orders//users/1/../admin?role=x" {
+		t.Errorf("orders step requested path %q, want the previous response escaped as one segment", got)
+	}
+
+	text, isError = toolText(t, s, "user_orders", map[string]any{"user_id": ".."})
+	if isError {
+		t.Fatalf("user_orders failed: %s", text)
+	}
+	if requests := backend.received(); len(requests) != 4 || requests[2].URL.Path != "/users/.." {
+		t.Errorf("user step didn't escape a '..' argument: %v", requests)
+	}
+}
+
+func TestCompositeStepHeadersAndErrors(t *testing.T) {
+	backend := newEchoBackend(t)
+	s := newTestProxy(t, fmt.Sprintf(`
+backends:
+  - base_url: %s
+    default_headers:
+      - name: X-Backend
+        type: constant
+        value: backend
+    endpoints:
+      - name: steps
+        capability: tool
+        mode: webhook
+        headers:
+          - name: X-Endpoint
+            type: constant
+            value: endpoint
+          - name: X-Override
+            type: constant
+            value: endpoint
+        success_codes: ["201"]
+        steps:
+          - name: first
+            method: GET
+            path: /first
+            headers:
+              - name: X-Override
+                value: step
+`, backend.URL))
+
+	text, isError := toolText(t, s, "steps", nil)
+	if !isError || !strings.Contains(text, "failed at step") {
+		t.Errorf("steps returned %q (error %v), want an error result for the failed step", text, isError)
+	}
+
+	requests := backend.received()
+	if len(requests) != 1 {
+		t.Fatalf("backend received %d requests, want 1", len(requests))
+	}
+	headers := requests[0].Header
+	for name, want := range map[string]string{"X-Backend": "backend", "X-Endpoint": "endpoint", "X-Override": "step"} {
+		if got := headers.Get(name); got != want {
+			t.Errorf("first step sent %s %q, want %q", name, got, want)
+		}
+	}
+}
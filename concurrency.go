@@ -0,0 +1,56 @@
+package proxy
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// concurrencyLimit caps how many requests run at once against an endpoint or backend.
+// Requests over the limit wait for a slot until their context is done.
+type concurrencyLimit struct {
+	name  string
+	slots chan struct{}
+}
+
+// newConcurrencyLimit creates a limit of max concurrent requests, named for errors
+func newConcurrencyLimit(name string, max int) *concurrencyLimit {
+	return &concurrencyLimit{name: name, slots: make(chan struct{}, max)}
+}
+
+// acquire waits for a free slot
+func (l *concurrencyLimit) acquire(ctx context.Context) error {
+	select {
+	case l.slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("%s is at its limit of %d concurrent requests and no slot freed up in time: %w", l.name, cap(l.slots), ctx.Err())
+	}
+}
+
+// release frees a slot taken by acquire
+func (l *concurrencyLimit) release() {
+	<-l.slots
+}
+
+// acquireAll takes a slot from each limit in order. The returned function releases
+// them and is safe to call more than once.
+func acquireAll(ctx context.Context, limits []*concurrencyLimit) (func(), error) {
+	for i, limit := range limits {
+		if err := limit.acquire(ctx); err != nil {
+			for _, held := range limits[:i] {
+				held.release()
+			}
+			return nil, err
+		}
+	}
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			for _, limit := range limits {
+				limit.release()
+			}
+		})
+	}, nil
+}
@@ -0,0 +1,106 @@
+package proxy
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAcquireAllReleasesTakenSlotsOnCancel(t *testing.T) {
+	endpoint := newConcurrencyLimit("endpoint 'get_user'", 1)
+	backend := newConcurrencyLimit("backend http://backend.test", 1)
+
+	// Another request holds the backend's only slot
+	if err := backend.acquire(context.Background()); err != nil {
+		t.Fatalf("acquire failed: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := acquireAll(ctx, []*concurrencyLimit{endpoint, backend}); err == nil || !strings.Contains(err.Error(), "backend http://backend.test is at its limit") {
+		t.Fatalf("acquireAll returned %v, want the backend's limit error", err)
+	}
+	if got := len(endpoint.slots); got != 0 {
+		t.Errorf("endpoint holds %d slots after the cancelled acquire, want 0", got)
+	}
+
+	// Once the backend frees its slot, both limits can be taken and released
+	backend.release()
+	release, err := acquireAll(context.Background(), []*concurrencyLimit{endpoint, backend})
+	if err != nil {
+		t.Fatalf("acquireAll failed: %v", err)
+	}
+	release()
+	release()
+	if len(endpoint.slots) != 0 || len(backend.slots) != 0 {
+		t.Errorf("slots held after release: endpoint %d, backend %d", len(endpoint.slots), len(backend.slots))
+	}
+}
+
+func TestConcurrencyLimitsTakenEndpointFirst(t *testing.T) {
+	s := newTestProxy(t, `
+backends:
+  - base_url: http://localhost
+    max_concurrency: 4
+    endpoints:
+      - name: get_user
+        capability: tool
+        mode: client
+        method: GET
+        path: /users
+        max_concurrency: 1
+`)
+
+	limits := s.currentClients().limits["get_user"]
+	if len(limits) != 2 {
+		t.Fatalf("get_user has %d limits, want the endpoint's and the backend's", len(limits))
+	}
+	if limits[0].name != "endpoint 'get_user'" || limits[1].name != "backend http://localhost" {
+		t.Errorf("limits are taken in the order %q, %q, want the endpoint's before the backend's", limits[0].name, limits[1].name)
+	}
+}
+
+func TestConcurrencySlotHeldUntilBodyClose(t *testing.T) {
+	backend := newEchoBackend(t)
+	limit := newConcurrencyLimit("endpoint 'get_user'", 1)
+	cm := NewClientManager()
+	cm.addConcurrencyLimit("get_user", limit)
+	t.Cleanup(func() { cm.Close() })
+
+	send := func(ctx context.Context) (*http.Response, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, backend.URL+"/users", nil)
+		if err != nil {
+			t.Fatalf("failed to create request: %v", err)
+		}
+		return cm.DoRequest(ctx, req, "get_user")
+	}
+
+	resp, err := send(context.Background())
+	if err != nil {
+		t.Fatalf("DoRequest failed: %v", err)
+	}
+	if got := len(limit.slots); got != 1 {
+		t.Errorf("limit holds %d slots while the body is open, want 1", got)
+	}
+
+	// A second request waits for the slot until its context is done
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := send(ctx); err == nil {
+		t.Error("second request got a slot while the first body was open")
+	}
+
+	resp.Body.Close()
+	if got := len(limit.slots); got != 0 {
+		t.Errorf("limit holds %d slots after Body.Close, want 0", got)
+	}
+	if resp, err = send(context.Background()); err != nil {
+		t.Fatalf("DoRequest after Body.Close failed: %v", err)
+	}
+	resp.Body.Close()
+	if got := len(backend.received()); got != 2 {
+		t.Errorf("backend received %d requests, want 2", got)
+	}
+}
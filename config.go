@@ -1,12 +1,16 @@
 package proxy
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
 	"slices"
 	"strings"
 
+	"github.com/mark3labs/mcp-go/mcp"
 	"gopkg.in/yaml.v3"
 )
 
@@ -17,6 +21,9 @@ type Config struct {
 
 	// Backends configuration (multiple backends for multi-backend mode)
 	Backends []*Backend `json:"backends,omitempty" yaml:"backends,omitempty"`
+
+	// Templates are named sets of headers and parameters that endpoints include with uses
+	Templates map[string]*Template `json:"templates,omitempty" yaml:"templates,omitempty"`
 }
 
 // MCPConfig defines MCP-specific settings
@@ -26,9 +33,59 @@ type MCPConfig struct {
 
 	// Version of the MCP server
 	Version string `json:"version" yaml:"version" default:"1.0.0"`
+
+	// DefaultTimeout is the response timeout for endpoints that don't set response_timeout
+	// Default: 30 seconds
+	DefaultTimeout Duration `json:"default_timeout,omitempty" yaml:"default_timeout,omitempty"`
+
+	// ConnectTimeout bounds establishing TCP connections to backends. Default: 10 seconds
+	ConnectTimeout Duration `json:"connect_timeout,omitempty" yaml:"connect_timeout,omitempty"`
+
+	// TLSHandshakeTimeout bounds the TLS handshake with backends. Default: 10 seconds
+	TLSHandshakeTimeout Duration `json:"tls_handshake_timeout,omitempty" yaml:"tls_handshake_timeout,omitempty"`
+
+	// MaxResponseBytes caps backend responses for endpoints that don't set their own limit
+	// Default: 10 MB
+	MaxResponseBytes int64 `json:"max_response_bytes,omitempty" yaml:"max_response_bytes,omitempty"`
+
+	// MaxRequestBytes caps request bodies for endpoints that don't set their own limit
+	// Default: 10 MB
+	MaxRequestBytes int64 `json:"max_request_bytes,omitempty" yaml:"max_request_bytes,omitempty"`
+
+	// UserAgent is sent with every backend request that doesn't set its own
+	// Default: Go's HTTP client User-Agent
+	UserAgent string `json:"user_agent,omitempty" yaml:"user_agent,omitempty"`
+
+	// DefaultHeaders are constant headers sent with every backend request, such as a
+	// tracing header or a global API version. Backend and endpoint headers override them
+	DefaultHeaders []*Header `json:"default_headers,omitempty" yaml:"default_headers,omitempty"`
+
+	// RequestIDHeader carries the ID of each MCP call to backends, and is read from MCP
+	// requests that bring their own ID. Default: X-Request-ID
+	RequestIDHeader string `json:"request_id_header,omitempty" yaml:"request_id_header,omitempty"`
+
+	// Profiles name sets of endpoint tags. A client connecting with ?profile=<name> only
+	// sees the tools, resources and prompts tagged with one of the profile's tags
+	Profiles map[string][]string `json:"profiles,omitempty" yaml:"profiles,omitempty"`
+
+	// DefaultProfile is the profile of clients that don't ask for one. Default: none,
+	// so such clients see everything
+	DefaultProfile string `json:"default_profile,omitempty" yaml:"default_profile,omitempty"`
 }
 
+// ParseConfig parses and validates a config file. A directory or glob is parsed with
+// ParseConfigDir. When CONFIG_ENV is set, the overlay for that environment is merged
+// over the file with ParseConfigWithOverlay.
 func ParseConfig(filename string) (*Config, error) {
+	return ParseConfigWithOverlay(filename, os.Getenv(ConfigEnvVar))
+}
+
+// parseConfigFile parses and validates a config file, or a directory or glob of them
+func parseConfigFile(filename string) (*Config, error) {
+	if isConfigSet(filename) {
+		return ParseConfigDir(filename)
+	}
+
 	// Expand path to handle environment variables and home directory
 	expandedPath := expandPath(filename)
 
@@ -142,6 +199,22 @@ func setConfigDefaults(cfg *Config) error {
 		}
 	}
 
+	// GraphQL queries and gRPC calls are always sent as POST requests
+	for _, backend := range cfg.Backends {
+		if backend.Type != GRAPHQL && backend.Type != GRPC {
+			continue
+		}
+		for i := range backend.Endpoints {
+			if backend.Endpoints[i].Method == "" {
+				backend.Endpoints[i].Method = POST
+			}
+		}
+	}
+
+	// Endpoints take the headers and parameters of the templates they use, so they're
+	// validated along with them
+	expandTemplates(cfg)
+
 	return nil
 }
 
@@ -152,6 +225,26 @@ func validateParsedConfig(cfg *Config) error {
 		return fmt.Errorf("MCP configuration is required")
 	}
 
+	// Validate global default headers; there are no arguments to fill dynamic ones from
+	for _, header := range cfg.MCP.DefaultHeaders {
+		if header.Type != CONSTANT {
+			return fmt.Errorf("global default header '%s' must be constant", header.Name)
+		}
+	}
+	if err := validateConstantHeaders(cfg.MCP.DefaultHeaders); err != nil {
+		return fmt.Errorf("global default headers: %w", err)
+	}
+
+	// Validate capability profiles
+	if err := validateProfiles(cfg.MCP); err != nil {
+		return err
+	}
+
+	// Validate templates, which endpoints have been merged with by setConfigDefaults
+	if err := validateTemplates(cfg); err != nil {
+		return err
+	}
+
 	// Validate backends
 	if len(cfg.Backends) == 0 {
 		return fmt.Errorf("at least one backend must be configured")
@@ -164,21 +257,145 @@ func validateParsedConfig(cfg *Config) error {
 		}
 	}
 
+	// Validate backend names and references
+	if err := validateBackendRefs(cfg); err != nil {
+		return err
+	}
+
 	return nil
 }
 
+// validateBackendRefs checks that backend names are unique, that every endpoint's
+// backend_ref names a backend of the same type as the one the endpoint is nested under,
+// and that composite steps only reference http backends
+func validateBackendRefs(cfg *Config) error {
+	names := make(map[string]bool)
+	for i, backend := range cfg.Backends {
+		if backend.Name == "" {
+			continue
+		}
+		if names[backend.Name] {
+			return fmt.Errorf("backend %d validation failed: duplicate backend name '%s'", i, backend.Name)
+		}
+		names[backend.Name] = true
+	}
+
+	for i, backend := range cfg.Backends {
+		for j, endpoint := range backend.Endpoints {
+			if err := validateStepBackendRefs(cfg, &endpoint); err != nil {
+				return fmt.Errorf("backend %d endpoint %d validation failed: %w", i, j, err)
+			}
+			if endpoint.BackendRef == "" {
+				continue
+			}
+
+			target := cfg.findBackend(endpoint.BackendRef)
+			if target == nil {
+				return fmt.Errorf("backend %d endpoint %d validation failed: backend_ref '%s' does not name a backend", i, j, endpoint.BackendRef)
+			}
+			if backendType(target) != backendType(backend) {
+				return fmt.Errorf("backend %d endpoint %d validation failed: backend_ref '%s' is a %s backend, but the endpoint is nested under a %s backend",
+					i, j, endpoint.BackendRef, backendType(target), backendType(backend))
+			}
+		}
+	}
+
+	return nil
+}
+
+// validateStepBackendRefs checks that every composite step's backend_ref names an http
+// backend
+func validateStepBackendRefs(cfg *Config, endpoint *Endpoint) error {
+	for _, step := range endpoint.Steps {
+		if step.BackendRef == "" {
+			continue
+		}
+		target := cfg.findBackend(step.BackendRef)
+		if target == nil {
+			return fmt.Errorf("step '%s' backend_ref '%s' does not name a backend", step.Name, step.BackendRef)
+		}
+		if backendType(target) != HTTP {
+			return fmt.Errorf("step '%s' backend_ref '%s' is a %s backend, but steps call http backends", step.Name, step.BackendRef, backendType(target))
+		}
+	}
+	return nil
+}
+
+// backendType returns a backend's type, defaulting to http
+func backendType(backend *Backend) BackendType {
+	if backend.Type == "" {
+		return HTTP
+	}
+	return backend.Type
+}
+
 // validateBackend validates a single backend configuration
 func validateBackend(backend *Backend, index int) error {
-	// Validate base URL
-	if backend.BaseURL == "" {
+	// Validate backend type
+	validTypes := []string{"", string(HTTP), string(GRAPHQL), string(GRPC)}
+	if !slices.Contains(validTypes, string(backend.Type)) {
+		return fmt.Errorf("invalid backend type '%s', must be one of: %s",
+			backend.Type, strings.Join(validTypes[1:], ", "))
+	}
+
+	// Validate base URL; backends serving only inline prompts or endpoints that reference
+	// other backends never make requests, while named backends may be referenced
+	needsBaseURL := backend.Name != "" || slices.ContainsFunc(backend.Endpoints, func(e Endpoint) bool {
+		return !e.HasInlineMessages() && e.BackendRef == ""
+	})
+	if backend.BaseURL == "" && needsBaseURL {
 		return fmt.Errorf("base_url is required")
 	}
 
-	// Validate endpoints
-	if len(backend.Endpoints) == 0 {
+	// Validate endpoints; named backends may only serve endpoints that reference them
+	if len(backend.Endpoints) == 0 && backend.Name == "" {
 		return fmt.Errorf("at least one endpoint must be configured")
 	}
 
+	// Validate the concurrency limit
+	if backend.MaxConcurrency < 0 {
+		return fmt.Errorf("max_concurrency must not be negative")
+	}
+
+	// Validate the redirect policy
+	if backend.Redirects != "" {
+		if backend.Type == GRPC {
+			return fmt.Errorf("redirects is only supported for http and graphql backends")
+		}
+		validPolicies := []RedirectPolicy{REDIRECT_SAME_HOST, REDIRECT_FOLLOW, REDIRECT_NONE}
+		if !slices.Contains(validPolicies, backend.Redirects) {
+			return fmt.Errorf("invalid redirects '%s', must be one of: same_host, follow, none", backend.Redirects)
+		}
+	}
+
+	// Validate the health check
+	if backend.HealthCheck != nil {
+		if backend.Type == GRPC {
+			return fmt.Errorf("health_check is only supported for http and graphql backends")
+		}
+		if backend.HealthCheck.ExpectedStatus != 0 && (backend.HealthCheck.ExpectedStatus < 100 || backend.HealthCheck.ExpectedStatus > 599) {
+			return fmt.Errorf("invalid health_check expected_status %d", backend.HealthCheck.ExpectedStatus)
+		}
+	}
+
+	// Validate the login request
+	if backend.Login != nil {
+		if backend.Type == GRPC {
+			return fmt.Errorf("login is only supported for http and graphql backends")
+		}
+		if backend.Login.Path == "" {
+			return fmt.Errorf("login path is required")
+		}
+		if backend.Login.Method != "" && !slices.Contains([]Method{GET, POST, PUT, PATCH}, backend.Login.Method) {
+			return fmt.Errorf("invalid login method '%s'", backend.Login.Method)
+		}
+	}
+
+	// Validate constant default header values
+	if err := validateConstantHeaders(backend.DefaultHeaders); err != nil {
+		return fmt.Errorf("default headers: %w", err)
+	}
+
 	// Validate each endpoint
 	endpointNames := make(map[string]bool)
 	for j, endpoint := range backend.Endpoints {
@@ -186,6 +403,60 @@ func validateBackend(backend *Backend, index int) error {
 			return fmt.Errorf("endpoint %d validation failed: %w", j, err)
 		}
 
+		// Streaming reads plain HTTP responses
+		if endpoint.Stream && backend.Type != "" && backend.Type != HTTP {
+			return fmt.Errorf("endpoint %d validation failed: stream is only supported for http backends", j)
+		}
+
+		// Validate GraphQL endpoints
+		if backend.Type == GRAPHQL {
+			if endpoint.Capability != TOOL {
+				return fmt.Errorf("endpoint %d validation failed: graphql backends only support tool endpoints", j)
+			}
+			if endpoint.Query == "" {
+				return fmt.Errorf("endpoint %d validation failed: query is required for graphql endpoints", j)
+			}
+		} else if endpoint.Query != "" {
+			return fmt.Errorf("endpoint %d validation failed: query is only supported for graphql backends", j)
+		}
+
+		// Validate gRPC endpoints
+		if backend.Type == GRPC {
+			if endpoint.Capability != TOOL {
+				return fmt.Errorf("endpoint %d validation failed: grpc backends only support tool endpoints", j)
+			}
+			if _, _, err := splitGRPCMethod(endpoint.RPC); err != nil {
+				return fmt.Errorf("endpoint %d validation failed: %w", j, err)
+			}
+		} else if endpoint.RPC != "" {
+			return fmt.Errorf("endpoint %d validation failed: rpc is only supported for grpc backends", j)
+		}
+
+		// GraphQL variables and gRPC messages are objects
+		if endpoint.ArrayBody && backend.Type != "" && backend.Type != HTTP {
+			return fmt.Errorf("endpoint %d validation failed: array_body is only supported for http backends", j)
+		}
+
+		if len(endpoint.Steps) > 0 && backend.Type != "" && backend.Type != HTTP {
+			return fmt.Errorf("endpoint %d validation failed: steps are only supported for http backends", j)
+		}
+
+		if endpoint.Passthrough != nil && backend.Type != "" && backend.Type != HTTP {
+			return fmt.Errorf("endpoint %d validation failed: passthrough is only supported for http backends", j)
+		}
+
+		if (endpoint.TransformRequest != "" || endpoint.TransformResponse != "") && backend.Type == GRPC {
+			return fmt.Errorf("endpoint %d validation failed: transform_request and transform_response are only supported for http and graphql backends", j)
+		}
+
+		if len(endpoint.SuccessCodes) > 0 && backend.Type == GRPC {
+			return fmt.Errorf("endpoint %d validation failed: success_codes is only supported for http and graphql backends", j)
+		}
+
+		if endpoint.RetryOnBody != nil && backend.Type == GRPC {
+			return fmt.Errorf("endpoint %d validation failed: retry_on_body is only supported for http and graphql backends", j)
+		}
+
 		// Check for duplicate endpoint names
 		if endpointNames[endpoint.Name] {
 			return fmt.Errorf("duplicate endpoint name '%s'", endpoint.Name)
@@ -203,7 +474,7 @@ func validateEndpoint(endpoint Endpoint, index int) error {
 		return fmt.Errorf("name is required")
 	}
 
-	if endpoint.Path == "" {
+	if endpoint.Path == "" && !endpoint.HasInlineMessages() && endpoint.Query == "" && endpoint.RPC == "" && len(endpoint.Steps) == 0 && endpoint.Passthrough == nil {
 		return fmt.Errorf("path is required")
 	}
 
@@ -224,16 +495,334 @@ func validateEndpoint(endpoint Endpoint, index int) error {
 	}
 
 	// Validate HTTP method
-	validMethods := []string{string(GET), string(POST), string(PUT), string(PATCH), string(DELETE)}
-	if !slices.Contains(validMethods, string(endpoint.Method)) {
+	validMethods := []string{string(GET), string(POST), string(PUT), string(PATCH), string(DELETE), string(HEAD), string(OPTIONS), string(UPDATE)}
+	if !slices.Contains(validMethods, string(endpoint.Method)) && !endpoint.HasInlineMessages() && len(endpoint.Steps) == 0 && endpoint.Passthrough == nil {
 		return fmt.Errorf("invalid HTTP method '%s'", endpoint.Method)
 	}
 
+	// Validate inline prompt messages
+	for i, message := range endpoint.Messages {
+		if endpoint.Capability != PROMPT {
+			return fmt.Errorf("messages are only supported for prompt endpoints")
+		}
+		validRoles := []string{"system", string(mcp.RoleUser), string(mcp.RoleAssistant)}
+		if !slices.Contains(validRoles, message.Role) {
+			return fmt.Errorf("message %d has invalid role '%s', must be one of: %s",
+				i, message.Role, strings.Join(validRoles, ", "))
+		}
+	}
+
+	// Validate raw body parameters
+	for _, params := range [][]*Param{endpoint.PathParameters, endpoint.QueryParameters} {
+		for _, param := range params {
+			if param.DataType == RAW_BODY {
+				return fmt.Errorf("raw_body parameter '%s' must be a body parameter", param.Identifier)
+			}
+		}
+	}
+	if param := rawBodyParam(&endpoint); param != nil {
+		if len(endpoint.BodyParams) > 1 {
+			return fmt.Errorf("raw_body parameter '%s' can't be combined with other body parameters", param.Identifier)
+		}
+		if param.ValueType == CONSTANT && param.Value != "" && !json.Valid([]byte(param.Value)) {
+			return fmt.Errorf("raw_body parameter '%s' value is not valid JSON", param.Identifier)
+		}
+	}
+
+	// Validate array bodies
+	if endpoint.ArrayBody {
+		if len(endpoint.BodyParams) != 1 {
+			return fmt.Errorf("array_body requires exactly one body parameter")
+		}
+		param := endpoint.BodyParams[0]
+		if !strings.EqualFold(string(param.DataType), "array") {
+			return fmt.Errorf("array_body parameter '%s' must have data_type array", param.Identifier)
+		}
+		if param.ValueType == CONSTANT {
+			var array []any
+			if err := json.Unmarshal([]byte(param.Value), &array); err != nil {
+				return fmt.Errorf("array_body parameter '%s' value is not a JSON array", param.Identifier)
+			}
+		}
+		if len(endpoint.Steps) > 0 {
+			return fmt.Errorf("array_body can't be combined with steps")
+		}
+	}
+
+	// Validate query styles
+	for _, params := range [][]*Param{endpoint.PathParameters, endpoint.BodyParams} {
+		for _, param := range params {
+			if param.Style != "" {
+				return fmt.Errorf("style is only supported for query parameters, not '%s'", param.Identifier)
+			}
+		}
+	}
+	for _, param := range endpoint.QueryParameters {
+		if param.Style != "" && param.Style != REPEAT && param.Style != COMMA_SEPARATED {
+			return fmt.Errorf("query parameter '%s' has invalid style '%s', must be one of: %s, %s",
+				param.Identifier, param.Style, REPEAT, COMMA_SEPARATED)
+		}
+	}
+
+	// Validate parameter defaults and constant values
+	for _, params := range [][]*Param{endpoint.PathParameters, endpoint.QueryParameters, endpoint.BodyParams} {
+		for _, param := range params {
+			if err := validateParamDefault(param); err != nil {
+				return err
+			}
+			if param.ValueType == CONSTANT && param.Value == "" {
+				return fmt.Errorf("constant parameter '%s' has no value", param.Identifier)
+			}
+			if err := validateParamRange(param); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Validate constant header values
+	if err := validateConstantHeaders(endpoint.Headers); err != nil {
+		return err
+	}
+
+	// Validate pagination
+	if endpoint.Pagination != nil {
+		if endpoint.Pagination.CursorPath == "" || endpoint.Pagination.PageParam == "" {
+			return fmt.Errorf("pagination requires cursor_path and page_param")
+		}
+		if endpoint.Pagination.MaxPages < 0 {
+			return fmt.Errorf("pagination max_pages must not be negative")
+		}
+	}
+
+	// Validate streaming
+	if endpoint.Stream {
+		if endpoint.Capability != TOOL && endpoint.Capability != RESOURCE {
+			return fmt.Errorf("stream is only supported for tool and resource endpoints")
+		}
+		if len(endpoint.Steps) > 0 || endpoint.Pagination != nil || endpoint.HedgeAfter > 0 {
+			return fmt.Errorf("stream can't be combined with steps, pagination or hedge_after")
+		}
+	}
+	if endpoint.IdleTimeout < 0 {
+		return fmt.Errorf("idle_timeout must not be negative")
+	} else if endpoint.IdleTimeout > 0 && !endpoint.Stream {
+		return fmt.Errorf("idle_timeout is only supported for stream endpoints")
+	}
+
+	// Validate binary responses
+	if endpoint.Binary && endpoint.Capability != RESOURCE {
+		return fmt.Errorf("binary is only supported for resource endpoints")
+	}
+
+	// Validate composite steps
+	if len(endpoint.Steps) > 0 {
+		if endpoint.Capability != TOOL {
+			return fmt.Errorf("steps are only supported for tool endpoints")
+		}
+		stepNames := make(map[string]bool)
+		for i, step := range endpoint.Steps {
+			if step.Name == "" || step.Path == "" {
+				return fmt.Errorf("step %d requires name and path", i)
+			}
+			if stepNames[step.Name] {
+				return fmt.Errorf("duplicate step name '%s'", step.Name)
+			}
+			stepNames[step.Name] = true
+			if !slices.Contains(validMethods, string(step.Method)) {
+				return fmt.Errorf("step '%s' has invalid HTTP method '%s'", step.Name, step.Method)
+			}
+		}
+		if _, err := parseCompositeSteps(&endpoint); err != nil {
+			return err
+		}
+	}
+
+	// Validate passthrough tools
+	if endpoint.Passthrough != nil {
+		if endpoint.Capability != TOOL {
+			return fmt.Errorf("passthrough is only supported for tool endpoints")
+		}
+		if endpoint.Path != "" || len(endpoint.PathParameters) > 0 || len(endpoint.QueryParameters) > 0 || len(endpoint.BodyParams) > 0 {
+			return fmt.Errorf("passthrough can't be combined with path or parameters; the LLM supplies them")
+		}
+		if len(endpoint.Steps) > 0 || endpoint.InputSchema != nil || endpoint.Stream || endpoint.Pagination != nil {
+			return fmt.Errorf("passthrough can't be combined with steps, input_schema, stream or pagination")
+		}
+		if len(endpoint.Passthrough.AllowedPaths) == 0 {
+			return fmt.Errorf("passthrough requires allowed_paths")
+		}
+		if _, err := compileAllowedPaths(endpoint.Passthrough.AllowedPaths); err != nil {
+			return err
+		}
+		for _, method := range endpoint.Passthrough.AllowedMethods {
+			if !slices.Contains(validMethods, strings.ToUpper(string(method))) {
+				return fmt.Errorf("passthrough has invalid HTTP method '%s'", method)
+			}
+		}
+	}
+
+	// Validate the input schema
+	if endpoint.InputSchema != nil {
+		if endpoint.Capability != TOOL {
+			return fmt.Errorf("input_schema is only supported for tool endpoints")
+		}
+		if len(endpoint.BodyParams) > 0 {
+			return fmt.Errorf("input_schema can't be combined with body_params")
+		}
+		if err := validateInputSchema(endpoint.InputSchema); err != nil {
+			return err
+		}
+	}
+
+	// Validate soft error retries; checking a streamed body would hold up the stream
+	if endpoint.RetryOnBody != nil {
+		if endpoint.Stream {
+			return fmt.Errorf("retry_on_body can't be combined with stream")
+		}
+		if err := endpoint.RetryOnBody.validate(); err != nil {
+			return err
+		}
+	}
+
+	// Validate transformers; which are registered is only known when endpoints are set up
+	if endpoint.TransformRequest != "" || endpoint.TransformResponse != "" {
+		if endpoint.Capability == PROMPT {
+			return fmt.Errorf("transform_request and transform_response are only supported for tool and resource endpoints")
+		}
+		if endpoint.TransformResponse != "" && (endpoint.Stream || endpoint.Pagination != nil) {
+			return fmt.Errorf("transform_response can't be combined with stream or pagination")
+		}
+	}
+
+	// Validate the jq expression
+	if endpoint.ResponseJQ != "" {
+		if endpoint.Capability == PROMPT {
+			return fmt.Errorf("response_jq is only supported for tool and resource endpoints")
+		}
+		if len(endpoint.ResponseFields) > 0 {
+			return fmt.Errorf("response_jq can't be combined with response_fields")
+		}
+		if _, err := compileJQ(endpoint.ResponseJQ); err != nil {
+			return err
+		}
+	}
+
+	// Validate response headers
+	if len(endpoint.ResponseHeaders) > 0 && endpoint.Capability != TOOL {
+		return fmt.Errorf("response_headers is only supported for tool endpoints")
+	}
+
+	// Validate body limits
+	if endpoint.MaxResponseBytes < 0 || endpoint.MaxRequestBytes < 0 {
+		return fmt.Errorf("max_response_bytes and max_request_bytes must not be negative")
+	}
+
+	// Validate the response timeout; zero takes the default
+	if endpoint.ResponseTimeout < 0 {
+		return fmt.Errorf("response_timeout must not be negative")
+	}
+
+	// Validate the concurrency limit
+	if endpoint.MaxConcurrency < 0 {
+		return fmt.Errorf("max_concurrency must not be negative")
+	}
+
+	// Validate response format
+	if endpoint.ResponseFormat != "" {
+		validFormats := []string{string(JSON), string(XML), string(CSV), string(TEXT)}
+		if !slices.Contains(validFormats, string(endpoint.ResponseFormat)) {
+			return fmt.Errorf("invalid response_format '%s', must be one of: %s",
+				endpoint.ResponseFormat, strings.Join(validFormats, ", "))
+		}
+	}
+
+	// Validate result packaging
+	if endpoint.ResultAs != "" {
+		if endpoint.Capability != TOOL {
+			return fmt.Errorf("result_as is only supported for tool endpoints")
+		}
+		validResultAs := []string{string(RESULT_TEXT), string(RESULT_JSON), string(RESULT_RESOURCE)}
+		if !slices.Contains(validResultAs, string(endpoint.ResultAs)) {
+			return fmt.Errorf("invalid result_as '%s', must be one of: %s",
+				endpoint.ResultAs, strings.Join(validResultAs, ", "))
+		}
+	}
+
+	// Validate content template
+	if endpoint.ContentTemplate != "" {
+		if _, err := parseContentTemplate(endpoint.Name, endpoint.ContentTemplate); err != nil {
+			return fmt.Errorf("invalid content_template: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// ConfigWarnings reports problems in a valid configuration that are likely mistakes,
+// such as endpoints the LLM can't pick well for lack of a description
+func ConfigWarnings(cfg *Config) []string {
+	var warnings []string
+	for i, backend := range cfg.Backends {
+		for _, endpoint := range backend.Endpoints {
+			prefix := fmt.Sprintf("backend %d endpoint '%s'", i, endpoint.Name)
+
+			if endpoint.Description == "" {
+				warnings = append(warnings, fmt.Sprintf("%s has no description", prefix))
+			}
+
+			for _, params := range [][]*Param{endpoint.PathParameters, endpoint.QueryParameters, endpoint.BodyParams} {
+				for _, param := range params {
+					if param.ValueType == DYNAMIC && param.Description == "" {
+						warnings = append(warnings, fmt.Sprintf("%s parameter '%s' has no description", prefix, param.Identifier))
+					}
+				}
+			}
+
+			// Path placeholders and path parameters should match up
+			declared := make(map[string]bool)
+			for _, param := range endpoint.PathParameters {
+				declared[param.Identifier] = true
+				if !strings.Contains(endpoint.Path, "{"+param.Identifier+"}") {
+					warnings = append(warnings, fmt.Sprintf("%s path parameter '%s' doesn't appear in path '%s'", prefix, param.Identifier, endpoint.Path))
+				}
+			}
+			for _, placeholder := range placeholderPattern.FindAllString(endpoint.Path, -1) {
+				if name := strings.Trim(placeholder, "{}"); !declared[name] {
+					warnings = append(warnings, fmt.Sprintf("%s path placeholder '%s' has no path parameter", prefix, placeholder))
+				}
+			}
+		}
+	}
+	return warnings
+}
+
+// validateConstantHeaders checks that constant headers have a value once environment
+// variables are expanded, so an unset variable holding an API key fails config load
+// rather than every request
+func validateConstantHeaders(headers []*Header) error {
+	for _, header := range headers {
+		if header.Type != CONSTANT || os.ExpandEnv(header.Value) != "" {
+			continue
+		}
+		if header.Value != "" {
+			return fmt.Errorf("constant header '%s' is empty after expanding '%s'; check that its environment variables are set", header.Name, header.Value)
+		}
+		return fmt.Errorf("constant header '%s' has no value", header.Name)
+	}
 	return nil
 }
 
 // postProcessParsedConfig performs post-processing on the parsed configuration
 func postProcessParsedConfig(cfg *Config) error {
+	// Expand environment variables in global settings
+	if cfg.MCP != nil {
+		cfg.MCP.UserAgent = os.ExpandEnv(cfg.MCP.UserAgent)
+		for _, header := range cfg.MCP.DefaultHeaders {
+			header.Name = os.ExpandEnv(header.Name)
+			header.Value = os.ExpandEnv(header.Value)
+		}
+	}
+
 	// Process environment variable substitution for all backends
 	for _, backend := range cfg.Backends {
 		if err := processBackendEnvironmentVars(backend); err != nil {
@@ -255,6 +844,15 @@ func processBackendEnvironmentVars(backend *Backend) error {
 		header.Value = os.ExpandEnv(header.Value)
 	}
 
+	// Expand environment variables in the login request, which typically holds credentials
+	if backend.Login != nil {
+		backend.Login.Body = os.ExpandEnv(backend.Login.Body)
+		for _, header := range backend.Login.Headers {
+			header.Name = os.ExpandEnv(header.Name)
+			header.Value = os.ExpandEnv(header.Value)
+		}
+	}
+
 	// Process environment variables in endpoints
 	for i := range backend.Endpoints {
 		processEndpointEnvironmentVars(&backend.Endpoints[i])
@@ -296,6 +894,32 @@ func processParamEnvironmentVars(param *Param) {
 	// Note: We don't expand Value field as it's used by the LLM for dynamic extraction
 }
 
+// hashConfig returns a short, stable hash of the normalized configuration.
+// It is used to identify which configuration a running proxy is serving.
+func hashConfig(cfg *Config) string {
+	if cfg == nil {
+		return ""
+	}
+
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return ""
+	}
+
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])[:12]
+}
+
+// ResolveConfigPath returns the absolute path of the file a config path refers to,
+// after expanding environment variables and the home directory
+func ResolveConfigPath(filename string) string {
+	path := expandPath(filename)
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return path
+}
+
 // expandPath expands environment variables and home directory in paths
 func expandPath(path string) string {
 	// Expand environment variables
@@ -0,0 +1,128 @@
+package proxy
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseResponseTimeout(t *testing.T) {
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"45s", 45 * time.Second},
+		{"1m30s", 90 * time.Second},
+		{"45", 45 * time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			cfg, err := ParseConfigFromBytes([]byte(`
+backends:
+  - base_url: http://localhost
+    endpoints:
+      - name: get_status
+        capability: tool
+        mode: client
+        method: GET
+        path: /status
+        response_timeout: ` + tt.value + `
+`))
+			if err != nil {
+				t.Fatalf("failed to parse config: %v", err)
+			}
+			if got := time.Duration(cfg.Backends[0].Endpoints[0].ResponseTimeout); got != tt.want {
+				t.Errorf("response_timeout %s parsed as %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTemplatesValidatedWithEndpoints(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		endpoint string
+		wantErr  string
+	}{
+		{
+			name: "array body with a template body param",
+			template: `
+      body_params:
+        - identifier: trace
+          data_type: string
+          value_type: dynamic`,
+			endpoint: `
+        array_body: true
+        body_params:
+          - identifier: items
+            data_type: array
+            value_type: dynamic`,
+			wantErr: "array_body requires exactly one body parameter",
+		},
+		{
+			name: "input schema with a template body param",
+			template: `
+      body_params:
+        - identifier: trace
+          data_type: string
+          value_type: dynamic`,
+			endpoint: `
+        input_schema:
+          type: object
+          properties:
+            q:
+              type: string`,
+			wantErr: "input_schema can't be combined with body_params",
+		},
+		{
+			name: "style on a template body param",
+			template: `
+      body_params:
+        - identifier: tags
+          data_type: array
+          value_type: dynamic
+          style: csv`,
+			wantErr: "style is only supported for query parameters",
+		},
+		{
+			name: "valid template",
+			template: `
+      headers:
+        - name: X-Trace
+          type: constant
+          value: "1"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := ParseConfigFromBytes([]byte(`
+templates:
+  shared:` + tt.template + `
+backends:
+  - base_url: http://localhost
+    endpoints:
+      - name: create_items
+        capability: tool
+        mode: client
+        method: POST
+        path: /items
+        uses: [shared]` + tt.endpoint + `
+`))
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("failed to parse config: %v", err)
+				}
+				if headers := cfg.Backends[0].Endpoints[0].Headers; len(headers) != 1 || headers[0].Name != "X-Trace" {
+					t.Errorf("endpoint headers = %v, want the template's X-Trace", headers)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("got error %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package proxy
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// isConfigSet reports whether a config path names a directory or glob of config files
+// rather than a single file
+func isConfigSet(path string) bool {
+	expanded := expandPath(path)
+	if strings.ContainsAny(expanded, "*?[") {
+		return true
+	}
+	info, err := os.Stat(expanded)
+	return err == nil && info.IsDir()
+}
+
+// configFiles returns the files a directory or glob refers to, in lexical order.
+// A directory contributes its .yml and .yaml files.
+func configFiles(path string) ([]string, error) {
+	expanded := expandPath(path)
+
+	var files []string
+	if info, err := os.Stat(expanded); err == nil && info.IsDir() {
+		for _, pattern := range []string{"*.yml", "*.yaml"} {
+			matches, err := filepath.Glob(filepath.Join(expanded, pattern))
+			if err != nil {
+				return nil, err
+			}
+			files = append(files, matches...)
+		}
+	} else {
+		matches, err := filepath.Glob(expanded)
+		if err != nil {
+			return nil, fmt.Errorf("invalid config pattern '%s': %w", expanded, err)
+		}
+		files = matches
+	}
+
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no config files found at '%s'", expanded)
+	}
+	slices.Sort(files)
+	return files, nil
+}
+
+// ParseConfigDir parses every config file in a directory or matching a glob and merges
+// them into one configuration, which is then validated as a whole. Backends and
+// templates are combined across files; the MCP settings come from the one base file
+// that has an mcp section. Backend, template and endpoint names must be unique across
+// files.
+func ParseConfigDir(path string) (*Config, error) {
+	files, err := configFiles(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var cfg Config
+	var mcpFile string
+	backendFiles := make(map[string]string)
+	endpointFiles := make(map[string]string)
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read config file '%s': %w", file, err)
+		}
+
+		var part Config
+		if err := yaml.Unmarshal(data, &part); err != nil {
+			return nil, fmt.Errorf("failed to parse YAML config '%s': %w", file, err)
+		}
+
+		if part.MCP != nil {
+			if mcpFile != "" {
+				return nil, fmt.Errorf("mcp settings are set in both '%s' and '%s'; keep them in one base file", mcpFile, file)
+			}
+			cfg.MCP = part.MCP
+			mcpFile = file
+		}
+
+		for _, backend := range part.Backends {
+			if backend.Name != "" {
+				if other, ok := backendFiles[backend.Name]; ok {
+					return nil, fmt.Errorf("duplicate backend name '%s' in '%s' and '%s'", backend.Name, other, file)
+				}
+				backendFiles[backend.Name] = file
+			}
+			for _, endpoint := range backend.Endpoints {
+				key := string(endpoint.Capability) + " " + endpoint.Name
+				if other, ok := endpointFiles[key]; ok && other != file {
+					return nil, fmt.Errorf("duplicate %s name '%s' in '%s' and '%s'", endpoint.Capability, endpoint.Name, other, file)
+				}
+				endpointFiles[key] = file
+			}
+		}
+		cfg.Backends = append(cfg.Backends, part.Backends...)
+
+		for name, template := range part.Templates {
+			if _, ok := cfg.Templates[name]; ok {
+				return nil, fmt.Errorf("duplicate template name '%s' in '%s'", name, file)
+			}
+			if cfg.Templates == nil {
+				cfg.Templates = make(map[string]*Template)
+			}
+			cfg.Templates[name] = template
+		}
+	}
+
+	// Set defaults if needed
+	if err := setConfigDefaults(&cfg); err != nil {
+		return nil, fmt.Errorf("failed to set config defaults: %w", err)
+	}
+
+	// Validate the merged configuration
+	if err := validateParsedConfig(&cfg); err != nil {
+		return nil, fmt.Errorf("config validation failed: %w", err)
+	}
+
+	// Post-process the configuration
+	if err := postProcessParsedConfig(&cfg); err != nil {
+		return nil, fmt.Errorf("failed to post-process config: %w", err)
+	}
+
+	return &cfg, nil
+}
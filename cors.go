@@ -0,0 +1,63 @@
+package proxy
+
+import (
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+)
+
+// isLocalOrigin reports whether an Origin header names a page served from this machine
+func isLocalOrigin(origin string) bool {
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	host := u.Hostname()
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// isSameOrigin reports whether an Origin header names the host the request was sent to
+func isSameOrigin(r *http.Request, origin string) bool {
+	u, err := url.Parse(origin)
+	return err == nil && u.Host == r.Host
+}
+
+// allowsOrigin reports whether the API may be called from pages served by origin. With
+// no origins configured only localhost pages are allowed; "*" allows every origin.
+func (s *Proxy) allowsOrigin(origin string) bool {
+	if len(s.config.AllowedOrigins) == 0 {
+		return isLocalOrigin(origin)
+	}
+	return slices.Contains(s.config.AllowedOrigins, "*") || slices.Contains(s.config.AllowedOrigins, origin)
+}
+
+// corsHandler wraps an API handler with CORS headers for allowed origins. Requests from
+// other cross-site pages are refused, since the config API can read and rewrite secrets.
+func (s *Proxy) corsHandler(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Origin")
+
+		origin := r.Header.Get("Origin")
+		if origin != "" && !isSameOrigin(r, origin) {
+			if !s.allowsOrigin(origin) {
+				http.Error(w, "Origin not allowed", http.StatusForbidden)
+				return
+			}
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+			w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+		}
+
+		if r.Method == "OPTIONS" {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+
+		h(w, r)
+	}
+}
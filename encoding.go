@@ -0,0 +1,90 @@
+package proxy
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+)
+
+// decodedBody reads a decompressed response body and closes the underlying one
+type decodedBody struct {
+	io.Reader
+	body io.Closer
+}
+
+func (b *decodedBody) Close() error {
+	return b.body.Close()
+}
+
+// decodeContentEncoding decompresses a response the backend encoded without the
+// transport negotiating it, e.g. a backend that always gzips. The transport already
+// decodes responses to the gzip it requests itself and drops their Content-Encoding.
+// Decoding drops Content-Encoding too, so a decoded response is left as it is.
+func decodeContentEncoding(resp *http.Response) error {
+	encoding := strings.ToLower(strings.TrimSpace(resp.Header.Get("Content-Encoding")))
+
+	var decoder io.Reader
+	var err error
+	switch encoding {
+	case "", "identity":
+		return nil
+	case "gzip", "x-gzip":
+		decoder, err = gzip.NewReader(resp.Body)
+	case "deflate":
+		decoder, err = newDeflateReader(resp.Body)
+	case "br":
+		decoder, err = newBrotliReader(resp.Body)
+	default:
+		return fmt.Errorf("unsupported response content encoding '%s'", encoding)
+	}
+
+	switch {
+	case errors.Is(err, io.EOF):
+		// Bodiless responses such as HEAD can still declare an encoding
+		decoder = http.NoBody
+	case err != nil:
+		return fmt.Errorf("failed to decode %s response body: %w", encoding, err)
+	}
+
+	resp.Body = &decodedBody{Reader: decoder, body: resp.Body}
+	resp.Header.Del("Content-Encoding")
+	resp.Header.Del("Content-Length")
+	resp.ContentLength = -1
+	resp.Uncompressed = true
+
+	return nil
+}
+
+// newDeflateReader decodes "deflate" bodies, which are meant to be zlib-wrapped but
+// are sent as raw deflate data by some servers
+func newDeflateReader(r io.Reader) (io.Reader, error) {
+	buffered := bufio.NewReader(r)
+	header, err := buffered.Peek(2)
+	if err != nil {
+		return nil, err
+	}
+
+	// A zlib header declares the deflate method and is a multiple of 31
+	if header[0]&0x0f == 8 && (uint16(header[0])<<8|uint16(header[1]))%31 == 0 {
+		return zlib.NewReader(buffered)
+	}
+	return flate.NewReader(buffered), nil
+}
+
+// newBrotliReader decodes "br" bodies. Brotli streams have no header to check, so an
+// empty body is detected by peeking at it.
+func newBrotliReader(r io.Reader) (io.Reader, error) {
+	buffered := bufio.NewReader(r)
+	if _, err := buffered.Peek(1); err != nil {
+		return nil, err
+	}
+	return brotli.NewReader(buffered), nil
+}
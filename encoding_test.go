@@ -0,0 +1,121 @@
+package proxy
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+)
+
+// compress encodes data with a writer from newWriter
+func compress(t *testing.T, data string, newWriter func(io.Writer) io.WriteCloser) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	w := newWriter(&buf)
+	if _, err := io.WriteString(w, data); err != nil {
+		t.Fatalf("failed to compress: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("failed to compress: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestDecodeContentEncoding(t *testing.T) {
+	const body = `{"message":"hello"}`
+
+	tests := []struct {
+		name     string
+		encoding string
+		data     []byte
+	}{
+		{"gzip", "gzip", compress(t, body, func(w io.Writer) io.WriteCloser { return gzip.NewWriter(w) })},
+		{"zlib deflate", "deflate", compress(t, body, func(w io.Writer) io.WriteCloser { return zlib.NewWriter(w) })},
+		{"raw deflate", "deflate", compress(t, body, func(w io.Writer) io.WriteCloser {
+			fw, _ := flate.NewWriter(w, flate.DefaultCompression)
+			return fw
+		})},
+		{"brotli", "br", compress(t, body, func(w io.Writer) io.WriteCloser { return brotli.NewWriter(w) })},
+		{"identity", "identity", []byte(body)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &http.Response{
+				Header: http.Header{"Content-Encoding": []string{tt.encoding}},
+				Body:   io.NopCloser(bytes.NewReader(tt.data)),
+			}
+			if err := decodeContentEncoding(resp); err != nil {
+				t.Fatalf("decodeContentEncoding failed: %v", err)
+			}
+			decoded, err := io.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatalf("failed to read decoded body: %v", err)
+			}
+			if string(decoded) != body {
+				t.Errorf("decoded body = %q, want %q", decoded, body)
+			}
+			if resp.Header.Get("Content-Encoding") != "" && tt.encoding != "identity" {
+				t.Error("Content-Encoding is still set after decoding")
+			}
+		})
+	}
+}
+
+func TestDecodeContentEncodingEmptyBody(t *testing.T) {
+	for _, encoding := range []string{"gzip", "deflate", "br"} {
+		resp := &http.Response{
+			Header: http.Header{"Content-Encoding": []string{encoding}},
+			Body:   http.NoBody,
+		}
+		if err := decodeContentEncoding(resp); err != nil {
+			t.Errorf("%s: decodeContentEncoding of an empty body failed: %v", encoding, err)
+		}
+	}
+}
+
+func TestDecodeContentEncodingUnsupported(t *testing.T) {
+	resp := &http.Response{
+		Header: http.Header{"Content-Encoding": []string{"zstd"}},
+		Body:   http.NoBody,
+	}
+	if err := decodeContentEncoding(resp); err == nil || !strings.Contains(err.Error(), "unsupported") {
+		t.Errorf("decodeContentEncoding(zstd) returned %v, want an unsupported encoding error", err)
+	}
+}
+
+func TestBrotliToolResponse(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Content-Encoding", "br")
+		bw := brotli.NewWriter(w)
+		fmt.Fprint(bw, `{"status":"ok"}`)
+		bw.Close()
+	}))
+	defer backend.Close()
+
+	s := newTestProxy(t, fmt.Sprintf(`
+backends:
+  - base_url: %s
+    endpoints:
+      - name: status
+        capability: tool
+        mode: client
+        method: GET
+        path: /status
+`, backend.URL))
+
+	text, isError := toolText(t, s, "status", nil)
+	if isError || !strings.Contains(text, `"status":"ok"`) {
+		t.Errorf("status returned %q (error %v), want the decoded response", text, isError)
+	}
+}
@@ -2,6 +2,8 @@ package proxy
 
 import (
 	"net/http"
+	"regexp"
+	"sync"
 )
 
 // Type aliases for better code readability and type safety
@@ -10,6 +12,9 @@ type Data string
 type Value string
 type Mode string
 type Capability string
+type ResponseFormat string
+type QueryStyle string
+type ResultAs string
 
 // Capability constants define what kind of MCP Endpoint this proxy represents
 const (
@@ -43,12 +48,14 @@ const (
 // HTTP method constants for the proxy requests
 // These define what HTTP method will be used when calling the target service
 const (
-	POST   Method = http.MethodPost   // For creating resources or sending data
-	GET    Method = http.MethodGet    // For retrieving information
-	PUT    Method = http.MethodPut    // For updating entire resources
-	PATCH  Method = http.MethodPatch  // For partial updates
-	DELETE Method = http.MethodDelete // For removing resources
-	UPDATE Method = "UPDATE"          // Custom method for specific update operations
+	POST    Method = http.MethodPost    // For creating resources or sending data
+	GET     Method = http.MethodGet     // For retrieving information
+	PUT     Method = http.MethodPut     // For updating entire resources
+	PATCH   Method = http.MethodPatch   // For partial updates
+	DELETE  Method = http.MethodDelete  // For removing resources
+	HEAD    Method = http.MethodHead    // For checking a resource without fetching it
+	OPTIONS Method = http.MethodOptions // For discovering supported methods
+	UPDATE  Method = "UPDATE"           // Custom method for specific update operations
 )
 
 // Mode constants define how webhook/client tools integrate with the MCP client
@@ -65,6 +72,43 @@ const (
 	CLIENT Mode = "client"
 )
 
+// RAW_BODY is a parameter data type whose value is the entire JSON request body
+// The LLM supplies the body as one argument and it is sent verbatim instead of being
+// wrapped in an object keyed by the identifier. It must be the only body parameter
+const RAW_BODY Data = "raw_body"
+
+// ResponseFormat constants declare how a backend response body is interpreted
+// XML and CSV responses are converted to JSON before being returned to the LLM
+const (
+	JSON ResponseFormat = "json"
+	XML  ResponseFormat = "xml"
+	CSV  ResponseFormat = "csv"
+	TEXT ResponseFormat = "text"
+)
+
+// QueryStyle constants define how array values of query parameters are serialized
+const (
+	// REPEAT sends each element under the same key: ?tag=a&tag=b
+	REPEAT QueryStyle = "repeat"
+
+	// COMMA_SEPARATED joins the elements into one value: ?tag=a,b
+	COMMA_SEPARATED QueryStyle = "csv"
+)
+
+// ResultAs constants control how a tool packages a successful backend response
+const (
+	// RESULT_TEXT returns the response as text prefixed with a success message (default)
+	RESULT_TEXT ResultAs = "text"
+
+	// RESULT_JSON returns a JSON response as a bare, indented JSON document so clients
+	// can format it. Non-JSON responses fall back to text
+	RESULT_JSON ResultAs = "json"
+
+	// RESULT_RESOURCE returns the response as an embedded resource with its MIME type,
+	// for large payloads clients render or download separately
+	RESULT_RESOURCE ResultAs = "resource"
+)
+
 // Header represents HTTP headers that will be included in proxy requests
 // These allow you to configure authentication, content types, and other HTTP metadata
 type Header struct {
@@ -74,15 +118,20 @@ type Header struct {
 	// Name is the HTTP header name (e.g., "Authorization", "Content-Type", "X-API-Key")
 	Name string `json:"name" yaml:"name"`
 
-	// Value is the header value - can be a constant string or a template for dynamic extraction
-	// For dynamic headers, this might be a description of what to extract
+	// Value is the header value - a constant string, or for dynamic headers a template
+	// filled from the call arguments like path parameters: "Bearer {token}", "{tenant_id}"
+	// A dynamic header without placeholders takes the argument with the same name as the header
 	Value string `json:"value" yaml:"value"`
+
+	// Secret hides the value in API responses. Authorization, cookie and API key
+	// headers are always treated as secret
+	Secret bool `json:"secret,omitempty" yaml:"secret,omitempty"`
 }
 
 // Param defines a parameter that the LLM should extract from conversations
 // These parameters become the data payload sent to your HTTP endpoint
 type Param struct {
-	// DataType specifies the expected data type (string, number, boolean, object, array, etc.)
+	// DataType specifies the expected data type (string, number, integer, boolean, object, array, etc.)
 	// Helps the LLM understand how to parse and format the extracted value
 	DataType Data `json:"data_type" yaml:"data_type"`
 
@@ -104,6 +153,26 @@ type Param struct {
 	// Value is the static value for constant parameters
 	// Only used when ValueType is CONSTANT or STATIC
 	Value string `json:"value,omitempty" yaml:"value,omitempty"`
+
+	// Secret hides the constant value in API responses, e.g. for API keys sent as parameters
+	Secret bool `json:"secret,omitempty" yaml:"secret,omitempty"`
+
+	// Enum restricts the parameter to a fixed set of allowed values
+	// The values are advertised in the tool schema and enforced before the request is made
+	Enum []string `json:"enum,omitempty" yaml:"enum,omitempty"`
+
+	// Default is used for DYNAMIC parameters the LLM omits
+	// The value is converted to the declared DataType (e.g. "10" for a number becomes 10)
+	Default string `json:"default,omitempty" yaml:"default,omitempty"`
+
+	// Minimum and Maximum bound number and integer parameters. They are advertised in the
+	// tool schema and out-of-range values are rejected before the request is made
+	Minimum *float64 `json:"minimum,omitempty" yaml:"minimum,omitempty"`
+	Maximum *float64 `json:"maximum,omitempty" yaml:"maximum,omitempty"`
+
+	// Style controls how array values of query parameters are sent: "repeat" (default)
+	// or "csv". Only used for query parameters
+	Style QueryStyle `json:"style,omitempty" yaml:"style,omitempty"`
 }
 
 // Endpoint defines a complete MCP Endpoint that proxies to an HTTP endpoint
@@ -126,12 +195,25 @@ type Endpoint struct {
 	// Method defines the HTTP method for the proxy request to your endpoint
 	Method Method `json:"method" yaml:"method"`
 
+	// BackendRef names the backend this endpoint calls instead of the one it is nested under
+	// The endpoint uses that backend's BaseURL, DefaultHeaders, session and concurrency limit
+	BackendRef string `json:"backend_ref,omitempty" yaml:"backend_ref,omitempty"`
+
 	// Path is the endpoint path that will be appended to the backend's BaseURL
 	// Supports path parameter templates using curly braces: "/users/{user_id}/orders/{order_id}"
 	// Examples: "/orders", "/users/{user_id}", "/templates/generate"
 	// The full URL becomes: Backend.BaseURL + Endpoint.Path
 	Path string `json:"path" yaml:"path"`
 
+	// Query is the GraphQL query or mutation sent for endpoints of a graphql backend
+	// Declare its variables as body_params: "query ($id: ID!) { user(id: $id) { name } }"
+	// Path is optional and is appended to the backend's BaseURL as usual
+	Query string `json:"query,omitempty" yaml:"query,omitempty"`
+
+	// RPC is the fully-qualified method invoked for endpoints of a grpc backend
+	// Example: "shop.v1.Catalog/GetProduct"
+	RPC string `json:"rpc,omitempty" yaml:"rpc,omitempty"`
+
 	// Description explains the Endpoint's purpose to the LLM
 	// Tools: when and how to use this action and any constraints or requirements
 	// Resources: what data this resource contains and when to reference it
@@ -143,6 +225,15 @@ type Endpoint struct {
 	// Common uses: authentication tokens, content-type specifications, custom API headers
 	Headers []*Header `json:"headers" yaml:"headers"`
 
+	// Tags group endpoints for profiles; sessions using a profile only see endpoints
+	// tagged with one of its tags. Example: [orders, support]
+	Tags []string `json:"tags,omitempty" yaml:"tags,omitempty"`
+
+	// Uses names templates from the config's templates section whose headers and
+	// parameters are merged into this endpoint. The endpoint's own headers and parameters
+	// take precedence over a template's, and earlier templates over later ones
+	Uses []string `json:"uses,omitempty" yaml:"uses,omitempty"`
+
 	// WaitResponse determines conversation flow control
 	// Tools: true = wait for action completion, false = fire-and-forget
 	// Resources: typically true to wait for data retrieval
@@ -154,12 +245,34 @@ type Endpoint struct {
 	// Consider your endpoint's typical response time when setting this value
 	ResponseTimeout Duration `json:"response_timeout" yaml:"response_timeout"`
 
+	// Stream reads a long-running response, such as server-sent events or chunked text,
+	// without an overall deadline. The request fails only when no data arrives for
+	// IdleTimeout. Tool calls with a progress token receive each chunk as a progress
+	// notification. Only supported for tool and resource endpoints of http backends
+	Stream bool `json:"stream,omitempty" yaml:"stream,omitempty"`
+
+	// IdleTimeout is the longest a streamed response may go without data. Default: 30 seconds
+	IdleTimeout Duration `json:"idle_timeout,omitempty" yaml:"idle_timeout,omitempty"`
+
+	// HedgeAfter enables request hedging for GET/HEAD/OPTIONS endpoints
+	// If the backend hasn't responded within this delay, a second identical request is sent
+	// and the first response wins. Trades extra backend load for lower tail latency. Default: disabled
+	HedgeAfter Duration `json:"hedge_after,omitempty" yaml:"hedge_after,omitempty"`
+
+	// MaxConcurrency caps how many requests to this endpoint run at once. Calls over the
+	// limit wait for a slot until their response timeout expires. Default: unlimited
+	MaxConcurrency int `json:"max_concurrency,omitempty" yaml:"max_concurrency,omitempty"`
+
 	// BodyParams define data that will be extracted and sent in the HTTP request body
 	// Tools: parameters for the action to execute
 	// Resources: filters or criteria for data retrieval
 	// Prompts: variables to substitute into the template
 	BodyParams []*Param `json:"body_params" yaml:"body_params"`
 
+	// ArrayBody sends the single array body parameter as the top-level JSON body, [...],
+	// instead of wrapping it in an object, for APIs that take a list of items
+	ArrayBody bool `json:"array_body,omitempty" yaml:"array_body,omitempty"`
+
 	// QueryParameters define data that will be extracted and sent as URL query parameters
 	// Example: ?user_id=123&include_details=true
 	// Commonly used for pagination, filtering, or simple parameter passing
@@ -169,4 +282,199 @@ type Endpoint struct {
 	// Use curly braces in your URL template: "/users/{user_id}/orders/{order_id}"
 	// The LLM will extract these values and substitute them into the path
 	PathParameters []*Param `json:"path_parameters" yaml:"path_parameters"`
+
+	// InputSchema is a JSON Schema object used as the tool's input schema instead of one
+	// generated from the parameters, for nested objects, patterns or ranges the flat
+	// parameters can't express. Arguments not used by path or query parameters are sent
+	// as the JSON request body. Only supported for tools; can't be combined with body_params
+	InputSchema map[string]any `json:"input_schema,omitempty" yaml:"input_schema,omitempty"`
+
+	// ContentTemplate is an optional Go text/template used to render JSON responses
+	// into a readable document (e.g. Markdown) for RESOURCE endpoints
+	// The parsed JSON response is the template's data: "# {{.title}}\n\n{{.body}}"
+	// If rendering fails, the raw response is returned instead
+	ContentTemplate string `json:"content_template,omitempty" yaml:"content_template,omitempty"`
+
+	// PollInterval enables change notifications for static RESOURCE endpoints
+	// The proxy fetches the resource at this interval and sends a resources/updated
+	// notification to subscribed clients whenever the content changes. Default: disabled
+	PollInterval Duration `json:"poll_interval,omitempty" yaml:"poll_interval,omitempty"`
+
+	// Pagination makes RESOURCE endpoints follow a backend's next-page cursor and
+	// aggregate all pages into a single JSON array
+	Pagination *Pagination `json:"pagination,omitempty" yaml:"pagination,omitempty"`
+
+	// Messages defines a static multi-message template for PROMPT endpoints
+	// When set, the prompt is rendered directly from config and no HTTP request is made
+	// Message content may reference prompt arguments as {identifier}
+	Messages []*PromptMessage `json:"messages,omitempty" yaml:"messages,omitempty"`
+
+	// ResponseFormat declares the backend's response format: json, xml, csv or text
+	// When empty, the format is inferred from the response Content-Type header
+	// XML and CSV are converted to JSON; if parsing fails, the raw text is returned
+	ResponseFormat ResponseFormat `json:"response_format,omitempty" yaml:"response_format,omitempty"`
+
+	// ResultAs controls how a TOOL returns a successful response: text (default), json
+	// or resource
+	ResultAs ResultAs `json:"result_as,omitempty" yaml:"result_as,omitempty"`
+
+	// Binary marks a RESOURCE endpoint as returning binary data such as images or PDFs
+	// The response is returned as a base64 blob without content sniffing
+	// Responses with image/*, audio/*, video/*, PDF or octet-stream content types are
+	// treated as binary automatically
+	Binary bool `json:"binary,omitempty" yaml:"binary,omitempty"`
+
+	// Steps turns a TOOL endpoint into a composite tool that makes several requests in order
+	// The endpoint's parameters define the tool's arguments; each step's path, body and
+	// header values are Go templates with the arguments as .args and earlier step
+	// responses as .steps.<name>. The tool stops at the first failing step and returns
+	// the last step's response
+	Steps []*Step `json:"steps,omitempty" yaml:"steps,omitempty"`
+
+	// Passthrough turns a TOOL endpoint into one whose method and path are chosen by the
+	// LLM on each call, within an allowlist, for exploratory or admin backends. The tool
+	// takes method, path, query and body arguments; path and parameters must not be set
+	Passthrough *Passthrough `json:"passthrough,omitempty" yaml:"passthrough,omitempty"`
+
+	// RetryNonIdempotent allows failed POST, PATCH and other non-idempotent requests to
+	// be retried. Off by default, since retrying them can repeat side effects.
+	RetryNonIdempotent bool `json:"retry_non_idempotent,omitempty" yaml:"retry_non_idempotent,omitempty"`
+
+	// SuccessCodes lists the backend statuses that count as success, as codes (418),
+	// classes ("2xx") or ranges ("200-299"). Other statuses are reported as errors.
+	// Default: any 2xx status
+	SuccessCodes []StatusRange `json:"success_codes,omitempty" yaml:"success_codes,omitempty"`
+
+	// RetryOnBody treats a response whose body reports an error, such as a 200 carrying
+	// {"error":"rate_limited"}, as failed: it is retried like a 5xx response, counts
+	// against the circuit breaker and fails the call once retries run out
+	RetryOnBody *RetryOnBody `json:"retry_on_body,omitempty" yaml:"retry_on_body,omitempty"`
+
+	// IdempotencyKey sends a random key, generated once per call, with every attempt of a
+	// non-idempotent request so the backend can deduplicate retries. Implies retries.
+	IdempotencyKey bool `json:"idempotency_key,omitempty" yaml:"idempotency_key,omitempty"`
+
+	// IdempotencyHeader names the header carrying the idempotency key. Default: Idempotency-Key
+	IdempotencyHeader string `json:"idempotency_header,omitempty" yaml:"idempotency_header,omitempty"`
+
+	// MaxResponseBytes caps how much of a backend response is read; larger responses fail
+	// Default: the mcp section's max_response_bytes, or 10 MB
+	MaxResponseBytes int64 `json:"max_response_bytes,omitempty" yaml:"max_response_bytes,omitempty"`
+
+	// MaxRequestBytes caps the size of request bodies built for the backend
+	// Default: the mcp section's max_request_bytes, or 10 MB
+	MaxRequestBytes int64 `json:"max_request_bytes,omitempty" yaml:"max_request_bytes,omitempty"`
+
+	// MockResponse is returned instead of calling the backend, for offline development
+	// The mock goes through the same response handling as a real backend response
+	MockResponse *MockResponse `json:"mock_response,omitempty" yaml:"mock_response,omitempty"`
+
+	// ResponseFields maps output field names to JSON paths in the backend response
+	// The tool result becomes a compact object of just these fields, e.g.
+	// {id: "$.data.id", status: "$.data.status"}; if no path matches, the full body is returned
+	ResponseFields map[string]string `json:"response_fields,omitempty" yaml:"response_fields,omitempty"`
+
+	// ResponseJQ is a jq expression that reshapes a JSON response before it is returned,
+	// e.g. '.items | map({id, name})'. Several outputs are returned as an array. If the
+	// expression fails, the error is logged and the full response is returned. Can't be
+	// combined with response_fields
+	ResponseJQ string `json:"response_jq,omitempty" yaml:"response_jq,omitempty"`
+
+	// TransformRequest names a request transformer, registered with WithRequestTransformer,
+	// that rewrites each call's arguments before the backend request is built
+	TransformRequest string `json:"transform_request,omitempty" yaml:"transform_request,omitempty"`
+
+	// TransformResponse names a response transformer, registered with
+	// WithResponseTransformer, that rewrites the backend response body before it's handled
+	TransformResponse string `json:"transform_response,omitempty" yaml:"transform_response,omitempty"`
+
+	// ResponseHeaders lists backend response headers to include in the tool result,
+	// e.g. Location after a create or X-RateLimit-Remaining. Names match case-insensitively
+	ResponseHeaders []string `json:"response_headers,omitempty" yaml:"response_headers,omitempty"`
+}
+
+// PromptMessage is a single message of an inline prompt template
+type PromptMessage struct {
+	// Role is the message author: "system", "user" or "assistant"
+	// MCP prompts have no system role, so system messages are sent as user messages
+	Role string `json:"role" yaml:"role"`
+
+	// Content is the message text; {identifier} placeholders are replaced with argument values
+	Content string `json:"content" yaml:"content"`
+}
+
+// Step is a single request of a composite tool
+type Step struct {
+	// Name identifies the step so later steps can reference its response as .steps.<name>
+	Name string `json:"name" yaml:"name"`
+
+	// Method is the HTTP method for this step's request
+	Method Method `json:"method" yaml:"method"`
+
+	// Path is a template appended to the backend's BaseURL, including any query string
+	// Example: "/users/{{.args.user_id}}/validate"
+	Path string `json:"path" yaml:"path"`
+
+	// Body is an optional template rendering the JSON request body
+	// Example: '{"user_id": {{json .steps.validate_user.id}}, "items": {{json .args.items}}}'
+	Body string `json:"body,omitempty" yaml:"body,omitempty"`
+
+	// Headers are added to this step's request; values are templates
+	Headers []*Header `json:"headers,omitempty" yaml:"headers,omitempty"`
+
+	// BackendRef names the backend this step calls instead of the endpoint's. The step
+	// uses that backend's BaseURL, DefaultHeaders, session and concurrency limit
+	BackendRef string `json:"backend_ref,omitempty" yaml:"backend_ref,omitempty"`
+}
+
+// Passthrough is the allowlist of a passthrough tool
+type Passthrough struct {
+	// AllowedPaths are regular expressions a requested path must match in full, e.g.
+	// "/users/[0-9]+" or "/reports/.*". Paths with '.' or '..' segments, empty segments,
+	// query strings or backslashes are rejected before matching
+	AllowedPaths []string `json:"allowed_paths" yaml:"allowed_paths"`
+
+	// AllowedMethods lists the HTTP methods the LLM may use. Default: GET
+	AllowedMethods []Method `json:"allowed_methods,omitempty" yaml:"allowed_methods,omitempty"`
+}
+
+// RetryOnBody describes a response body that reports an error despite its status
+type RetryOnBody struct {
+	// Path is a JSON path into the response, e.g. "$.error" or "status.code"
+	Path string `json:"path,omitempty" yaml:"path,omitempty"`
+
+	// Value is the value at path that marks a failure. Without value or pattern, the
+	// path merely existing does
+	Value any `json:"value,omitempty" yaml:"value,omitempty"`
+
+	// Pattern is a regular expression matched against the value at path, or against
+	// the whole body when path is empty
+	Pattern string `json:"pattern,omitempty" yaml:"pattern,omitempty"`
+
+	// compileOnce compiles Pattern on first use, for every response checked after
+	compileOnce sync.Once
+	pattern     *regexp.Regexp
+	patternErr  error
+}
+
+// HasInlineMessages reports whether the endpoint is a prompt rendered from config
+func (e *Endpoint) HasInlineMessages() bool {
+	return e.Capability == PROMPT && len(e.Messages) > 0
+}
+
+// Pagination describes how to walk a paginated backend response
+type Pagination struct {
+	// CursorPath is the JSON path of the next-page cursor in the response (e.g. "meta.next_cursor")
+	// Pagination stops when the cursor is missing, null, or empty
+	CursorPath string `json:"cursor_path" yaml:"cursor_path"`
+
+	// PageParam is the query parameter used to send the cursor on subsequent requests (e.g. "page")
+	PageParam string `json:"page_param" yaml:"page_param"`
+
+	// ItemsPath is the JSON path of the items array in each page (e.g. "data")
+	// Leave empty when the response body itself is the array
+	ItemsPath string `json:"items_path,omitempty" yaml:"items_path,omitempty"`
+
+	// MaxPages caps the number of pages fetched per read. Default: 10
+	MaxPages int `json:"max_pages,omitempty" yaml:"max_pages,omitempty"`
 }
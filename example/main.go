@@ -3,10 +3,12 @@ package main
 import (
 	"bufio"
 	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"log/slog"
 	"os"
+	"strconv"
 	"strings"
 
 	"github.com/mark3labs/mcp-go/client/transport"
@@ -77,6 +79,19 @@ func main() {
 	// Set Prompt
 	llmProvider.SetSystemPrompt("You're an helpful assistant")
 
+	// Apply sampling defaults once for every message
+	temperature, err := strconv.ParseFloat(getEnvOrDefault("LLM_TEMPERATURE", "0.7"), 64)
+	if err != nil {
+		logger.Error("Invalid LLM_TEMPERATURE", "error", err)
+		os.Exit(1)
+	}
+	maxTokens, err := strconv.Atoi(getEnvOrDefault("LLM_MAX_TOKENS", "4000"))
+	if err != nil {
+		logger.Error("Invalid LLM_MAX_TOKENS", "error", err)
+		os.Exit(1)
+	}
+	llmProvider.SetDefaults(temperature, maxTokens)
+
 	// Create universal client
 	universalClient := client.NewUniversalMCPClient(mcpClient, llmProvider, logger)
 
@@ -115,7 +130,14 @@ func main() {
 		default:
 			if err := universalClient.ProcessMessage(context.Background(), client.WithTextMessage(input)); err != nil {
 				logger.Error("Failed to process message", "error", err)
-				fmt.Printf("❌ Error: %v\n", err)
+
+				// Explain provider errors instead of dumping the raw API response
+				var llmErr *client.LLMError
+				if errors.As(err, &llmErr) {
+					fmt.Printf("❌ %s\n", llmErr.FriendlyMessage())
+				} else {
+					fmt.Printf("❌ Error: %v\n", err)
+				}
 			}
 		}
 
@@ -23,16 +23,26 @@ type AnthropicProvider struct {
 	systemPrompt        string
 	conversationHistory []ConversationMessage
 	conversationConfig  ConversationConfig
+	maxRetries          int
+	temperature         float64
+	maxTokens           int
+	contextWindow       int // Overrides the model's known context window when set
 }
 
 // Anthropic API structures
 type AnthropicRequest struct {
-	Model       string             `json:"model"`
-	MaxTokens   int                `json:"max_tokens"`
-	System      string             `json:"system,omitempty"`
-	Messages    []AnthropicMessage `json:"messages"`
-	Tools       []AnthropicTool    `json:"tools,omitempty"`
-	Temperature float64            `json:"temperature,omitempty"`
+	Model       string               `json:"model"`
+	MaxTokens   int                  `json:"max_tokens"`
+	System      string               `json:"system,omitempty"`
+	Messages    []AnthropicMessage   `json:"messages"`
+	Tools       []AnthropicTool      `json:"tools,omitempty"`
+	ToolChoice  *AnthropicToolChoice `json:"tool_choice,omitempty"`
+	Temperature float64              `json:"temperature,omitempty"`
+}
+
+type AnthropicToolChoice struct {
+	Type string `json:"type"` // auto, any, tool or none
+	Name string `json:"name,omitempty"`
 }
 
 type AnthropicMessage struct {
@@ -53,13 +63,17 @@ type AnthropicInputSchema struct {
 }
 
 type AnthropicResponse struct {
-	ID      string                  `json:"id"`
-	Content []AnthropicContentBlock `json:"content"`
-	Usage   AnthropicUsage          `json:"usage"`
-	Model   string                  `json:"model"`
-	Role    string                  `json:"role"`
+	ID         string                  `json:"id"`
+	Content    []AnthropicContentBlock `json:"content"`
+	Usage      AnthropicUsage          `json:"usage"`
+	Model      string                  `json:"model"`
+	Role       string                  `json:"role"`
+	StopReason string                  `json:"stop_reason"`
 }
 
+// anthropicMaxContinuations bounds how many times a response cut off mid-text is continued
+const anthropicMaxContinuations = 3
+
 type AnthropicContentBlock struct {
 	Type  string                 `json:"type"`
 	Text  string                 `json:"text,omitempty"`
@@ -87,6 +101,9 @@ func NewAnthropicProvider(apiKey string, logger *slog.Logger) (*AnthropicProvide
 		logger:             logger,
 		model:              "claude-3-5-haiku-20241022", // Default model
 		conversationConfig: DefaultConversationConfig(),
+		maxRetries:         defaultMaxRetries,
+		temperature:        DefaultTemperature,
+		maxTokens:          DefaultMaxTokens,
 	}, nil
 }
 
@@ -101,18 +118,43 @@ func (p *AnthropicProvider) SetModel(model string) {
 	p.logger.Info("Model changed", "new_model", model)
 }
 
+// SetDefaults sets the temperature and token limit used when SendMessage options don't set them
+func (p *AnthropicProvider) SetDefaults(temperature float64, maxTokens int) {
+	p.temperature = temperature
+	if maxTokens > 0 {
+		p.maxTokens = maxTokens
+	}
+	p.logger.Info("Defaults changed", "temperature", temperature, "max_tokens", p.maxTokens)
+}
+
+// GetDefaults returns the default temperature and token limit
+func (p *AnthropicProvider) GetDefaults() (float64, int) {
+	return p.temperature, p.maxTokens
+}
+
+// SetMaxRetries sets how many times rate-limited or failed API calls are retried
+func (p *AnthropicProvider) SetMaxRetries(maxRetries int) {
+	p.maxRetries = maxRetries
+}
+
 // SendMessage sends a message to Claude using function options
 func (p *AnthropicProvider) SendMessage(ctx context.Context, options ...SendMessageOption) (*LLMResponse, error) {
 	// Apply options
 	opts := &SendMessageOptions{
 		Role:         "user",
-		MaxTokens:    500,
-		Temperature:  0.7,
+		MaxTokens:    p.maxTokens,
+		Temperature:  p.temperature,
 		SystemPrompt: p.systemPrompt,
 	}
 	for _, fn := range options {
 		fn(opts)
 	}
+	if opts.MaxTokens <= 0 {
+		opts.MaxTokens = p.maxTokens
+	}
+	if err := validateToolChoice(opts.ToolChoice, opts.Tools); err != nil {
+		return nil, err
+	}
 
 	// Validate that message is provided
 	if opts.Message != nil {
@@ -126,6 +168,13 @@ func (p *AnthropicProvider) SendMessage(ctx context.Context, options ...SendMess
 	// Convert MCP tools to Anthropic format
 	anthropicTools := p.convertMCPToolsToAnthropic(opts.Tools)
 
+	// Trim history to the context window now rather than have the API reject the request
+	history, err := fitContextWindow(p.conversationHistory, estimateRequestTokens(opts.SystemPrompt, opts.Tools), opts.MaxTokens, p.GetContextWindow(), p.logger)
+	if err != nil {
+		return nil, err
+	}
+	p.conversationHistory = history
+
 	// Convert conversation history to Anthropic format
 	messages := p.convertConversationToAnthropic()
 
@@ -138,7 +187,54 @@ func (p *AnthropicProvider) SendMessage(ctx context.Context, options ...SendMess
 		Tools:       anthropicTools,
 		Temperature: opts.Temperature,
 	}
+	if len(anthropicTools) > 0 {
+		request.ToolChoice = convertToolChoiceToAnthropic(opts.ToolChoice)
+	}
+
+	anthropicResp, err := p.createMessage(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	response := p.convertAnthropicResponse(anthropicResp)
+
+	// Continue text cut off by the token limit by sending it back as the start of the
+	// assistant's turn, which Claude picks up from
+	for i := 0; i < anthropicMaxContinuations && response.Truncated() && len(response.ToolCalls) == 0 && response.TextContent != ""; i++ {
+		partial := strings.TrimRight(response.TextContent, " \t\n")
+		p.logger.Info("Continuing truncated response", "continuation", i+1, "text_length", len(partial))
+
+		request.Messages = append(messages, AnthropicMessage{
+			Role:    "assistant",
+			Content: partial,
+		})
+		anthropicResp, err := p.createMessage(ctx, request)
+		if err != nil {
+			return nil, err
+		}
+		next := p.convertAnthropicResponse(anthropicResp)
+
+		response.TextContent = partial + next.TextContent
+		response.ToolCalls = next.ToolCalls
+		response.StopReason = next.StopReason
+		response.Usage.InputTokens += next.Usage.InputTokens
+		response.Usage.OutputTokens += next.Usage.OutputTokens
+	}
 
+	// A tool call cut off mid-arguments can't be run, and recording it would leave a
+	// tool_use in the history that nothing answers
+	if response.Truncated() && len(response.ToolCalls) > 0 {
+		p.logger.Error("Response truncated during tool use", "max_tokens", opts.MaxTokens, "tool_calls", len(response.ToolCalls))
+		return nil, fmt.Errorf("%w during tool use; raise the max tokens above %d", ErrResponseTruncated, opts.MaxTokens)
+	}
+
+	// Add assistant response to conversation history
+	p.AddAssistantMessage(response.TextContent, response.ToolCalls)
+
+	return response, nil
+}
+
+// createMessage sends a request to the Messages API and decodes the response
+func (p *AnthropicProvider) createMessage(ctx context.Context, request AnthropicRequest) (*AnthropicResponse, error) {
 	// Marshal request
 	reqBody, err := json.Marshal(request)
 	if err != nil {
@@ -146,21 +242,21 @@ func (p *AnthropicProvider) SendMessage(ctx context.Context, options ...SendMess
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	// Create HTTP request
-	httpReq, err := http.NewRequestWithContext(ctx, "POST", "https://api.anthropic.com/v1/messages", bytes.NewBuffer(reqBody))
-	if err != nil {
-		p.logger.Error("Failed to create HTTP request", "error", err)
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	// Set headers
-	httpReq.Header.Set("Content-Type", "application/json")
-	httpReq.Header.Set("x-api-key", p.apiKey)
-	httpReq.Header.Set("anthropic-version", "2023-06-01")
-
-	// Make request
+	// Make request, retrying rate limits and transient errors
 	startTime := time.Now()
-	resp, err := p.httpClient.Do(httpReq)
+	resp, err := sendWithRetries(ctx, p.httpClient, p.logger, p.maxRetries, func() (*http.Request, error) {
+		httpReq, err := http.NewRequestWithContext(ctx, "POST", "https://api.anthropic.com/v1/messages", bytes.NewReader(reqBody))
+		if err != nil {
+			p.logger.Error("Failed to create HTTP request", "error", err)
+			return nil, fmt.Errorf("failed to create request: %w", err)
+		}
+
+		// Set headers
+		httpReq.Header.Set("Content-Type", "application/json")
+		httpReq.Header.Set("x-api-key", p.apiKey)
+		httpReq.Header.Set("anthropic-version", "2023-06-01")
+		return httpReq, nil
+	})
 	if err != nil {
 		p.logger.Error("HTTP request failed", "error", err)
 		return nil, fmt.Errorf("failed to make request: %w", err)
@@ -174,7 +270,7 @@ func (p *AnthropicProvider) SendMessage(ctx context.Context, options ...SendMess
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
 		p.logger.Error("API request failed", "status", resp.StatusCode, "body", string(body))
-		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
+		return nil, newLLMError("Anthropic", resp.StatusCode, body)
 	}
 
 	// Parse response
@@ -184,13 +280,7 @@ func (p *AnthropicProvider) SendMessage(ctx context.Context, options ...SendMess
 		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
 
-	// Convert to unified response
-	response := p.convertAnthropicResponse(&anthropicResp)
-
-	// Add assistant response to conversation history
-	p.AddAssistantMessage(response.TextContent, response.ToolCalls)
-
-	return response, nil
+	return &anthropicResp, nil
 }
 
 // convertMCPToolsToAnthropic converts MCP tools to Anthropic format
@@ -228,6 +318,21 @@ func (p *AnthropicProvider) convertMCPToolsToAnthropic(mcpTools []mcp.Tool) []An
 	return tools
 }
 
+// convertToolChoiceToAnthropic converts a tool choice to Anthropic's tool_choice, which
+// calls "required" any. Auto is the API's default, so it's left out.
+func convertToolChoiceToAnthropic(choice string) *AnthropicToolChoice {
+	switch choice {
+	case "", ToolChoiceAuto:
+		return nil
+	case ToolChoiceNone:
+		return &AnthropicToolChoice{Type: "none"}
+	case ToolChoiceRequired:
+		return &AnthropicToolChoice{Type: "any"}
+	default:
+		return &AnthropicToolChoice{Type: "tool", Name: choice}
+	}
+}
+
 // convertAnthropicResponse converts Anthropic response to unified format
 func (p *AnthropicProvider) convertAnthropicResponse(resp *AnthropicResponse) *LLMResponse {
 	llmResp := &LLMResponse{
@@ -263,15 +368,39 @@ func (p *AnthropicProvider) convertAnthropicResponse(resp *AnthropicResponse) *L
 		llmResp.TextContent = strings.Join(textParts, "\n")
 	}
 
+	llmResp.StopReason = anthropicStopReason(resp.StopReason)
+
+	// The token limit cut the response off, possibly in the middle of a tool call
+	if llmResp.Truncated() {
+		p.logger.Warn("Response truncated at the max_tokens limit", "output_tokens", resp.Usage.OutputTokens)
+	}
+
 	p.logger.Info("Response converted",
 		"text_length", len(llmResp.TextContent),
 		"tool_calls", len(llmResp.ToolCalls),
+		"stop_reason", llmResp.StopReason,
 		"input_tokens", llmResp.Usage.InputTokens,
 		"output_tokens", llmResp.Usage.OutputTokens)
 
 	return llmResp
 }
 
+// anthropicStopReason normalizes Anthropic's stop_reason to a Finish constant
+func anthropicStopReason(stopReason string) string {
+	switch stopReason {
+	case "end_turn", "stop_sequence":
+		return FinishStop
+	case "max_tokens":
+		return FinishLength
+	case "tool_use":
+		return FinishToolCalls
+	case "refusal":
+		return FinishContentFilter
+	default:
+		return stopReason
+	}
+}
+
 // GetAvailableModels returns available Anthropic models
 func (p *AnthropicProvider) GetAvailableModels() []string {
 	return []string{
@@ -288,6 +417,21 @@ func (p *AnthropicProvider) GetCurrentModel() string {
 	return p.model
 }
 
+// GetContextWindow returns the context window of the current model in tokens, or 0
+// when it isn't known, in which case history isn't trimmed
+func (p *AnthropicProvider) GetContextWindow() int {
+	if p.contextWindow > 0 {
+		return p.contextWindow
+	}
+	return contextWindowFor(p.model)
+}
+
+// SetContextWindow sets the context window in tokens for models the built-in table
+// doesn't know, such as deployment names or compatible APIs. Zero uses the table again.
+func (p *AnthropicProvider) SetContextWindow(tokens int) {
+	p.contextWindow = tokens
+}
+
 // SetSystemPrompt sets the system prompt for this provider
 func (p *AnthropicProvider) SetSystemPrompt(systemPrompt string) {
 	p.systemPrompt = systemPrompt
@@ -318,13 +462,15 @@ func (p *AnthropicProvider) AddAssistantMessage(content string, toolCalls []Tool
 	p.optimizeConversationHistory()
 }
 
-// AddToolResponse adds a tool response to the conversation history
-func (p *AnthropicProvider) AddToolResponse(toolCallID, toolName, content string) {
+// AddToolResponse adds a tool response to the conversation history. isError marks
+// the content as a failure description rather than a result.
+func (p *AnthropicProvider) AddToolResponse(toolCallID, toolName, content string, isError bool) {
 	p.conversationHistory = append(p.conversationHistory, ConversationMessage{
 		Role:       "user",
 		Content:    content,
 		ToolCallID: toolCallID,
 		Name:       toolName,
+		IsError:    isError,
 	})
 	p.optimizeConversationHistory()
 }
@@ -399,32 +545,92 @@ func (p *AnthropicProvider) optimizeConversationHistory() {
 	}
 }
 
-// convertConversationToAnthropic converts conversation history to Anthropic format
+// convertConversationToAnthropic converts conversation history to Anthropic format.
+// Anthropic requires the tool_result blocks answering an assistant's tool_use blocks to
+// open the very next user message, so each run of user entries becomes one message:
+// tool results first, then any text. Results recorded in several parts for one call are
+// joined, calls left without a result get an error result, and results whose tool_use
+// isn't in the preceding assistant message, e.g. after trimming, are sent as text.
 func (p *AnthropicProvider) convertConversationToAnthropic() []AnthropicMessage {
 	messages := make([]AnthropicMessage, 0, len(p.conversationHistory))
 
+	// The tool calls of the last assistant message, and the user turn being collected
+	var toolCalls []ToolCall
+	var results []map[string]interface{}
+	var texts []interface{}
+
+	flushUserTurn := func() {
+		if len(results) == 0 && len(texts) == 0 {
+			return
+		}
+
+		// Every tool_use needs a result, so the turn stays valid if one went missing
+		for _, toolCall := range toolCalls {
+			if findToolResult(results, toolCall.ID) == nil {
+				results = append(results, map[string]interface{}{
+					"type":        "tool_result",
+					"tool_use_id": toolCall.ID,
+					"content":     "No result was recorded for this tool call",
+					"is_error":    true,
+				})
+			}
+		}
+
+		var content interface{}
+		if len(results) == 0 && len(texts) == 1 {
+			content = texts[0].(map[string]interface{})["text"]
+		} else {
+			blocks := make([]interface{}, 0, len(results)+len(texts))
+			for _, result := range results {
+				blocks = append(blocks, result)
+			}
+			content = append(blocks, texts...)
+		}
+
+		messages = append(messages, AnthropicMessage{
+			Role:    "user",
+			Content: content,
+		})
+		toolCalls, results, texts = nil, nil, nil
+	}
+
 	for _, msg := range p.conversationHistory {
 		switch msg.Role {
 		case "user":
-			var content any
-
-			if msg.ToolCallID != "" {
-				content = []interface{}{
-					map[string]interface{}{
-						"type":        "tool_result",
-						"tool_use_id": msg.ToolCallID,
-						"content":     msg.Content,
-					},
+			switch {
+			case msg.ToolCallID != "" && findToolResult(results, msg.ToolCallID) != nil:
+				// Another part of a result already recorded for this call
+				result := findToolResult(results, msg.ToolCallID)
+				result["content"] = result["content"].(string) + "\n" + msg.Content
+				if msg.IsError {
+					result["is_error"] = true
 				}
-			} else {
-				content = msg.Content
+			case msg.ToolCallID != "" && hasToolCall(toolCalls, msg.ToolCallID):
+				result := map[string]interface{}{
+					"type":        "tool_result",
+					"tool_use_id": msg.ToolCallID,
+					"content":     msg.Content,
+				}
+				if msg.IsError {
+					result["is_error"] = true
+				}
+				results = append(results, result)
+			case msg.ToolCallID != "":
+				// The tool_use this answers is no longer in the history
+				texts = append(texts, map[string]interface{}{
+					"type": "text",
+					"text": fmt.Sprintf("Result of tool %s: %s", msg.Name, msg.Content),
+				})
+			case msg.Content != "":
+				texts = append(texts, map[string]interface{}{
+					"type": "text",
+					"text": msg.Content,
+				})
 			}
-
-			messages = append(messages, AnthropicMessage{
-				Role:    "user",
-				Content: content,
-			})
 		case "assistant":
+			flushUserTurn()
+			toolCalls = msg.ToolCalls
+
 			// Handle assistant messages with potential tool calls
 			if len(msg.ToolCalls) > 0 {
 				// Create content blocks for text and tool calls
@@ -438,13 +644,17 @@ func (p *AnthropicProvider) convertConversationToAnthropic() []AnthropicMessage
 					})
 				}
 
-				// Add tool calls
+				// Add tool calls; input must be an object even for calls without arguments
 				for _, toolCall := range msg.ToolCalls {
+					input := toolCall.Arguments
+					if input == nil {
+						input = map[string]interface{}{}
+					}
 					content = append(content, map[string]interface{}{
 						"type":  "tool_use",
 						"id":    toolCall.ID,
 						"name":  toolCall.Name,
-						"input": toolCall.Arguments,
+						"input": input,
 					})
 				}
 
@@ -461,10 +671,31 @@ func (p *AnthropicProvider) convertConversationToAnthropic() []AnthropicMessage
 			}
 		}
 	}
+	flushUserTurn()
 
 	return messages
 }
 
+// findToolResult returns the tool_result block answering a tool call, if any
+func findToolResult(results []map[string]interface{}, toolCallID string) map[string]interface{} {
+	for _, result := range results {
+		if result["tool_use_id"] == toolCallID {
+			return result
+		}
+	}
+	return nil
+}
+
+// hasToolCall reports whether toolCalls include the call with the given ID
+func hasToolCall(toolCalls []ToolCall, toolCallID string) bool {
+	for _, toolCall := range toolCalls {
+		if toolCall.ID == toolCallID {
+			return true
+		}
+	}
+	return false
+}
+
 // convertMessageContentToText converts MessageContent to text for conversation history
 func (p *AnthropicProvider) convertMessageContentToText(content *MessageContent) string {
 	switch content.Type {
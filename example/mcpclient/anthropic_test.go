@@ -0,0 +1,125 @@
+package client
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestConvertConversationToAnthropic(t *testing.T) {
+	lookup := []ToolCall{
+		{ID: "call_a", Name: "get_order", Arguments: map[string]interface{}{"id": "1"}},
+		{ID: "call_b", Name: "get_user"},
+	}
+
+	tests := []struct {
+		name    string
+		history []ConversationMessage
+		want    string
+	}{
+		{
+			name: "plain text",
+			history: []ConversationMessage{
+				{Role: "user", Content: "hi"},
+				{Role: "assistant", Content: "hello"},
+			},
+			want: `[
+				{"role": "user", "content": "hi"},
+				{"role": "assistant", "content": "hello"}
+			]`,
+		},
+		{
+			name: "results out of order with interleaved user text",
+			history: []ConversationMessage{
+				{Role: "user", Content: "where is my order?"},
+				{Role: "assistant", Content: "Let me check.", ToolCalls: lookup},
+				{Role: "user", ToolCallID: "call_b", Name: "get_user", Content: "Ada"},
+				{Role: "user", Content: "it's order 1"},
+				{Role: "user", ToolCallID: "call_a", Name: "get_order", Content: "shipped"},
+				{Role: "assistant", Content: "It shipped."},
+			},
+			want: `[
+				{"role": "user", "content": "where is my order?"},
+				{"role": "assistant", "content": [
+					{"type": "text", "text": "Let me check."},
+					{"type": "tool_use", "id": "call_a", "name": "get_order", "input": {"id": "1"}},
+					{"type": "tool_use", "id": "call_b", "name": "get_user", "input": {}}
+				]},
+				{"role": "user", "content": [
+					{"type": "tool_result", "tool_use_id": "call_b", "content": "Ada"},
+					{"type": "tool_result", "tool_use_id": "call_a", "content": "shipped"},
+					{"type": "text", "text": "it's order 1"}
+				]},
+				{"role": "assistant", "content": "It shipped."}
+			]`,
+		},
+		{
+			name: "result split across messages",
+			history: []ConversationMessage{
+				{Role: "user", Content: "look up order 1"},
+				{Role: "assistant", ToolCalls: lookup[:1]},
+				{Role: "user", ToolCallID: "call_a", Name: "get_order", Content: "partial"},
+				{Role: "user", ToolCallID: "call_a", Name: "get_order", Content: "timed out", IsError: true},
+			},
+			want: `[
+				{"role": "user", "content": "look up order 1"},
+				{"role": "assistant", "content": [
+					{"type": "tool_use", "id": "call_a", "name": "get_order", "input": {"id": "1"}}
+				]},
+				{"role": "user", "content": [
+					{"type": "tool_result", "tool_use_id": "call_a", "content": "partial\ntimed out", "is_error": true}
+				]}
+			]`,
+		},
+		{
+			name: "missing result",
+			history: []ConversationMessage{
+				{Role: "assistant", ToolCalls: lookup[1:]},
+				{Role: "user", Content: "never mind"},
+			},
+			want: `[
+				{"role": "assistant", "content": [
+					{"type": "tool_use", "id": "call_b", "name": "get_user", "input": {}}
+				]},
+				{"role": "user", "content": [
+					{"type": "tool_result", "tool_use_id": "call_b", "content": "No result was recorded for this tool call", "is_error": true},
+					{"type": "text", "text": "never mind"}
+				]}
+			]`,
+		},
+		{
+			name: "result of a trimmed tool_use",
+			history: []ConversationMessage{
+				{Role: "user", ToolCallID: "call_a", Name: "get_order", Content: "shipped"},
+				{Role: "user", Content: "thanks"},
+			},
+			want: `[
+				{"role": "user", "content": [
+					{"type": "text", "text": "Result of tool get_order: shipped"},
+					{"type": "text", "text": "thanks"}
+				]}
+			]`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &AnthropicProvider{conversationHistory: tt.history}
+			encoded, err := json.Marshal(p.convertConversationToAnthropic())
+			if err != nil {
+				t.Fatalf("failed to encode messages: %v", err)
+			}
+
+			var got, want interface{}
+			if err := json.Unmarshal(encoded, &got); err != nil {
+				t.Fatalf("failed to decode messages: %v", err)
+			}
+			if err := json.Unmarshal([]byte(tt.want), &want); err != nil {
+				t.Fatalf("failed to decode the expected messages: %v", err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("messages = %s\nwant %s", encoded, tt.want)
+			}
+		})
+	}
+}
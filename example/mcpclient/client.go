@@ -10,6 +10,7 @@ import (
 	"github.com/mark3labs/mcp-go/client"
 	"github.com/mark3labs/mcp-go/client/transport"
 	"github.com/mark3labs/mcp-go/mcp"
+	"golang.org/x/sync/errgroup"
 )
 
 // ConversationMessage represents a single message in the conversation history
@@ -19,6 +20,7 @@ type ConversationMessage struct {
 	ToolCalls  []ToolCall `json:"tool_calls,omitempty"`   // Tool calls made by assistant
 	ToolCallID string     `json:"tool_call_id,omitempty"` // ID for tool response messages
 	Name       string     `json:"name,omitempty"`         // Tool name for tool response messages
+	IsError    bool       `json:"is_error,omitempty"`     // Tool response describes a failure
 }
 
 // ConversationConfig holds configuration for conversation management
@@ -39,6 +41,12 @@ func DefaultConversationConfig() ConversationConfig {
 	}
 }
 
+// Default sampling settings shared by all providers
+const (
+	DefaultTemperature = 0.7
+	DefaultMaxTokens   = 4000
+)
+
 // MessageContent represents different types of content that can be sent to LLMs
 type MessageContent struct {
 	Type string      `json:"type"` // "text", "image", "multipart", etc.
@@ -53,8 +61,16 @@ type SendMessageOptions struct {
 	SystemPrompt string
 	MaxTokens    int
 	Temperature  float64
+	ToolChoice   string // One of the ToolChoice constants or the name of a tool to call; empty means auto
 }
 
+// How the LLM may use tools for a message
+const (
+	ToolChoiceAuto     = "auto"     // The LLM decides whether to call tools
+	ToolChoiceNone     = "none"     // The LLM must answer without calling tools
+	ToolChoiceRequired = "required" // The LLM must call at least one tool
+)
+
 // SendMessageOption is a function that configures SendMessageOptions
 type SendMessageOption func(*SendMessageOptions)
 
@@ -136,6 +152,35 @@ func WithTemperature(temperature float64) SendMessageOption {
 	}
 }
 
+// WithToolChoice controls tool use for the message: ToolChoiceAuto, ToolChoiceNone,
+// ToolChoiceRequired, or the name of a tool the LLM must call. ProcessMessage applies it
+// to the first request only, so follow-ups with tool results are free to answer.
+func WithToolChoice(choice string) SendMessageOption {
+	return func(opts *SendMessageOptions) {
+		opts.ToolChoice = choice
+	}
+}
+
+// validateToolChoice checks that a tool choice can be honored with the given tools
+func validateToolChoice(choice string, tools []mcp.Tool) error {
+	switch choice {
+	case "", ToolChoiceAuto, ToolChoiceNone:
+		return nil
+	case ToolChoiceRequired:
+		if len(tools) == 0 {
+			return fmt.Errorf("tool choice %q requires tools", choice)
+		}
+		return nil
+	}
+
+	for _, tool := range tools {
+		if tool.Name == choice {
+			return nil
+		}
+	}
+	return fmt.Errorf("tool choice %q is not one of the available tools", choice)
+}
+
 // WithOverride overrides message options
 func WithOverride(overrides *SendMessageOptions) SendMessageOption {
 	return func(opts *SendMessageOptions) {
@@ -150,10 +195,17 @@ type LLMProvider interface {
 	GetSystemPrompt() string
 	GetProviderName() string
 
+	// Defaults used when SendMessage options don't set a temperature or token limit
+	SetDefaults(temperature float64, maxTokens int)
+	GetDefaults() (temperature float64, maxTokens int)
+
+	// Context window of the current model in tokens, which history is trimmed to fit
+	GetContextWindow() int
+
 	// Conversation management
 	AddUserMessage(content string)
 	AddAssistantMessage(content string, toolCalls []ToolCall)
-	AddToolResponse(toolCallID, toolName, content string)
+	AddToolResponse(toolCallID, toolName, content string, isError bool)
 	GetConversationHistory() []ConversationMessage
 	ClearConversationHistory()
 
@@ -167,6 +219,21 @@ type LLMResponse struct {
 	TextContent string
 	ToolCalls   []ToolCall
 	Usage       TokenUsage
+	StopReason  string // Why generation stopped, one of the Finish constants or the provider's own value
+}
+
+// Reasons an LLM stopped generating, normalized from OpenAI's finish_reason and
+// Anthropic's stop_reason
+const (
+	FinishStop          = "stop"           // The answer is complete
+	FinishLength        = "length"         // The token limit cut the response off
+	FinishToolCalls     = "tool_calls"     // The LLM is waiting for tool results
+	FinishContentFilter = "content_filter" // The provider withheld content under its policy
+)
+
+// Truncated reports whether the response was cut off by the token limit
+func (r *LLMResponse) Truncated() bool {
+	return r.StopReason == FinishLength
 }
 
 type ToolCall struct {
@@ -194,11 +261,27 @@ type MCPClient struct {
 	logger       *slog.Logger
 }
 
+// defaultToolConcurrency is how many tool calls from one LLM response run at once
+const defaultToolConcurrency = 4
+
+// defaultMaxIterations bounds the LLM round trips made for a single user message
+const defaultMaxIterations = 10
+
+// Stop reasons reported when ProcessMessage finishes
+const (
+	StopFinalAnswer   = "final_answer"   // The LLM answered without requesting tools
+	StopMaxIterations = "max_iterations" // The LLM kept requesting tools past the limit
+	StopTruncated     = "truncated"      // The final answer was cut off by the token limit
+	StopContentFilter = "content_filter" // The provider withheld the final answer
+)
+
 // UniversalMCPClient integrates MCP with any LLM provider
 type UniversalMCPClient struct {
-	mcpClient   *MCPClient
-	llmProvider LLMProvider
-	logger      *slog.Logger
+	mcpClient       *MCPClient
+	llmProvider     LLMProvider
+	logger          *slog.Logger
+	toolConcurrency int
+	maxIterations   int
 }
 
 // NewMCPClient creates a new MCP client
@@ -406,21 +489,38 @@ func (c *MCPClient) GetPrompt(ctx context.Context, name string, arguments map[st
 // NewUniversalMCPClient creates a new universal MCP client
 func NewUniversalMCPClient(mcpClient *MCPClient, llmProvider LLMProvider, logger *slog.Logger) *UniversalMCPClient {
 	return &UniversalMCPClient{
-		mcpClient:   mcpClient,
-		llmProvider: llmProvider,
-		logger:      logger,
+		mcpClient:       mcpClient,
+		llmProvider:     llmProvider,
+		logger:          logger,
+		toolConcurrency: defaultToolConcurrency,
+		maxIterations:   defaultMaxIterations,
 	}
 }
 
+// SetMaxIterations sets how many LLM round trips a single user message may take
+// before the client stops executing further tool calls
+func (c *UniversalMCPClient) SetMaxIterations(limit int) {
+	c.maxIterations = max(limit, 1)
+}
+
+// SetToolConcurrency sets how many tool calls from one LLM response run at once
+func (c *UniversalMCPClient) SetToolConcurrency(limit int) {
+	c.toolConcurrency = max(limit, 1)
+}
+
 // ProcessMessage handles a user message and coordinates LLM and MCP interactions
 func (c *UniversalMCPClient) ProcessMessage(ctx context.Context, options ...SendMessageOption) error {
 	c.logger.Info("Processing user message", "provider", c.llmProvider.GetProviderName())
 
+	// Start from the provider's defaults; WithOverride replaces all of its options, so the
+	// system prompt must be carried over here or it would be dropped from every request
+	temperature, maxTokens := c.llmProvider.GetDefaults()
 	opts := &SendMessageOptions{
-		Role:        "user",
-		MaxTokens:   4000,
-		Temperature: 0.7,
-		Tools:       c.mcpClient.capabilities.Tools,
+		Role:         "user",
+		MaxTokens:    maxTokens,
+		Temperature:  temperature,
+		SystemPrompt: c.llmProvider.GetSystemPrompt(),
+		Tools:        c.mcpClient.capabilities.Tools,
 	}
 	for _, fn := range options {
 		fn(opts)
@@ -433,89 +533,146 @@ func (c *UniversalMCPClient) ProcessMessage(ctx context.Context, options ...Send
 		return fmt.Errorf("LLM request failed: %w", err)
 	}
 
-	// Process LLM response
-	if response.TextContent != "" {
-		fmt.Printf("🤖 %s: %s\n", c.llmProvider.GetProviderName(), response.TextContent)
-	}
+	// Keep executing tool calls and sending their results until the LLM answers
+	// without requesting tools, or the iteration limit is reached
+	usage := TokenUsage{}
+	stopReason := StopFinalAnswer
+	iteration := 1
+	for ; ; iteration++ {
+		if response.TextContent != "" {
+			fmt.Printf("🤖 %s: %s\n", c.llmProvider.GetProviderName(), response.TextContent)
+		}
+		usage.InputTokens += response.Usage.InputTokens
+		usage.OutputTokens += response.Usage.OutputTokens
+
+		switch response.StopReason {
+		case FinishLength:
+			c.logger.Warn("LLM response was cut off by the token limit", "max_tokens", opts.MaxTokens)
+			fmt.Printf("⚠️ Response cut off at %d tokens\n", opts.MaxTokens)
+		case FinishContentFilter:
+			c.logger.Warn("LLM response was withheld by the provider's content policy")
+			fmt.Println("⚠️ Response withheld by the provider's content policy")
+		case FinishToolCalls:
+			if len(response.ToolCalls) == 0 {
+				c.logger.Warn("LLM stopped for tool calls but none could be used")
+			}
+		}
 
-	// Execute any tool calls
-	for _, toolCall := range response.ToolCalls {
-		if err := c.executeToolCall(ctx, toolCall); err != nil {
-			c.logger.Error("Tool execution failed", "tool", toolCall.Name, "error", err)
-			fmt.Printf("❌ Failed to execute tool %s: %v\n", toolCall.Name, err)
-			continue
+		if len(response.ToolCalls) == 0 {
+			switch response.StopReason {
+			case FinishLength:
+				stopReason = StopTruncated
+			case FinishContentFilter:
+				stopReason = StopContentFilter
+			}
+			break
 		}
-	}
 
-	// If tool calls were executed, send tool responses back to LLM
-	if len(response.ToolCalls) > 0 {
-		c.logger.Info("Sending tool responses back to LLM")
+		if iteration >= c.maxIterations {
+			// Answer the pending calls so the conversation stays valid for the next turn
+			stopReason = StopMaxIterations
+			c.logger.Warn("Tool iteration limit reached", "max_iterations", c.maxIterations)
+			fmt.Printf("⚠️ Stopped after %d tool rounds\n", c.maxIterations)
+			for _, toolCall := range response.ToolCalls {
+				c.llmProvider.AddToolResponse(toolCall.ID, toolCall.Name, "Tool call skipped: iteration limit reached", true)
+			}
+			break
+		}
+
+		c.executeToolCalls(ctx, response.ToolCalls)
+
+		c.logger.Info("Sending tool responses back to LLM", "iteration", iteration)
 
 		// Send empty message to continue conversation with tool results
-		toolResponse, err := c.llmProvider.SendMessage(ctx, WithOverride(&SendMessageOptions{
+		response, err = c.llmProvider.SendMessage(ctx, WithOverride(&SendMessageOptions{
 			Tools:        opts.Tools,
 			MaxTokens:    opts.MaxTokens,
 			Temperature:  opts.Temperature,
 			SystemPrompt: opts.SystemPrompt,
 		}))
-
 		if err != nil {
 			c.logger.Error("Failed to send tool responses to LLM", "error", err)
 			return fmt.Errorf("failed to send tool responses to LLM: %w", err)
 		}
-
-		// Display LLM response to tool results
-		if toolResponse.TextContent != "" {
-			fmt.Printf("🤖 %s: %s\n", c.llmProvider.GetProviderName(), toolResponse.TextContent)
-		}
-
-		// Handle any additional tool calls (recursive)
-		for _, toolCall := range toolResponse.ToolCalls {
-			if err := c.executeToolCall(ctx, toolCall); err != nil {
-				c.logger.Error("Tool execution failed", "tool", toolCall.Name, "error", err)
-				fmt.Printf("❌ Failed to execute tool %s: %v\n", toolCall.Name, err)
-				continue
-			}
-		}
 	}
 
 	// Log token usage
-	c.logger.Info("Token usage",
-		"input_tokens", response.Usage.InputTokens,
-		"output_tokens", response.Usage.OutputTokens)
+	c.logger.Info("Message processed",
+		"iterations", iteration,
+		"stop_reason", stopReason,
+		"input_tokens", usage.InputTokens,
+		"output_tokens", usage.OutputTokens)
 
-	fmt.Printf("📊 Tokens: %d input, %d output\n", response.Usage.InputTokens, response.Usage.OutputTokens)
+	fmt.Printf("📊 Tokens: %d input, %d output (%d rounds, stop: %s)\n", usage.InputTokens, usage.OutputTokens, iteration, stopReason)
 
 	return nil
 }
 
-func (c *UniversalMCPClient) executeToolCall(ctx context.Context, toolCall ToolCall) error {
-	c.logger.Info("Executing tool call", "name", toolCall.Name)
-	fmt.Printf("🔧 Executing tool: %s\n", toolCall.Name)
+// executeToolCalls runs independent tool calls concurrently, up to the tool concurrency
+// limit, then records their results in the order the LLM requested them so the
+// follow-up message is reproducible
+func (c *UniversalMCPClient) executeToolCalls(ctx context.Context, toolCalls []ToolCall) {
+	type outcome struct {
+		result *mcp.CallToolResult
+		err    error
+	}
 
-	result, err := c.mcpClient.CallTool(ctx, toolCall.Name, toolCall.Arguments)
-	if err != nil {
-		return err
+	outcomes := make([]outcome, len(toolCalls))
+
+	var group errgroup.Group
+	group.SetLimit(max(c.toolConcurrency, 1))
+	for i, toolCall := range toolCalls {
+		group.Go(func() error {
+			c.logger.Info("Executing tool call", "name", toolCall.Name)
+			fmt.Printf("🔧 Executing tool: %s\n", toolCall.Name)
+
+			result, err := c.mcpClient.CallTool(ctx, toolCall.Name, toolCall.Arguments)
+			outcomes[i] = outcome{result: result, err: err}
+			return nil
+		})
 	}
+	group.Wait()
+
+	for i, toolCall := range toolCalls {
+		if err := outcomes[i].err; err != nil {
+			c.logger.Error("Tool execution failed", "tool", toolCall.Name, "error", err)
+			fmt.Printf("❌ Failed to execute tool %s: %v\n", toolCall.Name, err)
 
-	// Display tool result
+			// Tell the LLM the call failed so it can recover instead of waiting for a result
+			c.llmProvider.AddToolResponse(toolCall.ID, toolCall.Name, fmt.Sprintf("Tool execution failed: %v", err), true)
+			continue
+		}
+		c.recordToolResult(toolCall, outcomes[i].result)
+	}
+}
+
+// recordToolResult displays a tool result and adds it to the conversation history
+func (c *UniversalMCPClient) recordToolResult(toolCall ToolCall, result *mcp.CallToolResult) {
+	// Results flagged IsError describe a failure, such as a backend 4xx/5xx
+	marker := "✅ Tool result"
+	if result.IsError {
+		marker = "⚠️ Tool reported an error"
+		c.logger.Warn("Tool returned an error result", "tool", toolCall.Name)
+	}
+
+	// Display each content item, then answer the tool call once with all of them, since
+	// providers expect exactly one response per call
+	parts := make([]string, 0, len(result.Content))
 	for _, content := range result.Content {
 		// Handle different content types using type assertion
+		text := fmt.Sprintf("%+v", content)
 		if textContent, ok := content.(mcp.TextContent); ok {
-			fmt.Printf("✅ Tool result: %s\n", textContent.Text)
-
-			// Add tool response to conversation history
-			c.llmProvider.AddToolResponse(toolCall.ID, toolCall.Name, textContent.Text)
-		} else {
-			// Generic content handling
-			fmt.Printf("✅ Tool result: %+v\n", content)
-
-			// Add tool response to conversation history
-			c.llmProvider.AddToolResponse(toolCall.ID, toolCall.Name, fmt.Sprintf("%+v", content))
+			text = textContent.Text
 		}
+		fmt.Printf("%s: %s\n", marker, text)
+		parts = append(parts, text)
 	}
 
-	return nil
+	response := strings.Join(parts, "\n")
+	if response == "" {
+		response = "Tool returned no content"
+	}
+	c.llmProvider.AddToolResponse(toolCall.ID, toolCall.Name, response, result.IsError)
 }
 
 // ListCapabilities displays all available MCP capabilities
@@ -563,7 +720,7 @@ func (c *UniversalMCPClient) ListCapabilities() {
 		}
 	}
 
-	fmt.Println("=== End Capabilities ===\n")
+	fmt.Print("=== End Capabilities ===\n\n")
 }
 
 // ShowProviderInfo displays current LLM provider information
@@ -588,5 +745,5 @@ func (c *UniversalMCPClient) ShowProviderInfo() {
 		fmt.Printf("Messages in conversation: %d\n", len(conversation))
 	}
 
-	fmt.Println("=== End Provider Info ===\n")
+	fmt.Print("=== End Provider Info ===\n\n")
 }
@@ -0,0 +1,63 @@
+package client
+
+import (
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestRecordToolResultRespondsOnce(t *testing.T) {
+	tests := []struct {
+		name    string
+		result  *mcp.CallToolResult
+		want    string
+		isError bool
+	}{
+		{
+			name: "several content items",
+			result: &mcp.CallToolResult{Content: []mcp.Content{
+				mcp.NewTextContent("first"),
+				mcp.NewTextContent("second"),
+			}},
+			want: "first\nsecond",
+		},
+		{
+			name:   "no content",
+			result: &mcp.CallToolResult{},
+			want:   "Tool returned no content",
+		},
+		{
+			name: "error result",
+			result: &mcp.CallToolResult{
+				Content: []mcp.Content{mcp.NewTextContent("backend returned 500")},
+				IsError: true,
+			},
+			want:    "backend returned 500",
+			isError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+			provider, err := NewAnthropicProvider("test-key", logger)
+			if err != nil {
+				t.Fatalf("NewAnthropicProvider failed: %v", err)
+			}
+			c := &UniversalMCPClient{llmProvider: provider, logger: logger}
+
+			c.recordToolResult(ToolCall{ID: "call_1", Name: "get_user"}, tt.result)
+
+			history := provider.GetConversationHistory()
+			if len(history) != 1 {
+				t.Fatalf("history has %d messages, want one response for the tool call", len(history))
+			}
+			got := history[0]
+			if got.ToolCallID != "call_1" || got.Content != tt.want || got.IsError != tt.isError {
+				t.Errorf("tool response = %+v, want content %q and error %v", got, tt.want, tt.isError)
+			}
+		})
+	}
+}
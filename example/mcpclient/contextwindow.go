@@ -0,0 +1,104 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// contextWindows lists the context window, in tokens, of known models by name prefix.
+// More specific prefixes come first.
+var contextWindows = []struct {
+	prefix string
+	tokens int
+}{
+	{"claude-", 200000},
+	{"gpt-4o", 128000},
+	{"gpt-4-turbo", 128000},
+	{"gpt-4.1", 1047576},
+	{"gpt-4", 8192},
+	{"gpt-3.5-turbo", 16385},
+	{"o1", 200000},
+	{"o3", 200000},
+}
+
+// contextWindowFor returns the context window of a model, or 0 for models not in the
+// table, such as deployment names and local models
+func contextWindowFor(model string) int {
+	for _, entry := range contextWindows {
+		if strings.HasPrefix(model, entry.prefix) {
+			return entry.tokens
+		}
+	}
+	return 0
+}
+
+// estimateRequestTokens roughly estimates the tokens of a request's system prompt and
+// tool definitions, 4 characters to a token like estimateTokens
+func estimateRequestTokens(systemPrompt string, tools []mcp.Tool) int {
+	chars := len(systemPrompt)
+	if len(tools) > 0 {
+		if encoded, err := json.Marshal(tools); err == nil {
+			chars += len(encoded)
+		}
+	}
+	return chars / 4
+}
+
+// estimateMessageTokens roughly estimates the tokens of a conversation message, including
+// its tool call arguments
+func estimateMessageTokens(msg ConversationMessage) int {
+	chars := len(msg.Content)
+	for _, toolCall := range msg.ToolCalls {
+		chars += len(toolCall.Name)
+		if encoded, err := json.Marshal(toolCall.Arguments); err == nil {
+			chars += len(encoded)
+		}
+	}
+	return chars / 4
+}
+
+// fitContextWindow drops the oldest turns of history until the request, made of the
+// fixed tokens of its system prompt and tools, the history and the maximum output,
+// fits the context window. History is only cut before a user message, so tool results
+// are never separated from the calls they answer. It fails with
+// ErrContextWindowExceeded when even the latest turn doesn't fit. A window of 0, for
+// models whose window isn't known, leaves history as is.
+func fitContextWindow(history []ConversationMessage, fixedTokens, maxOutput, window int, logger *slog.Logger) ([]ConversationMessage, error) {
+	if window <= 0 {
+		return history, nil
+	}
+
+	total := fixedTokens + maxOutput
+	for _, msg := range history {
+		total += estimateMessageTokens(msg)
+	}
+	if total <= window {
+		return history, nil
+	}
+
+	originalLength := len(history)
+	originalTotal := total
+	for start := 1; start < len(history); start++ {
+		total -= estimateMessageTokens(history[start-1])
+		if total <= window && isTurnStart(history[start]) {
+			logger.Warn("Conversation history trimmed to fit the context window",
+				"context_window", window,
+				"estimated_tokens", originalTotal,
+				"messages_removed", start,
+				"new_length", originalLength-start)
+			return history[start:], nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: about %d tokens including %d for the response, limit %d", ErrContextWindowExceeded, total, maxOutput, window)
+}
+
+// isTurnStart reports whether a conversation can begin at msg: a user message that
+// isn't a tool result
+func isTurnStart(msg ConversationMessage) bool {
+	return msg.Role == "user" && msg.ToolCallID == ""
+}
@@ -0,0 +1,42 @@
+package client
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestContextWindow(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	provider, err := NewOpenAIProvider("test-key", logger)
+	if err != nil {
+		t.Fatalf("failed to create provider: %v", err)
+	}
+
+	provider.SetModel("gpt-4o-mini")
+	if got := provider.GetContextWindow(); got != 128000 {
+		t.Errorf("gpt-4o-mini window = %d, want 128000", got)
+	}
+	provider.SetModel("my-azure-deployment")
+	if got := provider.GetContextWindow(); got != 0 {
+		t.Errorf("unknown model window = %d, want 0", got)
+	}
+	provider.SetContextWindow(32000)
+	if got := provider.GetContextWindow(); got != 32000 {
+		t.Errorf("window after SetContextWindow(32000) = %d, want 32000", got)
+	}
+
+	// About 5000 tokens of history, well over an 8192 token window with the output
+	history := []ConversationMessage{
+		{Role: "user", Content: strings.Repeat("a", 10000)},
+		{Role: "assistant", Content: strings.Repeat("b", 10000)},
+	}
+	if got, err := fitContextWindow(history, 0, DefaultMaxTokens, 0, logger); err != nil || len(got) != len(history) {
+		t.Errorf("unknown window returned %d messages and %v, want the history unchanged", len(got), err)
+	}
+	if _, err := fitContextWindow(history, 0, DefaultMaxTokens, 8192, logger); !errors.Is(err, ErrContextWindowExceeded) {
+		t.Errorf("8192 token window returned %v, want ErrContextWindowExceeded", err)
+	}
+}
@@ -0,0 +1,102 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// ErrResponseTruncated reports a response cut off by the token limit in a way that
+// can't be used, such as in the middle of a tool call's arguments
+var ErrResponseTruncated = errors.New("LLM response was cut off by the max tokens limit")
+
+// ErrContextWindowExceeded reports a request that can't fit the model's context window,
+// even after dropping all but the latest turn of the conversation
+var ErrContextWindowExceeded = errors.New("request doesn't fit the model's context window")
+
+// LLMError is an error returned by an LLM provider's API
+type LLMError struct {
+	Provider   string // Provider that returned the error
+	StatusCode int    // HTTP status of the API response
+	Type       string // Provider error code or type, e.g. "invalid_api_key" or "rate_limit_error"
+	Message    string // Provider error message, or the raw response body
+}
+
+// Error implements the error interface
+func (e *LLMError) Error() string {
+	if e.Type != "" {
+		return fmt.Sprintf("%s API request failed with status %d (%s): %s", e.Provider, e.StatusCode, e.Type, e.Message)
+	}
+	return fmt.Sprintf("%s API request failed with status %d: %s", e.Provider, e.StatusCode, e.Message)
+}
+
+// IsAuthError reports whether the API rejected the credentials
+func (e *LLMError) IsAuthError() bool {
+	return e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden
+}
+
+// IsRateLimited reports whether the API rejected the request for exceeding a rate limit or quota
+func (e *LLMError) IsRateLimited() bool {
+	return e.StatusCode == http.StatusTooManyRequests
+}
+
+// IsOverloaded reports whether the API was temporarily unable to serve the request
+func (e *LLMError) IsOverloaded() bool {
+	return e.StatusCode == 529 || e.StatusCode >= 500
+}
+
+// IsContentPolicy reports whether the request was rejected by the provider's content policy
+func (e *LLMError) IsContentPolicy() bool {
+	return e.Type == "content_policy_violation" || e.Type == "content_filter"
+}
+
+// FriendlyMessage describes the error for people rather than logs
+func (e *LLMError) FriendlyMessage() string {
+	switch {
+	case e.IsAuthError():
+		return fmt.Sprintf("%s rejected the API key. Check your credentials.", e.Provider)
+	case e.IsRateLimited():
+		return fmt.Sprintf("%s rate limit reached. Wait a moment and try again.", e.Provider)
+	case e.IsContentPolicy():
+		return fmt.Sprintf("%s declined the request under its content policy.", e.Provider)
+	case e.IsOverloaded():
+		return fmt.Sprintf("%s is temporarily unavailable. Try again shortly.", e.Provider)
+	default:
+		return fmt.Sprintf("%s returned an error: %s", e.Provider, e.Message)
+	}
+}
+
+// newLLMError builds an LLMError from an API error response. It understands the
+// {"error": {"type", "code", "message"}} envelopes used by OpenAI and Anthropic.
+func newLLMError(provider string, statusCode int, body []byte) *LLMError {
+	llmErr := &LLMError{
+		Provider:   provider,
+		StatusCode: statusCode,
+		Message:    string(body),
+	}
+
+	var envelope struct {
+		Error struct {
+			Type    string `json:"type"`
+			Code    any    `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if json.Unmarshal(body, &envelope) != nil {
+		return llmErr
+	}
+
+	if envelope.Error.Message != "" {
+		llmErr.Message = envelope.Error.Message
+	}
+
+	// OpenAI's code is more specific than its type; Anthropic only sets a type
+	if code, ok := envelope.Error.Code.(string); ok && code != "" {
+		llmErr.Type = code
+	} else {
+		llmErr.Type = envelope.Error.Type
+	}
+
+	return llmErr
+}
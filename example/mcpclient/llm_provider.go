@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"log/slog"
 	"os"
+	"slices"
 	"strings"
 )
 
@@ -24,24 +25,47 @@ type ProviderConfig struct {
 	BaseURL      string
 	Model        string
 	SystemPrompt string
+
+	// ContextWindow is the model's context window in tokens, for models the built-in
+	// table doesn't know. Without one, history of unknown models isn't trimmed.
+	ContextWindow int
 }
 
+// ProviderConstructor builds an LLM provider from its configuration
+type ProviderConstructor func(config ProviderConfig, logger *slog.Logger) (LLMProvider, error)
+
 // ProviderFactory creates LLM providers based on configuration
 type ProviderFactory struct {
-	logger *slog.Logger
+	logger   *slog.Logger
+	registry map[ProviderType]ProviderConstructor
 }
 
 // NewProviderFactory creates a new provider factory
 func NewProviderFactory(logger *slog.Logger) *ProviderFactory {
 	return &ProviderFactory{
-		logger: logger,
+		logger:   logger,
+		registry: make(map[ProviderType]ProviderConstructor),
 	}
 }
 
+// RegisterProvider adds a custom provider type, or replaces a built-in one.
+// Registered providers are configured from the environment with variables prefixed by
+// the upper-cased name, e.g. MISTRAL_API_KEY, MISTRAL_BASE_URL and MISTRAL_MODEL.
+// Register providers before creating any.
+func (f *ProviderFactory) RegisterProvider(name ProviderType, constructor ProviderConstructor) {
+	f.registry[ProviderType(strings.ToLower(string(name)))] = constructor
+}
+
 // CreateProvider creates an LLM provider based on the configuration
 func (f *ProviderFactory) CreateProvider(config ProviderConfig) (LLMProvider, error) {
+	// Types are registered lowercased, so "Mistral" finds a provider registered as "mistral"
+	config.Type = ProviderType(strings.ToLower(string(config.Type)))
 	f.logger.Info("Creating LLM provider", "type", config.Type, "model", config.Model)
 
+	if constructor, ok := f.registry[config.Type]; ok {
+		return constructor(config, f.logger)
+	}
+
 	switch config.Type {
 	case ProviderAnthropic:
 		return f.createAnthropicProvider(config)
@@ -61,22 +85,22 @@ func (f *ProviderFactory) CreateProviderFromEnv() (LLMProvider, error) {
 	// Check for explicit provider configuration first
 	providerEnv := os.Getenv("LLM_PROVIDER")
 	if providerEnv != "" {
-		providerType, err := GetProviderFromString(providerEnv)
+		providerType, err := f.providerFromString(providerEnv)
 		if err != nil {
 			return nil, fmt.Errorf("invalid LLM_PROVIDER value '%s': %w", providerEnv, err)
 		}
-		
+
 		config, err := f.getConfigForProvider(providerType)
 		if err != nil {
 			return nil, err
 		}
-		
+
 		return f.CreateProvider(config)
 	}
 
 	// Fallback: auto-detect based on available environment variables
 	f.logger.Info("LLM_PROVIDER not set, auto-detecting from available environment variables")
-	
+
 	// Check which provider is configured via environment variables
 	if apiKey := os.Getenv("ANTHROPIC_API_KEY"); apiKey != "" {
 		config := ProviderConfig{
@@ -121,45 +145,54 @@ func (f *ProviderFactory) CreateProviderFromEnv() (LLMProvider, error) {
 // getConfigForProvider creates a config for the specified provider type using environment variables
 func (f *ProviderFactory) getConfigForProvider(providerType ProviderType) (ProviderConfig, error) {
 	config := ProviderConfig{Type: providerType}
-	
+
+	// Registered providers read <NAME>_API_KEY, <NAME>_BASE_URL and <NAME>_MODEL
+	if _, ok := f.registry[providerType]; ok {
+		prefix := strings.ToUpper(string(providerType))
+		config.APIKey = os.Getenv(prefix + "_API_KEY")
+		config.BaseURL = os.Getenv(prefix + "_BASE_URL")
+		config.Model = os.Getenv(prefix + "_MODEL")
+		return config, nil
+	}
+
 	switch providerType {
 	case ProviderAnthropic:
 		config.APIKey = os.Getenv("ANTHROPIC_API_KEY")
 		config.Model = getEnvOrDefault("ANTHROPIC_MODEL", "claude-3-5-sonnet-20241022")
-		
+
 		if config.APIKey == "" {
 			return config, fmt.Errorf("ANTHROPIC_API_KEY environment variable is required for Anthropic provider")
 		}
-		
+
 	case ProviderOpenAI:
 		config.APIKey = os.Getenv("OPENAI_API_KEY")
 		config.BaseURL = getEnvOrDefault("OPENAI_BASE_URL", "https://api.openai.com/v1")
 		config.Model = getEnvOrDefault("OPENAI_MODEL", "gpt-4o")
-		
+
 		if config.APIKey == "" {
 			return config, fmt.Errorf("OPENAI_API_KEY environment variable is required for OpenAI provider")
 		}
-		
+
 	case ProviderOllama:
 		config.BaseURL = os.Getenv("OLLAMA_BASE_URL")
 		config.Model = getEnvOrDefault("OLLAMA_MODEL", "llama2")
-		
+
 		if config.BaseURL == "" {
 			return config, fmt.Errorf("OLLAMA_BASE_URL environment variable is required for Ollama provider")
 		}
-		
+
 	case ProviderLocal:
 		config.BaseURL = os.Getenv("LOCAL_LLM_URL")
 		config.Model = getEnvOrDefault("LOCAL_LLM_MODEL", "local-model")
-		
+
 		if config.BaseURL == "" {
 			return config, fmt.Errorf("LOCAL_LLM_URL environment variable is required for local provider")
 		}
-		
+
 	default:
 		return config, fmt.Errorf("unsupported provider type: %s", providerType)
 	}
-	
+
 	return config, nil
 }
 
@@ -177,6 +210,10 @@ func (f *ProviderFactory) createAnthropicProvider(config ProviderConfig) (LLMPro
 		provider.SetSystemPrompt(config.SystemPrompt)
 	}
 
+	if config.ContextWindow > 0 {
+		provider.SetContextWindow(config.ContextWindow)
+	}
+
 	return provider, nil
 }
 
@@ -198,6 +235,10 @@ func (f *ProviderFactory) createOpenAIProvider(config ProviderConfig) (LLMProvid
 		provider.SetSystemPrompt(config.SystemPrompt)
 	}
 
+	if config.ContextWindow > 0 {
+		provider.SetContextWindow(config.ContextWindow)
+	}
+
 	return provider, nil
 }
 
@@ -213,14 +254,33 @@ func (f *ProviderFactory) createLocalProvider(config ProviderConfig) (LLMProvide
 	return nil, fmt.Errorf("Local provider not yet implemented")
 }
 
-// GetAvailableProviders returns a list of available provider types
+// GetAvailableProviders returns a list of available provider types, including registered ones
 func (f *ProviderFactory) GetAvailableProviders() []ProviderType {
-	return []ProviderType{
+	providers := []ProviderType{
 		ProviderAnthropic,
 		ProviderOpenAI,
 		ProviderOllama,
 		ProviderLocal,
 	}
+
+	var registered []ProviderType
+	for name := range f.registry {
+		if !slices.Contains(providers, name) {
+			registered = append(registered, name)
+		}
+	}
+	slices.Sort(registered)
+
+	return append(providers, registered...)
+}
+
+// providerFromString resolves a provider name, checking registered providers first
+func (f *ProviderFactory) providerFromString(provider string) (ProviderType, error) {
+	name := ProviderType(strings.ToLower(provider))
+	if _, ok := f.registry[name]; ok {
+		return name, nil
+	}
+	return GetProviderFromString(provider)
 }
 
 // GetProviderFromString converts a string to ProviderType
@@ -246,7 +306,7 @@ func (f *ProviderFactory) GetConfigFromFlags(providerStr, model, apiKey, baseURL
 
 // GetConfigFromFlagsWithSystem creates a provider config from command line flags with system prompt
 func (f *ProviderFactory) GetConfigFromFlagsWithSystem(providerStr, model, apiKey, baseURL, systemPrompt string) (ProviderConfig, error) {
-	providerType, err := GetProviderFromString(providerStr)
+	providerType, err := f.providerFromString(providerStr)
 	if err != nil {
 		return ProviderConfig{}, err
 	}
@@ -24,6 +24,10 @@ type OpenAIProvider struct {
 	systemPrompt        string
 	conversationHistory []ConversationMessage
 	conversationConfig  ConversationConfig
+	maxRetries          int
+	temperature         float64
+	maxTokens           int
+	contextWindow       int // Overrides the model's known context window when set
 }
 
 // OpenAI API structures
@@ -31,7 +35,7 @@ type OpenAIRequest struct {
 	Model       string          `json:"model"`
 	Messages    []OpenAIMessage `json:"messages"`
 	Tools       []OpenAITool    `json:"tools,omitempty"`
-	ToolChoice  string          `json:"tool_choice,omitempty"`
+	ToolChoice  interface{}     `json:"tool_choice,omitempty"`
 	MaxTokens   int             `json:"max_tokens,omitempty"`
 	Temperature float64         `json:"temperature,omitempty"`
 }
@@ -101,6 +105,9 @@ func NewOpenAIProvider(apiKey string, logger *slog.Logger) (*OpenAIProvider, err
 		model:              "gpt-4o", // Default model
 		baseURL:            "https://api.openai.com/v1",
 		conversationConfig: DefaultConversationConfig(),
+		maxRetries:         defaultMaxRetries,
+		temperature:        DefaultTemperature,
+		maxTokens:          DefaultMaxTokens,
 	}, nil
 }
 
@@ -121,18 +128,43 @@ func (p *OpenAIProvider) SetBaseURL(baseURL string) {
 	p.logger.Info("Base URL changed", "new_url", p.baseURL)
 }
 
+// SetDefaults sets the temperature and token limit used when SendMessage options don't set them
+func (p *OpenAIProvider) SetDefaults(temperature float64, maxTokens int) {
+	p.temperature = temperature
+	if maxTokens > 0 {
+		p.maxTokens = maxTokens
+	}
+	p.logger.Info("Defaults changed", "temperature", temperature, "max_tokens", p.maxTokens)
+}
+
+// GetDefaults returns the default temperature and token limit
+func (p *OpenAIProvider) GetDefaults() (float64, int) {
+	return p.temperature, p.maxTokens
+}
+
+// SetMaxRetries sets how many times rate-limited or failed API calls are retried
+func (p *OpenAIProvider) SetMaxRetries(maxRetries int) {
+	p.maxRetries = maxRetries
+}
+
 // SendMessage sends a message to OpenAI using function options
 func (p *OpenAIProvider) SendMessage(ctx context.Context, options ...SendMessageOption) (*LLMResponse, error) {
 	// Apply options
 	opts := &SendMessageOptions{
 		Role:         "user",
-		Temperature:  0.7,
-		MaxTokens:    4000,
+		MaxTokens:    p.maxTokens,
+		Temperature:  p.temperature,
 		SystemPrompt: p.systemPrompt,
 	}
 	for _, option := range options {
 		option(opts)
 	}
+	if opts.MaxTokens <= 0 {
+		opts.MaxTokens = p.maxTokens
+	}
+	if err := validateToolChoice(opts.ToolChoice, opts.Tools); err != nil {
+		return nil, err
+	}
 
 	// Validate that message is provided
 	if opts.Message == nil {
@@ -148,6 +180,13 @@ func (p *OpenAIProvider) SendMessage(ctx context.Context, options ...SendMessage
 	// Convert MCP tools to OpenAI format
 	openaiTools := p.convertMCPToolsToOpenAI(opts.Tools)
 
+	// Trim history to the context window now rather than have the API reject the request
+	history, err := fitContextWindow(p.conversationHistory, estimateRequestTokens(opts.SystemPrompt, opts.Tools), opts.MaxTokens, p.GetContextWindow(), p.logger)
+	if err != nil {
+		return nil, err
+	}
+	p.conversationHistory = history
+
 	// Convert conversation history to OpenAI format
 	messages := p.convertConversationToOpenAI(opts.SystemPrompt)
 
@@ -162,7 +201,7 @@ func (p *OpenAIProvider) SendMessage(ctx context.Context, options ...SendMessage
 	// Add tools if available
 	if len(openaiTools) > 0 {
 		request.Tools = openaiTools
-		request.ToolChoice = "auto"
+		request.ToolChoice = convertToolChoiceToOpenAI(opts.ToolChoice)
 	}
 
 	// Marshal request
@@ -172,21 +211,22 @@ func (p *OpenAIProvider) SendMessage(ctx context.Context, options ...SendMessage
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	// Create HTTP request
 	url := fmt.Sprintf("%s/chat/completions", p.baseURL)
-	httpReq, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(reqBody))
-	if err != nil {
-		p.logger.Error("Failed to create HTTP request", "error", err)
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	// Set headers
-	httpReq.Header.Set("Content-Type", "application/json")
-	httpReq.Header.Set("Authorization", "Bearer "+p.apiKey)
 
-	// Make request
+	// Make request, retrying rate limits and transient errors
 	startTime := time.Now()
-	resp, err := p.httpClient.Do(httpReq)
+	resp, err := sendWithRetries(ctx, p.httpClient, p.logger, p.maxRetries, func() (*http.Request, error) {
+		httpReq, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(reqBody))
+		if err != nil {
+			p.logger.Error("Failed to create HTTP request", "error", err)
+			return nil, fmt.Errorf("failed to create request: %w", err)
+		}
+
+		// Set headers
+		httpReq.Header.Set("Content-Type", "application/json")
+		httpReq.Header.Set("Authorization", "Bearer "+p.apiKey)
+		return httpReq, nil
+	})
 	if err != nil {
 		p.logger.Error("HTTP request failed", "error", err)
 		return nil, fmt.Errorf("failed to make request: %w", err)
@@ -200,7 +240,7 @@ func (p *OpenAIProvider) SendMessage(ctx context.Context, options ...SendMessage
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
 		p.logger.Error("API request failed", "status", resp.StatusCode, "body", string(body))
-		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
+		return nil, newLLMError("OpenAI", resp.StatusCode, body)
 	}
 
 	// Parse response
@@ -252,6 +292,22 @@ func (p *OpenAIProvider) convertMCPToolsToOpenAI(mcpTools []mcp.Tool) []OpenAITo
 	return tools
 }
 
+// convertToolChoiceToOpenAI converts a tool choice to OpenAI's tool_choice, which names
+// a specific tool with a function object
+func convertToolChoiceToOpenAI(choice string) interface{} {
+	switch choice {
+	case "":
+		return ToolChoiceAuto
+	case ToolChoiceAuto, ToolChoiceNone, ToolChoiceRequired:
+		return choice
+	default:
+		return map[string]interface{}{
+			"type":     "function",
+			"function": map[string]string{"name": choice},
+		}
+	}
+}
+
 // convertOpenAIResponse converts OpenAI response to unified format
 func (p *OpenAIProvider) convertOpenAIResponse(resp *OpenAIResponse) *LLMResponse {
 	llmResp := &LLMResponse{
@@ -271,6 +327,16 @@ func (p *OpenAIProvider) convertOpenAIResponse(resp *OpenAIResponse) *LLMRespons
 			llmResp.TextContent = content
 		}
 
+		// finish_reason already uses the Finish values; "function_call" is the legacy
+		// name for tool calls
+		llmResp.StopReason = choice.FinishReason
+		if llmResp.StopReason == "function_call" {
+			llmResp.StopReason = FinishToolCalls
+		}
+		if llmResp.Truncated() {
+			p.logger.Warn("Response truncated at the max_tokens limit", "output_tokens", resp.Usage.CompletionTokens)
+		}
+
 		// Handle tool calls
 		for _, toolCall := range choice.Message.ToolCalls {
 			if toolCall.Type == "function" {
@@ -295,6 +361,7 @@ func (p *OpenAIProvider) convertOpenAIResponse(resp *OpenAIResponse) *LLMRespons
 	p.logger.Info("Response converted",
 		"text_length", len(llmResp.TextContent),
 		"tool_calls", len(llmResp.ToolCalls),
+		"stop_reason", llmResp.StopReason,
 		"input_tokens", llmResp.Usage.InputTokens,
 		"output_tokens", llmResp.Usage.OutputTokens)
 
@@ -317,6 +384,21 @@ func (p *OpenAIProvider) GetCurrentModel() string {
 	return p.model
 }
 
+// GetContextWindow returns the context window of the current model in tokens, or 0
+// when it isn't known, in which case history isn't trimmed
+func (p *OpenAIProvider) GetContextWindow() int {
+	if p.contextWindow > 0 {
+		return p.contextWindow
+	}
+	return contextWindowFor(p.model)
+}
+
+// SetContextWindow sets the context window in tokens for models the built-in table
+// doesn't know, such as deployment names or compatible APIs. Zero uses the table again.
+func (p *OpenAIProvider) SetContextWindow(tokens int) {
+	p.contextWindow = tokens
+}
+
 // SetSystemPrompt sets the system prompt for this provider
 func (p *OpenAIProvider) SetSystemPrompt(systemPrompt string) {
 	p.systemPrompt = systemPrompt
@@ -347,13 +429,15 @@ func (p *OpenAIProvider) AddAssistantMessage(content string, toolCalls []ToolCal
 	p.optimizeConversationHistory()
 }
 
-// AddToolResponse adds a tool response to the conversation history
-func (p *OpenAIProvider) AddToolResponse(toolCallID, toolName, content string) {
+// AddToolResponse adds a tool response to the conversation history. isError marks
+// the content as a failure description rather than a result.
+func (p *OpenAIProvider) AddToolResponse(toolCallID, toolName, content string, isError bool) {
 	p.conversationHistory = append(p.conversationHistory, ConversationMessage{
 		Role:       "tool",
 		Content:    content,
 		ToolCallID: toolCallID,
 		Name:       toolName,
+		IsError:    isError,
 	})
 	p.optimizeConversationHistory()
 }
@@ -475,10 +559,14 @@ func (p *OpenAIProvider) convertConversationToOpenAI(systemPrompt string) []Open
 
 			messages = append(messages, openaiMsg)
 		case "tool":
-			// Tool response message
+			// Tool response message; OpenAI has no error flag, so failures are labelled
+			content := msg.Content
+			if msg.IsError {
+				content = "Error: " + content
+			}
 			messages = append(messages, OpenAIMessage{
 				Role:    "tool",
-				Content: msg.Content,
+				Content: content,
 				Name:    msg.Name,
 			})
 		}
@@ -1,6 +1,19 @@
 package client
 
-import "os"
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+)
+
+// defaultMaxRetries is how many times providers retry rate-limited or failed API calls
+const defaultMaxRetries = 3
+
+// maxRetryDelay caps the backoff between API call attempts
+const maxRetryDelay = 30 * time.Second
 
 // getEnvOrDefault returns environment variable value or default
 func getEnvOrDefault(key, defaultValue string) string {
@@ -9,3 +22,62 @@ func getEnvOrDefault(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+// sendWithRetries sends an API request, retrying transport errors, rate limits and
+// transient server errors with exponential backoff. A Retry-After header overrides
+// the backoff. newRequest builds a fresh request for every attempt.
+func sendWithRetries(ctx context.Context, httpClient *http.Client, logger *slog.Logger, maxRetries int, newRequest func() (*http.Request, error)) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		req, err := newRequest()
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := httpClient.Do(req)
+		if attempt >= maxRetries || (err == nil && !isRetryableStatus(resp.StatusCode)) {
+			return resp, err
+		}
+
+		delay := time.Second << attempt
+		if err != nil {
+			logger.Warn("API request failed, retrying", "error", err, "attempt", attempt+1)
+		} else {
+			if retryAfter, ok := parseRetryAfter(resp.Header.Get("Retry-After")); ok {
+				delay = retryAfter
+			}
+			resp.Body.Close()
+			logger.Warn("API request failed, retrying", "status", resp.StatusCode, "attempt", attempt+1, "delay", delay)
+		}
+		delay = min(delay, maxRetryDelay)
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+}
+
+// isRetryableStatus reports whether an API status is worth retrying: rate limits,
+// transient server errors and Anthropic's overloaded status
+func isRetryableStatus(status int) bool {
+	switch status {
+	case http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable, 529:
+		return true
+	}
+	return false
+}
+
+// parseRetryAfter parses a Retry-After header given in seconds or as an HTTP date
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return max(time.Until(date), 0), true
+	}
+	return 0, false
+}
@@ -0,0 +1,241 @@
+package proxy
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// detectResponseFormat returns the endpoint's declared response format, or infers one
+// from the backend's Content-Type header when none is declared
+func detectResponseFormat(declared ResponseFormat, contentType string) ResponseFormat {
+	if declared != "" {
+		return declared
+	}
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return ""
+	}
+
+	switch {
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		return JSON
+	case mediaType == "application/xml" || mediaType == "text/xml" || strings.HasSuffix(mediaType, "+xml"):
+		return XML
+	case mediaType == "text/csv":
+		return CSV
+	case strings.HasPrefix(mediaType, "text/"):
+		return TEXT
+	}
+
+	return ""
+}
+
+// isBinaryContentType reports whether a Content-Type describes data that isn't text
+func isBinaryContentType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+
+	switch {
+	case strings.HasPrefix(mediaType, "image/"),
+		strings.HasPrefix(mediaType, "audio/"),
+		strings.HasPrefix(mediaType, "video/"):
+		return true
+	}
+
+	switch mediaType {
+	case "application/pdf", "application/octet-stream", "application/zip", "application/gzip":
+		return true
+	}
+
+	return false
+}
+
+// newBlobResourceContents wraps binary data as base64-encoded resource contents
+func newBlobResourceContents(uri, contentType string, data []byte) mcp.BlobResourceContents {
+	mimeType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mimeType = "application/octet-stream"
+	}
+
+	return mcp.BlobResourceContents{
+		URI:      uri,
+		MIMEType: mimeType,
+		Blob:     base64.StdEncoding.EncodeToString(data),
+	}
+}
+
+// mimeTypeForFormat returns the MIME type used for unconverted content of a format
+func mimeTypeForFormat(format ResponseFormat) string {
+	switch format {
+	case JSON:
+		return "application/json"
+	case XML:
+		return "application/xml"
+	case CSV:
+		return "text/csv"
+	default:
+		return "text/plain"
+	}
+}
+
+// convertResponseBody converts XML and CSV bodies into JSON the LLM can read.
+// It reports false for other formats or when the body can't be parsed.
+func convertResponseBody(format ResponseFormat, body []byte) ([]byte, bool) {
+	var data any
+	var err error
+
+	switch format {
+	case XML:
+		data, err = xmlToJSON(body)
+	case CSV:
+		data, err = csvToJSON(body)
+	default:
+		return nil, false
+	}
+	if err != nil {
+		return nil, false
+	}
+
+	converted, err := json.Marshal(data)
+	if err != nil {
+		return nil, false
+	}
+
+	return converted, true
+}
+
+// csvToJSON converts CSV with a header row into an array of objects keyed by column name
+func csvToJSON(body []byte) (any, error) {
+	reader := csv.NewReader(bytes.NewReader(body))
+	reader.FieldsPerRecord = -1
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, errors.New("empty CSV")
+	}
+
+	header := records[0]
+	rows := make([]map[string]string, 0, len(records)-1)
+	for _, record := range records[1:] {
+		row := make(map[string]string, len(header))
+		for i, column := range header {
+			if i < len(record) {
+				row[column] = record[i]
+			}
+		}
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
+
+// xmlToJSON converts an XML document into nested objects. Attributes become "@name"
+// keys, repeated child elements become arrays, and text mixed with child elements is
+// kept under "#text". Elements with only text become plain strings.
+func xmlToJSON(body []byte) (any, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(body))
+
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return nil, errors.New("no XML root element")
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if start, ok := token.(xml.StartElement); ok {
+			value, err := decodeXMLElement(decoder, start)
+			if err != nil {
+				return nil, err
+			}
+			return map[string]any{start.Name.Local: value}, nil
+		}
+	}
+}
+
+// decodeXMLElement decodes the element opened by start up to its matching end tag
+func decodeXMLElement(decoder *xml.Decoder, start xml.StartElement) (any, error) {
+	node := make(map[string]any)
+	for _, attr := range start.Attr {
+		node["@"+attr.Name.Local] = attr.Value
+	}
+
+	var text strings.Builder
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			child, err := decodeXMLElement(decoder, t)
+			if err != nil {
+				return nil, err
+			}
+			name := t.Name.Local
+			switch existing := node[name].(type) {
+			case nil:
+				node[name] = child
+			case []any:
+				node[name] = append(existing, child)
+			default:
+				node[name] = []any{existing, child}
+			}
+		case xml.CharData:
+			text.Write(t)
+		case xml.EndElement:
+			content := strings.TrimSpace(text.String())
+			if len(node) == 0 {
+				return content, nil
+			}
+			if content != "" {
+				node["#text"] = content
+			}
+			return node, nil
+		}
+	}
+}
+
+// summaryHeaders are the response headers reported for bodiless responses
+var summaryHeaders = []string{"Allow", "Cache-Control", "Content-Length", "Content-Type", "ETag", "Last-Modified", "Location"}
+
+// isHeaderOnlyMethod reports whether a method's responses are described by their
+// status and headers rather than a body
+func isHeaderOnlyMethod(method Method) bool {
+	return method == HEAD || method == OPTIONS
+}
+
+// summarizeHeaderResponse describes a bodiless response as JSON holding its status
+// and the informative headers it set
+func summarizeHeaderResponse(resp *http.Response) []byte {
+	headers := make(map[string]string)
+	for _, name := range summaryHeaders {
+		if value := resp.Header.Get(name); value != "" {
+			headers[name] = value
+		}
+	}
+
+	summary, _ := json.Marshal(map[string]any{
+		"status":  resp.StatusCode,
+		"headers": headers,
+	})
+	return summary
+}
@@ -3,15 +3,25 @@ module github.com/paulgrammer/mcp-proxy
 go 1.24.2
 
 require (
+	github.com/andybalholm/brotli v1.2.6
+	github.com/google/uuid v1.6.0
+	github.com/itchyny/gojq v0.12.7
 	github.com/joho/godotenv v1.5.1
 	github.com/mark3labs/mcp-go v0.31.1-0.20250605111858-774b17bb03e2
+	github.com/yosida95/uritemplate/v3 v3.0.2
+	golang.org/x/sync v0.12.0
+	google.golang.org/grpc v1.73.0
+	google.golang.org/protobuf v1.36.6
 	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
-	github.com/google/uuid v1.6.0 // indirect
+	github.com/itchyny/timefmt-go v0.1.3 // indirect
 	github.com/spf13/cast v1.7.1 // indirect
 	github.com/stretchr/testify v1.10.0 // indirect
-	github.com/yosida95/uritemplate/v3 v3.0.2 // indirect
+	golang.org/x/net v0.38.0 // indirect
+	golang.org/x/sys v0.31.0 // indirect
+	golang.org/x/text v0.23.0 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20250324211829-b45e905df463 // indirect
 	gopkg.in/check.v1 v1.0.0-20190902080502-41f04d3bba15 // indirect
 )
@@ -0,0 +1,232 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// GraphQLToolHandler handles tool execution by sending GraphQL queries.
+// It reuses the HTTP tool handler's schema, parameter and header handling.
+type GraphQLToolHandler struct {
+	*HTTPToolHandler
+}
+
+// graphQLRequest is the JSON body POSTed to a GraphQL endpoint
+type graphQLRequest struct {
+	Query     string         `json:"query"`
+	Variables map[string]any `json:"variables,omitempty"`
+}
+
+// graphQLResponse is the JSON body returned by a GraphQL endpoint
+type graphQLResponse struct {
+	Data   json.RawMessage `json:"data"`
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+// NewGraphQLToolHandler creates a new GraphQL tool handler
+func NewGraphQLToolHandler(endpoint *Endpoint, backend *Backend, logger *slog.Logger, clientManager *ClientManager) *GraphQLToolHandler {
+	return &GraphQLToolHandler{
+		HTTPToolHandler: NewHTTPToolHandler(endpoint, backend, logger, clientManager),
+	}
+}
+
+// Handler executes the tool by POSTing the endpoint's query with its variables
+func (h *GraphQLToolHandler) Handler(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	// Tag logs and backend requests with the call's request ID
+	ctx, requestID := startRequest(ctx, req.Params.Meta)
+	call := *h
+	call.HTTPToolHandler = h.HTTPToolHandler.forRequest(requestID)
+	h = &call
+
+	// Validate and coerce arguments before making any request
+	arguments, err := validateArguments(h.endpoint, req.GetArguments())
+	if err != nil {
+		return toolErrorResult("Tool '%s' received invalid arguments: %v", h.endpoint.Name, err), nil
+	}
+
+	// Let the endpoint's request transformer rewrite the arguments
+	arguments, err = h.transformArguments(ctx, arguments)
+	if err != nil {
+		return nil, err
+	}
+
+	// Serve the configured mock instead of calling the backend
+	if h.endpoint.MockResponse != nil {
+		return h.handleResponse(newMockHTTPResponse(h.endpoint.MockResponse))
+	}
+
+	// Build the URL with path and query parameters
+	url, err := h.buildURL(arguments)
+	if err != nil {
+		return toolErrorResult("Tool '%s' failed to build URL: %v", h.endpoint.Name, err), nil
+	}
+
+	queryParams, err := h.buildQueryParams(arguments)
+	if err != nil {
+		return toolErrorResult("Tool '%s' failed to build query parameters: %v", h.endpoint.Name, err), nil
+	}
+	if len(queryParams) > 0 {
+		url += "?" + queryParams
+	}
+
+	// Body parameters become the query's variables
+	variables, err := h.buildVariables(arguments)
+	if err != nil {
+		return toolErrorResult("Tool '%s' failed to build variables: %v", h.endpoint.Name, err), nil
+	}
+
+	body, err := json.Marshal(graphQLRequest{Query: h.endpoint.Query, Variables: variables})
+	if err != nil {
+		return toolErrorResult("Tool '%s' failed to build request body: %v", h.endpoint.Name, err), nil
+	}
+	if err := checkRequestSize(h.endpoint, body); err != nil {
+		return toolErrorResult("Tool '%s' request body too large: %v", h.endpoint.Name, err), nil
+	}
+
+	// Bound the request, including reading the response, by the endpoint's response timeout
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(h.endpoint.ResponseTimeout))
+	defer cancel()
+
+	// Apply the endpoint's retry, limit and idempotency settings to the call
+	ctx, stats := h.callContext(ctx)
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create HTTP request: %w", err)
+	}
+
+	h.addHeaders(httpReq, arguments)
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	h.logger.Debug("Making GraphQL request for tool",
+		"tool", h.endpoint.Name,
+		"url", url,
+	)
+
+	resp, err := h.clientManager.DoHedgedRequest(ctx, httpReq, h.endpoint.Name, time.Duration(h.endpoint.HedgeAfter))
+	if err != nil {
+		return nil, fmt.Errorf("HTTP request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// Let the endpoint's response transformer rewrite the response
+	if err := h.transformResponseBody(ctx, resp); err != nil {
+		return nil, err
+	}
+
+	result, err := h.handleResponse(resp)
+	if err != nil {
+		return nil, err
+	}
+
+	// Include the configured response headers and backend diagnostics
+	h.addResponseHeaders(result, resp)
+	result.Meta = backendResultMeta(resp, stats)
+
+	return result, nil
+}
+
+// buildVariables maps body parameters into the GraphQL variables object
+func (h *GraphQLToolHandler) buildVariables(arguments map[string]any) (map[string]any, error) {
+	// With an input schema, the arguments themselves are the variables
+	if h.endpoint.InputSchema != nil {
+		return schemaBody(h.endpoint, arguments), nil
+	}
+
+	variables := make(map[string]any)
+	for _, param := range h.endpoint.BodyParams {
+		var value any
+		var exists bool
+
+		if param.ValueType == CONSTANT {
+			value = param.Value
+			exists = param.Value != ""
+		} else {
+			value, exists = arguments[param.Identifier]
+		}
+
+		if exists {
+			variables[param.Identifier] = value
+		} else if param.Required {
+			return nil, fmt.Errorf("required variable '%s' not provided", param.Identifier)
+		}
+	}
+
+	return variables, nil
+}
+
+// handleResponse processes the GraphQL response. Errors reported by the GraphQL
+// server are returned as tool errors even when the HTTP status is 200.
+func (h *GraphQLToolHandler) handleResponse(resp *http.Response) (*mcp.CallToolResult, error) {
+	var responseBody bytes.Buffer
+	if _, err := responseBody.ReadFrom(limitBody(resp.Body, h.endpoint.MaxResponseBytes)); err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	var gqlResp graphQLResponse
+	parseErr := json.Unmarshal(responseBody.Bytes(), &gqlResp)
+
+	if parseErr == nil && len(gqlResp.Errors) > 0 {
+		messages := make([]string, 0, len(gqlResp.Errors))
+		for _, gqlErr := range gqlResp.Errors {
+			messages = append(messages, gqlErr.Message)
+		}
+
+		h.logger.Error("GraphQL query failed",
+			"tool", h.endpoint.Name,
+			"status", resp.StatusCode,
+			"errors", messages,
+		)
+
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Tool '%s' failed with GraphQL errors: %s", h.endpoint.Name, strings.Join(messages, "; ")),
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	if parseErr != nil || !h.endpoint.isSuccessStatus(resp.StatusCode) {
+		h.logger.Error("Tool execution failed",
+			"tool", h.endpoint.Name,
+			"status", resp.StatusCode,
+			"response", responseBody.String(),
+		)
+
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Tool '%s' failed with status %d: %s", h.endpoint.Name, resp.StatusCode, responseBody.String()),
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	h.logger.Debug("Tool execution successful",
+		"tool", h.endpoint.Name,
+		"status", resp.StatusCode,
+	)
+
+	// Response fields and jq expressions are resolved against the "data" object
+	responseText := string(gqlResp.Data)
+	if transformed, ok := h.transformResponse(gqlResp.Data); ok {
+		responseText = transformed
+	}
+
+	return h.newSuccessResult(responseText, "application/json"), nil
+}
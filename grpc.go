@@ -0,0 +1,375 @@
+package proxy
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	reflectionpb "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// grpcBackend holds the connection to a gRPC backend and the method descriptors
+// resolved from its reflection service
+type grpcBackend struct {
+	conn *grpc.ClientConn
+
+	mu      sync.Mutex
+	files   *protoregistry.Files
+	methods map[string]protoreflect.MethodDescriptor
+}
+
+// newGRPCBackend connects to a gRPC backend. The base URL is the target address;
+// a "grpcs://" prefix enables TLS, otherwise the connection is plaintext.
+func newGRPCBackend(baseURL string) (*grpcBackend, error) {
+	target := baseURL
+	creds := insecure.NewCredentials()
+	if strings.HasPrefix(baseURL, "grpcs://") {
+		target = strings.TrimPrefix(baseURL, "grpcs://")
+		creds = credentials.NewTLS(&tls.Config{})
+	} else {
+		target = strings.TrimPrefix(target, "grpc://")
+	}
+
+	conn, err := grpc.NewClient(target, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return nil, fmt.Errorf("grpc.NewClient(): %w", err)
+	}
+
+	return &grpcBackend{
+		conn:    conn,
+		files:   new(protoregistry.Files),
+		methods: make(map[string]protoreflect.MethodDescriptor),
+	}, nil
+}
+
+// Close closes the backend connection
+func (b *grpcBackend) Close() error {
+	return b.conn.Close()
+}
+
+// resolveMethod returns the descriptor of a fully-qualified method such as
+// "shop.v1.Catalog/GetProduct", fetching its service definition via reflection
+// on first use
+func (b *grpcBackend) resolveMethod(ctx context.Context, fullMethod string) (protoreflect.MethodDescriptor, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if method, ok := b.methods[fullMethod]; ok {
+		return method, nil
+	}
+
+	serviceName, methodName, err := splitGRPCMethod(fullMethod)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := b.files.FindDescriptorByName(protoreflect.FullName(serviceName)); err != nil {
+		if err := b.loadSymbol(ctx, serviceName); err != nil {
+			return nil, err
+		}
+	}
+
+	descriptor, err := b.files.FindDescriptorByName(protoreflect.FullName(serviceName))
+	if err != nil {
+		return nil, fmt.Errorf("service '%s' not found: %w", serviceName, err)
+	}
+	service, ok := descriptor.(protoreflect.ServiceDescriptor)
+	if !ok {
+		return nil, fmt.Errorf("'%s' is not a service", serviceName)
+	}
+
+	method := service.Methods().ByName(protoreflect.Name(methodName))
+	if method == nil {
+		return nil, fmt.Errorf("method '%s' not found in service '%s'", methodName, serviceName)
+	}
+	if method.IsStreamingClient() || method.IsStreamingServer() {
+		return nil, fmt.Errorf("streaming method '%s' is not supported", fullMethod)
+	}
+
+	b.methods[fullMethod] = method
+	return method, nil
+}
+
+// loadSymbol fetches the file defining symbol, and any dependencies not yet known,
+// from the backend's reflection service and registers them
+func (b *grpcBackend) loadSymbol(ctx context.Context, symbol string) error {
+	stream, err := reflectionpb.NewServerReflectionClient(b.conn).ServerReflectionInfo(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to open reflection stream: %w", err)
+	}
+	defer stream.CloseSend()
+
+	protos := make(map[string]*descriptorpb.FileDescriptorProto)
+
+	request := &reflectionpb.ServerReflectionRequest{
+		MessageRequest: &reflectionpb.ServerReflectionRequest_FileContainingSymbol{FileContainingSymbol: symbol},
+	}
+	if err := fetchFileDescriptors(stream, request, protos); err != nil {
+		return err
+	}
+
+	// Servers usually send transitive dependencies; fetch any they left out
+	for {
+		var missing string
+		for _, fd := range protos {
+			for _, dep := range fd.GetDependency() {
+				if _, ok := protos[dep]; ok {
+					continue
+				}
+				if _, err := b.files.FindFileByPath(dep); err == nil {
+					continue
+				}
+				if _, err := protoregistry.GlobalFiles.FindFileByPath(dep); err == nil {
+					continue
+				}
+				missing = dep
+			}
+		}
+		if missing == "" {
+			break
+		}
+
+		request := &reflectionpb.ServerReflectionRequest{
+			MessageRequest: &reflectionpb.ServerReflectionRequest_FileByFilename{FileByFilename: missing},
+		}
+		if err := fetchFileDescriptors(stream, request, protos); err != nil {
+			return err
+		}
+		if _, ok := protos[missing]; !ok {
+			return fmt.Errorf("reflection did not return dependency '%s'", missing)
+		}
+	}
+
+	for name := range protos {
+		if err := b.registerFile(name, protos); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// registerFile registers a file descriptor after its dependencies
+func (b *grpcBackend) registerFile(name string, protos map[string]*descriptorpb.FileDescriptorProto) error {
+	if _, err := b.files.FindFileByPath(name); err == nil {
+		return nil
+	}
+
+	fdProto, ok := protos[name]
+	if !ok {
+		// Well-known types and other files linked into the binary
+		fd, err := protoregistry.GlobalFiles.FindFileByPath(name)
+		if err != nil {
+			return fmt.Errorf("unknown proto file '%s'", name)
+		}
+		return b.files.RegisterFile(fd)
+	}
+
+	for _, dep := range fdProto.GetDependency() {
+		if err := b.registerFile(dep, protos); err != nil {
+			return err
+		}
+	}
+
+	fd, err := protodesc.NewFile(fdProto, b.files)
+	if err != nil {
+		return fmt.Errorf("invalid proto file '%s': %w", name, err)
+	}
+
+	return b.files.RegisterFile(fd)
+}
+
+// fetchFileDescriptors sends a reflection request and collects the returned files
+func fetchFileDescriptors(stream reflectionpb.ServerReflection_ServerReflectionInfoClient, request *reflectionpb.ServerReflectionRequest, protos map[string]*descriptorpb.FileDescriptorProto) error {
+	if err := stream.Send(request); err != nil {
+		return fmt.Errorf("failed to send reflection request: %w", err)
+	}
+
+	resp, err := stream.Recv()
+	if err != nil {
+		return fmt.Errorf("failed to receive reflection response: %w", err)
+	}
+	if errResp := resp.GetErrorResponse(); errResp != nil {
+		return fmt.Errorf("reflection error: %s", errResp.GetErrorMessage())
+	}
+
+	for _, raw := range resp.GetFileDescriptorResponse().GetFileDescriptorProto() {
+		fdProto := new(descriptorpb.FileDescriptorProto)
+		if err := proto.Unmarshal(raw, fdProto); err != nil {
+			return fmt.Errorf("invalid file descriptor: %w", err)
+		}
+		protos[fdProto.GetName()] = fdProto
+	}
+
+	return nil
+}
+
+// splitGRPCMethod splits "pkg.Service/Method" (or "pkg.Service.Method") into
+// service and method names
+func splitGRPCMethod(fullMethod string) (string, string, error) {
+	fullMethod = strings.TrimPrefix(fullMethod, "/")
+
+	separator := strings.LastIndex(fullMethod, "/")
+	if separator < 0 {
+		separator = strings.LastIndex(fullMethod, ".")
+	}
+	if separator <= 0 || separator == len(fullMethod)-1 {
+		return "", "", fmt.Errorf("invalid rpc '%s', expected package.Service/Method", fullMethod)
+	}
+
+	return fullMethod[:separator], fullMethod[separator+1:], nil
+}
+
+// GRPCToolHandler handles tool execution by invoking unary gRPC methods.
+// It reuses the HTTP tool handler's schema and parameter handling.
+type GRPCToolHandler struct {
+	*HTTPToolHandler
+	grpcBackend *grpcBackend
+}
+
+// NewGRPCToolHandler creates a new gRPC tool handler
+func NewGRPCToolHandler(endpoint *Endpoint, backend *Backend, logger *slog.Logger, clientManager *ClientManager, grpcBackend *grpcBackend) *GRPCToolHandler {
+	return &GRPCToolHandler{
+		HTTPToolHandler: NewHTTPToolHandler(endpoint, backend, logger, clientManager),
+		grpcBackend:     grpcBackend,
+	}
+}
+
+// Handler executes the tool by building the request message from the arguments
+// and invoking the endpoint's method
+func (h *GRPCToolHandler) Handler(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	// Tag logs and backend requests with the call's request ID
+	ctx, requestID := startRequest(ctx, req.Params.Meta)
+	call := *h
+	call.HTTPToolHandler = h.HTTPToolHandler.forRequest(requestID)
+	h = &call
+
+	// Validate and coerce arguments before making any request
+	arguments, err := validateArguments(h.endpoint, req.GetArguments())
+	if err != nil {
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Tool '%s' received invalid arguments: %v", h.endpoint.Name, err),
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	// Serve the configured mock instead of calling the backend
+	if h.endpoint.MockResponse != nil {
+		return h.HTTPToolHandler.handleResponse(newMockHTTPResponse(h.endpoint.MockResponse))
+	}
+
+	// Bound the call by the endpoint's response timeout
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(h.endpoint.ResponseTimeout))
+	defer cancel()
+
+	method, err := h.grpcBackend.resolveMethod(ctx, h.endpoint.RPC)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve method: %w", err)
+	}
+
+	// Body parameters become fields of the request message
+	fields, err := h.buildRequestBody(arguments)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build request body: %w", err)
+	}
+	if err := checkRequestSize(h.endpoint, fields); err != nil {
+		return nil, fmt.Errorf("request body too large: %w", err)
+	}
+
+	request := dynamicpb.NewMessage(method.Input())
+	if len(fields) > 0 {
+		if err := protojson.Unmarshal(fields, request); err != nil {
+			return &mcp.CallToolResult{
+				Content: []mcp.Content{
+					mcp.TextContent{
+						Type: "text",
+						Text: fmt.Sprintf("Tool '%s' received invalid arguments: %v", h.endpoint.Name, err),
+					},
+				},
+				IsError: true,
+			}, nil
+		}
+	}
+
+	ctx = metadata.NewOutgoingContext(ctx, h.buildMetadata(arguments))
+
+	h.logger.Debug("Making gRPC request for tool",
+		"tool", h.endpoint.Name,
+		"rpc", h.endpoint.RPC,
+	)
+
+	response := dynamicpb.NewMessage(method.Output())
+	fullMethod := fmt.Sprintf("/%s/%s", method.Parent().FullName(), method.Name())
+	if err := h.grpcBackend.conn.Invoke(ctx, fullMethod, request, response); err != nil {
+		st := status.Convert(err)
+
+		h.logger.Error("Tool execution failed",
+			"tool", h.endpoint.Name,
+			"code", st.Code(),
+			"message", st.Message(),
+		)
+
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Tool '%s' failed with status %s: %s", h.endpoint.Name, st.Code(), st.Message()),
+				},
+			},
+			IsError: true,
+		}, nil
+	}
+
+	responseJSON, err := protojson.Marshal(response)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode response: %w", err)
+	}
+
+	h.logger.Debug("Tool execution successful", "tool", h.endpoint.Name)
+
+	// Reshape the response or reduce it to the configured fields
+	responseText := string(responseJSON)
+	if transformed, ok := h.transformResponse(responseJSON); ok {
+		responseText = transformed
+	}
+
+	return h.newSuccessResult(responseText, "application/json"), nil
+}
+
+// buildMetadata converts backend and endpoint headers into gRPC request metadata
+func (h *GRPCToolHandler) buildMetadata(arguments map[string]any) metadata.MD {
+	md := metadata.MD{}
+	for _, header := range h.backend.DefaultHeaders {
+		md.Set(header.Name, header.Value)
+	}
+
+	for _, header := range h.endpoint.Headers {
+		if value, ok := resolveHeaderValue(header, arguments); ok {
+			md.Set(header.Name, value)
+		}
+	}
+
+	return md
+}
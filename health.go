@@ -0,0 +1,133 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// defaultHealthCheckTimeout bounds a backend's startup probe when it sets no timeout
+const defaultHealthCheckTimeout = 10 * time.Second
+
+// healthCheckInterval is the delay between probes of a backend that isn't healthy yet
+const healthCheckInterval = time.Second
+
+// HealthCheck configures a backend probe run when the proxy starts
+type HealthCheck struct {
+	// Path is appended to the backend's BaseURL and requested with GET, e.g. "/healthz"
+	Path string `json:"path" yaml:"path"`
+
+	// ExpectedStatus is the status a healthy backend returns. Default: any 2xx status
+	ExpectedStatus int `json:"expected_status,omitempty" yaml:"expected_status,omitempty"`
+
+	// Timeout is how long to keep probing before giving up. Default: 10 seconds
+	Timeout Duration `json:"timeout,omitempty" yaml:"timeout,omitempty"`
+
+	// Critical makes the proxy refuse to start until the backend is healthy. Failed
+	// probes of other backends are logged as warnings
+	Critical bool `json:"critical,omitempty" yaml:"critical,omitempty"`
+}
+
+// checkBackends probes every backend with a health check concurrently, retrying each
+// until it is healthy or its timeout expires. It returns an error if a critical
+// backend never became healthy.
+func (s *Proxy) checkBackends(ctx context.Context) error {
+	cfg := s.currentConfig()
+	if cfg == nil {
+		return nil
+	}
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		critical []error
+	)
+
+	for _, backend := range cfg.Backends {
+		if backend.HealthCheck == nil {
+			continue
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			err := s.probeBackend(ctx, backend)
+			switch {
+			case err == nil:
+				s.logger.Info("Backend is healthy", "backend", backend.BaseURL)
+			case backend.HealthCheck.Critical:
+				s.logger.Error("Critical backend is unhealthy", "backend", backend.BaseURL, "error", err)
+				mu.Lock()
+				critical = append(critical, fmt.Errorf("backend %s: %w", backend.BaseURL, err))
+				mu.Unlock()
+			default:
+				s.logger.Warn("Backend is unhealthy", "backend", backend.BaseURL, "error", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(critical) > 0 {
+		return fmt.Errorf("critical backends are unhealthy: %w", errors.Join(critical...))
+	}
+	return nil
+}
+
+// probeBackend requests a backend's health path until it returns the expected status
+// or the health check's timeout expires
+func (s *Proxy) probeBackend(ctx context.Context, backend *Backend) error {
+	check := backend.HealthCheck
+	timeout := time.Duration(check.Timeout)
+	if timeout <= 0 {
+		timeout = defaultHealthCheckTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	for {
+		err := s.probeOnce(ctx, backend)
+		if err == nil {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(healthCheckInterval):
+		}
+	}
+}
+
+// probeOnce sends a single health check request. Probes skip retries and the circuit
+// breaker so they neither wait on nor count toward real traffic.
+func (s *Proxy) probeOnce(ctx context.Context, backend *Backend) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, backend.BaseURL+backend.HealthCheck.Path, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create health check request: %w", err)
+	}
+
+	for _, header := range backend.DefaultHeaders {
+		if header.Type == CONSTANT {
+			req.Header.Set(header.Name, header.Value)
+		}
+	}
+
+	client := s.currentClients().GetClient("")
+	setDefaultHeaders(req, client.config.DefaultHeaders)
+
+	resp, err := client.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("health check request failed: %w", err)
+	}
+	resp.Body.Close()
+
+	expected := backend.HealthCheck.ExpectedStatus
+	if (expected != 0 && resp.StatusCode != expected) || (expected == 0 && (resp.StatusCode < 200 || resp.StatusCode >= 300)) {
+		return fmt.Errorf("health check returned status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -2,13 +2,19 @@ package proxy
 
 import (
 	"context"
+	"errors"
 	"log/slog"
+	"reflect"
+	"sort"
+	"sync"
 
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
 )
 
-func newServerHooks(logger *slog.Logger) *server.Hooks {
+// newServerHooks returns the hooks the proxy always installs, which log MCP traffic and
+// count tool calls and error results in stats
+func newServerHooks(logger *slog.Logger, stats *toolCallStats) *server.Hooks {
 	hooks := &server.Hooks{}
 
 	hooks.AddBeforeAny(func(ctx context.Context, id any, method mcp.MCPMethod, message any) {
@@ -21,6 +27,11 @@ func newServerHooks(logger *slog.Logger) *server.Hooks {
 
 	hooks.AddOnError(func(ctx context.Context, id any, method mcp.MCPMethod, message any, err error) {
 		logger.Error("onError", "method", method, "id", id, "message", message, "error", err)
+
+		// Tool handlers that fail outright don't reach afterCallTool
+		if request, ok := message.(*mcp.CallToolRequest); ok && !errors.Is(err, server.ErrToolNotFound) {
+			stats.record(request.Params.Name, true)
+		}
 	})
 
 	hooks.AddBeforeInitialize(func(ctx context.Context, id any, message *mcp.InitializeRequest) {
@@ -38,6 +49,11 @@ func newServerHooks(logger *slog.Logger) *server.Hooks {
 	})
 
 	hooks.AddAfterCallTool(func(ctx context.Context, id any, message *mcp.CallToolRequest, result *mcp.CallToolResult) {
+		stats.record(message.Params.Name, result.IsError)
+		if result.IsError {
+			logger.Warn("afterCallTool returned an error result", "tool", message.Params.Name, "id", id, "result", result)
+			return
+		}
 		logger.Debug("afterCallTool", "id", id, "message", message, "result", result)
 	})
 
@@ -47,3 +63,61 @@ func newServerHooks(logger *slog.Logger) *server.Hooks {
 
 	return hooks
 }
+
+// appendHooks adds every hook registered in extra to hooks, after the ones hooks
+// already has
+func appendHooks(hooks, extra *server.Hooks) {
+	target := reflect.ValueOf(hooks).Elem()
+	source := reflect.ValueOf(extra).Elem()
+	for i := 0; i < target.NumField(); i++ {
+		if field := target.Field(i); field.Kind() == reflect.Slice {
+			field.Set(reflect.AppendSlice(field, source.Field(i)))
+		}
+	}
+}
+
+// ToolStatus reports how often a tool was called and how many calls failed, either with
+// an error result or a handler error
+type ToolStatus struct {
+	Name   string `json:"name"`
+	Calls  int64  `json:"calls"`
+	Errors int64  `json:"errors"`
+}
+
+// toolCallStats counts calls and errors per tool. The zero value is ready to use.
+type toolCallStats struct {
+	mu    sync.Mutex
+	tools map[string]*ToolStatus
+}
+
+// record counts a call to a tool
+func (s *toolCallStats) record(name string, failed bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.tools == nil {
+		s.tools = make(map[string]*ToolStatus)
+	}
+	status, ok := s.tools[name]
+	if !ok {
+		status = &ToolStatus{Name: name}
+		s.tools[name] = status
+	}
+	status.Calls++
+	if failed {
+		status.Errors++
+	}
+}
+
+// snapshot returns the counts of every tool called so far, sorted by name
+func (s *toolCallStats) snapshot() []ToolStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	statuses := make([]ToolStatus, 0, len(s.tools))
+	for _, status := range s.tools {
+		statuses = append(statuses, *status)
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Name < statuses[j].Name })
+	return statuses
+}
@@ -0,0 +1,100 @@
+package proxy
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"slices"
+)
+
+// validateInputSchema checks that an endpoint's input_schema describes an object and
+// can be sent as a tool's JSON Schema
+func validateInputSchema(schema map[string]any) error {
+	if schemaType, _ := schema["type"].(string); schemaType != "object" {
+		return fmt.Errorf("input_schema must have type 'object'")
+	}
+	if _, err := json.Marshal(schema); err != nil {
+		return fmt.Errorf("input_schema is not valid JSON: %w", err)
+	}
+	if properties, ok := schema["properties"]; ok {
+		if _, ok := properties.(map[string]any); !ok {
+			return fmt.Errorf("input_schema properties must be an object")
+		}
+	}
+	return nil
+}
+
+// validateSchemaArguments checks arguments against the top level of an input schema:
+// required properties must be present and properties must have their declared type
+// and enum value. Nested constraints are left to the backend.
+func validateSchemaArguments(schema map[string]any, arguments map[string]any) error {
+	if required, ok := schema["required"].([]any); ok {
+		for _, name := range required {
+			name, _ := name.(string)
+			if value, exists := arguments[name]; !exists || value == nil {
+				return fmt.Errorf("required argument '%s' not provided", name)
+			}
+		}
+	}
+
+	properties, _ := schema["properties"].(map[string]any)
+	for name, value := range arguments {
+		property, ok := properties[name].(map[string]any)
+		if !ok {
+			continue
+		}
+		if schemaType, ok := property["type"].(string); ok && !schemaTypeMatches(schemaType, value) {
+			return fmt.Errorf("invalid value for argument '%s': expected %s, got %T", name, schemaType, value)
+		}
+		if enum, ok := property["enum"].([]any); ok && !slices.ContainsFunc(enum, func(allowed any) bool {
+			return fmt.Sprintf("%v", allowed) == fmt.Sprintf("%v", value)
+		}) {
+			return fmt.Errorf("invalid value for argument '%s': %v is not an allowed value", name, value)
+		}
+	}
+
+	return nil
+}
+
+// schemaTypeMatches reports whether a decoded JSON value has a JSON Schema type
+func schemaTypeMatches(schemaType string, value any) bool {
+	switch schemaType {
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "number":
+		_, ok := value.(float64)
+		return ok
+	case "integer":
+		number, ok := value.(float64)
+		return ok && number == math.Trunc(number)
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "object":
+		_, ok := value.(map[string]any)
+		return ok
+	case "array":
+		_, ok := value.([]any)
+		return ok
+	case "null":
+		return value == nil
+	default:
+		return true
+	}
+}
+
+// schemaBody returns the arguments sent as the body of an endpoint with an input schema:
+// every argument except those filling path and query parameters
+func schemaBody(endpoint *Endpoint, arguments map[string]any) map[string]any {
+	body := make(map[string]any, len(arguments))
+	for name, value := range arguments {
+		body[name] = value
+	}
+	for _, params := range [][]*Param{endpoint.PathParameters, endpoint.QueryParameters} {
+		for _, param := range params {
+			delete(body, param.Identifier)
+		}
+	}
+	return body
+}
@@ -0,0 +1,76 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/itchyny/gojq"
+)
+
+// jqTimeout bounds evaluating a response_jq expression, so one that never ends can't
+// hang the call
+const jqTimeout = 5 * time.Second
+
+// jqCodes caches compiled response_jq expressions by their text
+var jqCodes sync.Map
+
+// compileJQ parses and compiles a jq expression, reusing an earlier compilation
+func compileJQ(expr string) (*gojq.Code, error) {
+	if code, ok := jqCodes.Load(expr); ok {
+		return code.(*gojq.Code), nil
+	}
+
+	query, err := gojq.Parse(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid response_jq: %w", err)
+	}
+	code, err := gojq.Compile(query)
+	if err != nil {
+		return nil, fmt.Errorf("invalid response_jq: %w", err)
+	}
+
+	jqCodes.Store(expr, code)
+	return code, nil
+}
+
+// applyJQ runs a jq expression against a JSON body and returns its output as JSON.
+// An expression producing several values returns them as an array.
+func applyJQ(expr string, body []byte) ([]byte, error) {
+	code, err := compileJQ(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	var data any
+	if err := json.Unmarshal(body, &data); err != nil {
+		return nil, fmt.Errorf("response is not JSON: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), jqTimeout)
+	defer cancel()
+
+	var results []any
+	iter := code.RunWithContext(ctx, data)
+	for {
+		value, ok := iter.Next()
+		if !ok {
+			break
+		}
+		if err, ok := value.(error); ok {
+			return nil, fmt.Errorf("response_jq failed: %w", err)
+		}
+		results = append(results, value)
+	}
+
+	var output any = results
+	switch len(results) {
+	case 0:
+		output = nil
+	case 1:
+		output = results[0]
+	}
+	return json.Marshal(output)
+}
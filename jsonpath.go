@@ -0,0 +1,45 @@
+package proxy
+
+import (
+	"strconv"
+	"strings"
+)
+
+// lookupJSONPath resolves a simple JSONPath-style expression against decoded JSON data.
+// Supported forms are dotted keys with optional "$." prefix and numeric array indexes:
+// "$.data.items", "meta.next_cursor", "results.0.id", "items[0].id"
+func lookupJSONPath(data any, path string) (any, bool) {
+	path = strings.TrimPrefix(strings.TrimPrefix(path, "$"), ".")
+	if path == "" {
+		return data, true
+	}
+
+	// Normalize bracket indexes to dotted segments
+	path = strings.NewReplacer("[", ".", "]", "").Replace(path)
+
+	current := data
+	for _, segment := range strings.Split(path, ".") {
+		if segment == "" {
+			continue
+		}
+
+		switch node := current.(type) {
+		case map[string]any:
+			value, exists := node[segment]
+			if !exists {
+				return nil, false
+			}
+			current = value
+		case []any:
+			index, err := strconv.Atoi(segment)
+			if err != nil || index < 0 || index >= len(node) {
+				return nil, false
+			}
+			current = node[index]
+		default:
+			return nil, false
+		}
+	}
+
+	return current, true
+}
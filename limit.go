@@ -0,0 +1,78 @@
+package proxy
+
+import (
+	"context"
+	"fmt"
+	"io"
+)
+
+// defaultMaxBodyBytes is the default cap on request and response bodies
+const defaultMaxBodyBytes = 10 << 20
+
+// errBodyTooLarge reports a body over the configured limit
+type errBodyTooLarge struct {
+	limit int64
+}
+
+func (e *errBodyTooLarge) Error() string {
+	return fmt.Sprintf("body exceeds the %d byte limit", e.limit)
+}
+
+// limitedReader reads at most limit bytes and fails if the source holds more
+type limitedReader struct {
+	r         io.Reader
+	limit     int64
+	remaining int64
+}
+
+// limitBody caps how much of a response body is read. A limit of zero means no limit.
+func limitBody(r io.Reader, limit int64) io.Reader {
+	if limit <= 0 {
+		return r
+	}
+	return &limitedReader{r: r, limit: limit, remaining: limit}
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if l.remaining <= 0 {
+		// Probe for data past the limit
+		var probe [1]byte
+		n, err := l.r.Read(probe[:])
+		if n > 0 {
+			return 0, &errBodyTooLarge{limit: l.limit}
+		}
+		return 0, err
+	}
+
+	if int64(len(p)) > l.remaining {
+		p = p[:l.remaining]
+	}
+	n, err := l.r.Read(p)
+	l.remaining -= int64(n)
+	return n, err
+}
+
+// checkRequestSize fails when a built request body is over the endpoint's limit
+func checkRequestSize(endpoint *Endpoint, body []byte) error {
+	if endpoint.MaxRequestBytes > 0 && int64(len(body)) > endpoint.MaxRequestBytes {
+		return &errBodyTooLarge{limit: endpoint.MaxRequestBytes}
+	}
+	return nil
+}
+
+type responseLimitKey struct{}
+
+// withResponseLimit returns a context whose backend responses, when read before the
+// handler reads them, e.g. to record a cassette, are capped at limit bytes
+func withResponseLimit(ctx context.Context, limit int64) context.Context {
+	return context.WithValue(ctx, responseLimitKey{}, limit)
+}
+
+// responseLimitFromContext returns the response limit of ctx, or the default limit
+// for requests made outside an endpoint handler
+func responseLimitFromContext(ctx context.Context) int64 {
+	if limit, ok := ctx.Value(responseLimitKey{}).(int64); ok {
+		return limit
+	}
+	return defaultMaxBodyBytes
+}
@@ -0,0 +1,83 @@
+package proxy
+
+// endpointSummary describes a configured endpoint for the endpoints API
+type endpointSummary struct {
+	Name        string          `json:"name"`
+	Capability  Capability      `json:"capability"`
+	Mode        Mode            `json:"mode,omitempty"`
+	Method      Method          `json:"method,omitempty"`
+	Path        string          `json:"path,omitempty"`
+	Description string          `json:"description"`
+	BaseURL     string          `json:"base_url"`
+	Parameters  []paramSummary  `json:"parameters"`
+	Headers     []headerSummary `json:"headers"`
+}
+
+// paramSummary describes an endpoint parameter; constant values are left out
+type paramSummary struct {
+	Identifier  string `json:"identifier"`
+	Location    string `json:"location"`
+	DataType    Data   `json:"data_type"`
+	ValueType   Value  `json:"value_type"`
+	Required    bool   `json:"required"`
+	Description string `json:"description,omitempty"`
+}
+
+// headerSummary describes a header sent to the backend, with secret values redacted
+type headerSummary struct {
+	Name  string `json:"name"`
+	Type  Value  `json:"type"`
+	Value string `json:"value"`
+}
+
+// summarizeEndpoints lists every endpoint in cfg along with its backend's base URL
+func summarizeEndpoints(cfg *Config) []endpointSummary {
+	summaries := []endpointSummary{}
+	for _, nested := range cfg.Backends {
+		for _, endpoint := range nested.Endpoints {
+			backend := cfg.endpointBackend(nested, &endpoint)
+			summary := endpointSummary{
+				Name:        endpoint.Name,
+				Capability:  endpoint.Capability,
+				Mode:        endpoint.Mode,
+				Method:      endpoint.Method,
+				Path:        endpoint.Path,
+				Description: endpoint.Description,
+				BaseURL:     backend.BaseURL,
+				Parameters:  []paramSummary{},
+				Headers:     []headerSummary{},
+			}
+
+			for _, group := range []struct {
+				location string
+				params   []*Param
+			}{
+				{"path", endpoint.PathParameters},
+				{"query", endpoint.QueryParameters},
+				{"body", endpoint.BodyParams},
+			} {
+				for _, param := range group.params {
+					summary.Parameters = append(summary.Parameters, paramSummary{
+						Identifier:  param.Identifier,
+						Location:    group.location,
+						DataType:    param.DataType,
+						ValueType:   param.ValueType,
+						Required:    param.Required,
+						Description: param.Description,
+					})
+				}
+			}
+
+			for _, header := range append(append([]*Header{}, backend.DefaultHeaders...), endpoint.Headers...) {
+				value := header.Value
+				if header.isSecret() {
+					value = redactedValue
+				}
+				summary.Headers = append(summary.Headers, headerSummary{Name: header.Name, Type: header.Type, Value: value})
+			}
+
+			summaries = append(summaries, summary)
+		}
+	}
+	return summaries
+}
@@ -0,0 +1,51 @@
+package proxy
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// MockResponse is a canned backend response returned instead of calling the backend
+type MockResponse struct {
+	// Status is the HTTP status code of the mock. Default: 200
+	Status int `json:"status,omitempty" yaml:"status,omitempty"`
+
+	// Body is the response body, usually JSON or text
+	Body string `json:"body" yaml:"body"`
+
+	// ContentType is the response Content-Type. Default: application/json
+	ContentType string `json:"content_type,omitempty" yaml:"content_type,omitempty"`
+}
+
+// defaultMockResponse is served in mock mode for endpoints without a mock_response
+func defaultMockResponse(endpoint *Endpoint) *MockResponse {
+	return &MockResponse{
+		Body: fmt.Sprintf(`{"mock": true, "endpoint": %q}`, endpoint.Name),
+	}
+}
+
+// newMockHTTPResponse builds the HTTP response handlers process in place of a backend response
+func newMockHTTPResponse(mock *MockResponse) *http.Response {
+	status := mock.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+
+	contentType := mock.ContentType
+	if contentType == "" {
+		contentType = "application/json"
+	}
+
+	header := make(http.Header)
+	header.Set("Content-Type", contentType)
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		StatusCode:    status,
+		Header:        header,
+		Body:          io.NopCloser(strings.NewReader(mock.Body)),
+		ContentLength: int64(len(mock.Body)),
+	}
+}
@@ -0,0 +1,145 @@
+package proxy
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigEnvVar names the environment variable that selects the overlay ParseConfig
+// merges over the base config file, e.g. CONFIG_ENV=prod for config.prod.yml
+const ConfigEnvVar = "CONFIG_ENV"
+
+// OverlayPath returns the overlay file for an environment, next to the base file:
+// config.yml with env prod gives config.prod.yml
+func OverlayPath(filename, env string) string {
+	ext := filepath.Ext(filename)
+	return strings.TrimSuffix(filename, ext) + "." + env + ext
+}
+
+// configOverlay returns the overlay ParseConfig merges over filename, or "" when the
+// environment selects none
+func configOverlay(filename string) string {
+	env := os.Getenv(ConfigEnvVar)
+	if env == "" {
+		return ""
+	}
+	return OverlayPath(filename, env)
+}
+
+// ParseConfigWithOverlay parses a base config file with the overlay for env deep-merged
+// over it. Settings in the overlay replace those in the base, and maps are merged key
+// by key. Lists whose items all have a name, such as backends, endpoints and headers,
+// are merged by name: items with a name in the base are merged with it and others are
+// appended. Other lists are replaced. A null value removes the setting from the base.
+// An empty env parses the base file alone.
+func ParseConfigWithOverlay(filename, env string) (*Config, error) {
+	if env == "" {
+		return parseConfigFile(filename)
+	}
+	if isConfigSet(filename) {
+		return nil, fmt.Errorf("environment overlays need a single base config file, not '%s'", filename)
+	}
+
+	basePath := expandPath(filename)
+	base, err := readConfigTree(basePath)
+	if err != nil {
+		return nil, err
+	}
+	overlay, err := readConfigTree(OverlayPath(basePath, env))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load overlay for environment '%s': %w", env, err)
+	}
+
+	data, err := yaml.Marshal(mergeConfigTrees(base, overlay))
+	if err != nil {
+		return nil, fmt.Errorf("failed to merge overlay for environment '%s': %w", env, err)
+	}
+	return ParseConfigFromBytes(data)
+}
+
+// readConfigTree reads a config file as generic YAML values for merging
+func readConfigTree(path string) (any, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file '%s': %w", path, err)
+	}
+
+	var tree any
+	if err := yaml.Unmarshal(data, &tree); err != nil {
+		return nil, fmt.Errorf("failed to parse YAML config '%s': %w", path, err)
+	}
+	return tree, nil
+}
+
+// mergeConfigTrees deep-merges overlay over base as described by ParseConfigWithOverlay
+func mergeConfigTrees(base, overlay any) any {
+	switch overlay := overlay.(type) {
+	case map[string]any:
+		baseMap, ok := base.(map[string]any)
+		if !ok {
+			return overlay
+		}
+		merged := make(map[string]any, len(baseMap)+len(overlay))
+		for key, value := range baseMap {
+			merged[key] = value
+		}
+		for key, value := range overlay {
+			if value == nil {
+				delete(merged, key)
+				continue
+			}
+			merged[key] = mergeConfigTrees(baseMap[key], value)
+		}
+		return merged
+
+	case []any:
+		baseList, ok := base.([]any)
+		if !ok {
+			return overlay
+		}
+		return mergeNamedLists(baseList, overlay)
+
+	default:
+		return overlay
+	}
+}
+
+// mergeNamedLists merges overlay items into base items with the same name and appends
+// the rest. Lists with an unnamed overlay item are replaced by the overlay.
+func mergeNamedLists(base, overlay []any) []any {
+	for _, item := range overlay {
+		if itemName(item) == "" {
+			return overlay
+		}
+	}
+
+	merged := make([]any, len(base), len(base)+len(overlay))
+	copy(merged, base)
+	for _, item := range overlay {
+		name := itemName(item)
+		i := 0
+		for i < len(merged) && itemName(merged[i]) != name {
+			i++
+		}
+		if i < len(merged) {
+			merged[i] = mergeConfigTrees(merged[i], item)
+		} else {
+			merged = append(merged, item)
+		}
+	}
+	return merged
+}
+
+// itemName returns the name of a list item, or "" if it isn't a map with a string name
+func itemName(item any) string {
+	m, ok := item.(map[string]any)
+	if !ok {
+		return ""
+	}
+	name, _ := m["name"].(string)
+	return name
+}
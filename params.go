@@ -0,0 +1,367 @@
+package proxy
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// validateArguments checks that all required dynamic parameters are present and
+// coerces supplied values to their declared data types. It returns a new argument
+// map so the caller's request is left untouched.
+func validateArguments(endpoint *Endpoint, arguments map[string]any) (map[string]any, error) {
+	validated := make(map[string]any, len(arguments))
+	for name, value := range arguments {
+		validated[name] = value
+	}
+
+	groups := []struct {
+		location string
+		params   []*Param
+	}{
+		{"path", endpoint.PathParameters},
+		{"query", endpoint.QueryParameters},
+		{"body", endpoint.BodyParams},
+	}
+
+	for _, group := range groups {
+		for _, param := range group.params {
+			if param.ValueType == CONSTANT {
+				continue
+			}
+
+			value, exists := validated[param.Identifier]
+			if (!exists || value == nil) && param.Default != "" {
+				value, exists = param.Default, true
+			}
+			if !exists || value == nil {
+				if param.Required {
+					return nil, fmt.Errorf("required %s parameter '%s' not provided", group.location, param.Identifier)
+				}
+				continue
+			}
+
+			coerced, err := coerceParamValue(param, value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid value for %s parameter '%s': %w", group.location, param.Identifier, err)
+			}
+
+			if len(param.Enum) > 0 && !slices.Contains(param.Enum, fmt.Sprintf("%v", coerced)) {
+				return nil, fmt.Errorf("invalid value for %s parameter '%s': %v is not one of: %s",
+					group.location, param.Identifier, coerced, strings.Join(param.Enum, ", "))
+			}
+
+			validated[param.Identifier] = coerced
+		}
+	}
+
+	// Check the arguments against a configured input schema
+	if endpoint.InputSchema != nil {
+		if err := validateSchemaArguments(endpoint.InputSchema, validated); err != nil {
+			return nil, err
+		}
+	}
+
+	return validated, nil
+}
+
+// dynamicParams returns the parameters the caller supplies. Constant parameters are
+// filled in from config, so they aren't exposed as arguments.
+func dynamicParams(params []*Param) []*Param {
+	dynamic := make([]*Param, 0, len(params))
+	for _, param := range params {
+		if param.ValueType != CONSTANT {
+			dynamic = append(dynamic, param)
+		}
+	}
+	return dynamic
+}
+
+// validateParamDefault checks that a parameter's default converts to its data type
+// and satisfies its enum, so misconfigured defaults fail at config load
+func validateParamDefault(param *Param) error {
+	if param.Default == "" {
+		return nil
+	}
+
+	value, err := coerceParamValue(param, param.Default)
+	if err != nil {
+		return fmt.Errorf("invalid default for parameter '%s': %w", param.Identifier, err)
+	}
+
+	if len(param.Enum) > 0 && !slices.Contains(param.Enum, fmt.Sprintf("%v", value)) {
+		return fmt.Errorf("default for parameter '%s' is not one of: %s", param.Identifier, strings.Join(param.Enum, ", "))
+	}
+
+	return nil
+}
+
+// validateParamRange checks that a minimum or maximum is only set on number and integer
+// parameters and that the range isn't empty
+func validateParamRange(param *Param) error {
+	if param.Minimum == nil && param.Maximum == nil {
+		return nil
+	}
+
+	dataType := strings.ToLower(string(param.DataType))
+	if dataType != "number" && dataType != "integer" {
+		return fmt.Errorf("minimum and maximum are only supported for number and integer parameters, not '%s'", param.Identifier)
+	}
+	if param.Minimum != nil && param.Maximum != nil && *param.Minimum > *param.Maximum {
+		return fmt.Errorf("parameter '%s' minimum is greater than its maximum", param.Identifier)
+	}
+
+	return nil
+}
+
+// coerceParamValue converts a value to the parameter's declared data type.
+// Strings are parsed for numbers, booleans, objects and arrays since prompt
+// arguments and some LLMs deliver everything as text.
+func coerceParamValue(param *Param, value any) (any, error) {
+	switch strings.ToLower(string(param.DataType)) {
+	case "string":
+		switch v := value.(type) {
+		case string:
+			return v, nil
+		case float64, bool, int, int64:
+			return fmt.Sprintf("%v", v), nil
+		default:
+			return nil, fmt.Errorf("expected string, got %T", value)
+		}
+
+	case "number":
+		number, err := parseNumber("number", value)
+		if err != nil {
+			return nil, err
+		}
+		if err := checkParamRange(param, number); err != nil {
+			return nil, err
+		}
+		return number, nil
+
+	case "integer":
+		number, err := parseNumber("integer", value)
+		if err != nil {
+			return nil, err
+		}
+		if number != math.Trunc(number) {
+			return nil, fmt.Errorf("expected integer, got %v", number)
+		}
+		if err := checkParamRange(param, number); err != nil {
+			return nil, err
+		}
+		return int64(number), nil
+
+	case "boolean":
+		switch v := value.(type) {
+		case bool:
+			return v, nil
+		case string:
+			boolean, err := strconv.ParseBool(strings.TrimSpace(v))
+			if err != nil {
+				return nil, fmt.Errorf("expected boolean, got %q", v)
+			}
+			return boolean, nil
+		default:
+			return nil, fmt.Errorf("expected boolean, got %T", value)
+		}
+
+	case "object":
+		switch v := value.(type) {
+		case map[string]any:
+			return v, nil
+		case string:
+			var object map[string]any
+			if err := json.Unmarshal([]byte(v), &object); err != nil {
+				return nil, fmt.Errorf("expected object, got %q", v)
+			}
+			return object, nil
+		default:
+			return nil, fmt.Errorf("expected object, got %T", value)
+		}
+
+	case "array":
+		switch v := value.(type) {
+		case []any:
+			return v, nil
+		case string:
+			var array []any
+			if err := json.Unmarshal([]byte(v), &array); err != nil {
+				return nil, fmt.Errorf("expected array, got %q", v)
+			}
+			return array, nil
+		default:
+			return nil, fmt.Errorf("expected array, got %T", value)
+		}
+
+	case string(RAW_BODY):
+		switch v := value.(type) {
+		case string:
+			if !json.Valid([]byte(v)) {
+				return nil, fmt.Errorf("expected JSON body, got %q", v)
+			}
+			return json.RawMessage(v), nil
+		default:
+			body, err := json.Marshal(v)
+			if err != nil {
+				return nil, fmt.Errorf("expected JSON body: %w", err)
+			}
+			return json.RawMessage(body), nil
+		}
+
+	default:
+		// Unknown data types are passed through unchanged
+		return value, nil
+	}
+}
+
+// parseNumber converts a JSON number, Go integer or numeric string to a float64
+func parseNumber(dataType string, value any) (float64, error) {
+	switch v := value.(type) {
+	case float64:
+		return v, nil
+	case int:
+		return float64(v), nil
+	case int64:
+		return float64(v), nil
+	case string:
+		number, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		if err != nil {
+			return 0, fmt.Errorf("expected %s, got %q", dataType, v)
+		}
+		return number, nil
+	default:
+		return 0, fmt.Errorf("expected %s, got %T", dataType, value)
+	}
+}
+
+// checkParamRange checks a number against the parameter's minimum and maximum
+func checkParamRange(param *Param, number float64) error {
+	if param.Minimum != nil && number < *param.Minimum {
+		return fmt.Errorf("%v is less than the minimum %v", number, *param.Minimum)
+	}
+	if param.Maximum != nil && number > *param.Maximum {
+		return fmt.Errorf("%v is greater than the maximum %v", number, *param.Maximum)
+	}
+	return nil
+}
+
+// rawBodyParam returns the endpoint's raw_body parameter, if it declares one
+func rawBodyParam(endpoint *Endpoint) *Param {
+	for _, param := range endpoint.BodyParams {
+		if param.DataType == RAW_BODY {
+			return param
+		}
+	}
+	return nil
+}
+
+// buildRawBody returns the raw_body parameter's value as the request body
+func buildRawBody(param *Param, arguments map[string]any) ([]byte, error) {
+	if param.ValueType == CONSTANT {
+		if param.Value == "" {
+			return nil, nil
+		}
+		return []byte(param.Value), nil
+	}
+
+	value, exists := arguments[param.Identifier]
+	if !exists {
+		if param.Required {
+			return nil, fmt.Errorf("required body parameter '%s' not provided", param.Identifier)
+		}
+		return nil, nil
+	}
+
+	body, err := coerceParamValue(param, value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid value for body parameter '%s': %w", param.Identifier, err)
+	}
+
+	return body.(json.RawMessage), nil
+}
+
+// buildArrayBody returns an array_body endpoint's body parameter as a JSON array
+func buildArrayBody(param *Param, arguments map[string]any) ([]byte, error) {
+	if param.ValueType == CONSTANT {
+		return []byte(param.Value), nil
+	}
+
+	value, exists := arguments[param.Identifier]
+	if !exists || value == nil {
+		if param.Required {
+			return nil, fmt.Errorf("required body parameter '%s' not provided", param.Identifier)
+		}
+		return nil, nil
+	}
+
+	array, err := coerceParamValue(param, value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid value for body parameter '%s': %w", param.Identifier, err)
+	}
+
+	return json.Marshal(array)
+}
+
+// placeholderPattern matches {identifier} placeholders in templated values
+var placeholderPattern = regexp.MustCompile(`\{[A-Za-z0-9_.-]+\}`)
+
+// expandPlaceholders replaces {identifier} placeholders with argument values
+func expandPlaceholders(text string, arguments map[string]any) string {
+	replacements := make([]string, 0, len(arguments)*2)
+	for name, value := range arguments {
+		replacements = append(replacements, fmt.Sprintf("{%s}", name), fmt.Sprintf("%v", value))
+	}
+	return strings.NewReplacer(replacements...).Replace(text)
+}
+
+// resolveHeaderValue returns the value to send for a header. Constant headers are
+// sent as-is. Dynamic header values are templates like "Bearer {token}" filled from
+// the arguments; a dynamic header without placeholders takes the argument named like
+// the header. It reports false when the value can't be resolved.
+func resolveHeaderValue(header *Header, arguments map[string]any) (string, bool) {
+	switch header.Type {
+	case CONSTANT:
+		return header.Value, true
+	case DYNAMIC:
+		if placeholderPattern.MatchString(header.Value) {
+			value := expandPlaceholders(header.Value, arguments)
+			if placeholderPattern.MatchString(value) {
+				return "", false
+			}
+			return value, true
+		}
+		if value, exists := arguments[header.Name]; exists {
+			return fmt.Sprintf("%v", value), true
+		}
+	}
+	return "", false
+}
+
+// addQueryValue adds a query parameter's value to values. Arrays are sent as repeated
+// keys (?tag=a&tag=b), or as one comma-separated value (?tag=a,b) for the csv style.
+func addQueryValue(values url.Values, param *Param, value any) {
+	items, isArray := value.([]any)
+	if !isArray {
+		values.Add(param.Identifier, fmt.Sprintf("%v", value))
+		return
+	}
+
+	strs := make([]string, len(items))
+	for i, item := range items {
+		strs[i] = fmt.Sprintf("%v", item)
+	}
+
+	if param.Style == COMMA_SEPARATED {
+		values.Add(param.Identifier, strings.Join(strs, ","))
+		return
+	}
+	for _, str := range strs {
+		values.Add(param.Identifier, str)
+	}
+}
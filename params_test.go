@@ -0,0 +1,213 @@
+package proxy
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func floatPtr(v float64) *float64 {
+	return &v
+}
+
+func TestCoerceParamValue(t *testing.T) {
+	tests := []struct {
+		name    string
+		param   Param
+		value   any
+		want    any
+		wantErr string
+	}{
+		{"integer from float", Param{DataType: "integer"}, 3.0, int64(3), ""},
+		{"integer from string", Param{DataType: "integer"}, " 42 ", int64(42), ""},
+		{"integer from int", Param{DataType: "integer"}, 7, int64(7), ""},
+		{"integer rejects fraction", Param{DataType: "integer"}, 2.5, nil, "expected integer"},
+		{"integer rejects fractional string", Param{DataType: "integer"}, "2.5", nil, "expected integer"},
+		{"number keeps fraction", Param{DataType: "number"}, "2.5", 2.5, ""},
+		{"number from int", Param{DataType: "number"}, 2, 2.0, ""},
+		{"number rejects text", Param{DataType: "number"}, "many", nil, "expected number"},
+		{"number rejects bool", Param{DataType: "number"}, true, nil, "expected number, got bool"},
+		{"string from number", Param{DataType: "string"}, 12.0, "12", ""},
+		{"boolean from string", Param{DataType: "boolean"}, "true", true, ""},
+		{"boolean rejects text", Param{DataType: "boolean"}, "yes", nil, "expected boolean"},
+		{"integer within range", Param{DataType: "integer", Minimum: floatPtr(1), Maximum: floatPtr(100)}, "100", int64(100), ""},
+		{"integer below minimum", Param{DataType: "integer", Minimum: floatPtr(1)}, 0.0, nil, "less than the minimum 1"},
+		{"number above maximum", Param{DataType: "number", Maximum: floatPtr(1.5)}, 1.75, nil, "greater than the maximum 1.5"},
+		{"data type is case-insensitive", Param{DataType: "Integer"}, "5", int64(5), ""},
+		{"unknown type passes through", Param{DataType: "uuid"}, "abc", "abc", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := coerceParamValue(&tt.param, tt.value)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("coerceParamValue(%v) = %v, %v, want an error containing %q", tt.value, got, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("coerceParamValue(%v) = %#v, %v, want %#v", tt.value, got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckParamRange(t *testing.T) {
+	param := &Param{Identifier: "limit", DataType: "number", Minimum: floatPtr(-1), Maximum: floatPtr(10)}
+	tests := []struct {
+		number  float64
+		wantErr string
+	}{
+		{-1, ""},
+		{0, ""},
+		{10, ""},
+		{-1.5, "less than the minimum -1"},
+		{10.01, "greater than the maximum 10"},
+	}
+
+	for _, tt := range tests {
+		err := checkParamRange(param, tt.number)
+		if tt.wantErr == "" && err != nil {
+			t.Errorf("checkParamRange(%v) = %v, want nil", tt.number, err)
+		}
+		if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+			t.Errorf("checkParamRange(%v) = %v, want an error containing %q", tt.number, err, tt.wantErr)
+		}
+	}
+
+	// Only set bounds are checked
+	if err := checkParamRange(&Param{DataType: "number", Maximum: floatPtr(0)}, -1e9); err != nil {
+		t.Errorf("checkParamRange without a minimum = %v, want nil", err)
+	}
+}
+
+func TestValidateArguments(t *testing.T) {
+	endpoint := &Endpoint{
+		PathParameters: []*Param{
+			{Identifier: "user_id", DataType: "integer", ValueType: DYNAMIC, Required: true, Minimum: floatPtr(1)},
+		},
+		QueryParameters: []*Param{
+			{Identifier: "limit", DataType: "integer", ValueType: DYNAMIC, Default: "20", Maximum: floatPtr(100)},
+			{Identifier: "order", DataType: "string", ValueType: DYNAMIC, Default: "asc", Enum: []string{"asc", "desc"}},
+			{Identifier: "page_size", DataType: "integer", ValueType: DYNAMIC, Enum: []string{"10", "50"}},
+			{Identifier: "api_version", DataType: "string", ValueType: CONSTANT, Value: "2024-01"},
+		},
+		BodyParams: []*Param{
+			{Identifier: "score", DataType: "number", ValueType: DYNAMIC, Minimum: floatPtr(0), Maximum: floatPtr(1)},
+		},
+	}
+
+	tests := []struct {
+		name      string
+		arguments map[string]any
+		want      map[string]any
+		wantErr   string
+	}{
+		{
+			name:      "defaults fill omitted params",
+			arguments: map[string]any{"user_id": "7"},
+			want:      map[string]any{"user_id": int64(7), "limit": int64(20), "order": "asc"},
+		},
+		{
+			name:      "null uses the default",
+			arguments: map[string]any{"user_id": 7.0, "limit": nil},
+			want:      map[string]any{"user_id": int64(7), "limit": int64(20), "order": "asc"},
+		},
+		{
+			name:      "supplied values override defaults",
+			arguments: map[string]any{"user_id": 7.0, "limit": "50", "order": "desc", "score": "0.5"},
+			want:      map[string]any{"user_id": int64(7), "limit": int64(50), "order": "desc", "score": 0.5},
+		},
+		{
+			name:      "integer enum matches coerced value",
+			arguments: map[string]any{"user_id": 7.0, "page_size": "50"},
+			want:      map[string]any{"user_id": int64(7), "limit": int64(20), "order": "asc", "page_size": int64(50)},
+		},
+		{
+			name:      "missing required param",
+			arguments: map[string]any{"limit": 10.0},
+			wantErr:   "required path parameter 'user_id' not provided",
+		},
+		{
+			name:      "float for integer param",
+			arguments: map[string]any{"user_id": 7.5},
+			wantErr:   "invalid value for path parameter 'user_id': expected integer",
+		},
+		{
+			name:      "integer below minimum",
+			arguments: map[string]any{"user_id": 0.0},
+			wantErr:   "less than the minimum 1",
+		},
+		{
+			name:      "integer above maximum",
+			arguments: map[string]any{"user_id": 1.0, "limit": 101.0},
+			wantErr:   "invalid value for query parameter 'limit': 101 is greater than the maximum 100",
+		},
+		{
+			name:      "number above maximum",
+			arguments: map[string]any{"user_id": 1.0, "score": 1.5},
+			wantErr:   "invalid value for body parameter 'score': 1.5 is greater than the maximum 1",
+		},
+		{
+			name:      "value outside enum",
+			arguments: map[string]any{"user_id": 1.0, "order": "random"},
+			wantErr:   "random is not one of: asc, desc",
+		},
+		{
+			name:      "integer outside enum",
+			arguments: map[string]any{"user_id": 1.0, "page_size": 20.0},
+			wantErr:   "20 is not one of: 10, 50",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := validateArguments(endpoint, tt.arguments)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("validateArguments(%v) = %v, want an error containing %q", tt.arguments, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("validateArguments(%v) = %#v, %v, want %#v", tt.arguments, got, err, tt.want)
+			}
+		})
+	}
+
+	// The caller's arguments are left untouched
+	arguments := map[string]any{"user_id": "7"}
+	if _, err := validateArguments(endpoint, arguments); err != nil {
+		t.Fatalf("validateArguments failed: %v", err)
+	}
+	if !reflect.DeepEqual(arguments, map[string]any{"user_id": "7"}) {
+		t.Errorf("validateArguments changed the caller's arguments to %v", arguments)
+	}
+}
+
+func TestValidateParamDefault(t *testing.T) {
+	tests := []struct {
+		name    string
+		param   Param
+		wantErr string
+	}{
+		{"valid integer default", Param{Identifier: "limit", DataType: "integer", Default: "10", Maximum: floatPtr(100)}, ""},
+		{"default above maximum", Param{Identifier: "limit", DataType: "integer", Default: "500", Maximum: floatPtr(100)}, "invalid default for parameter 'limit'"},
+		{"fractional integer default", Param{Identifier: "limit", DataType: "integer", Default: "1.5"}, "expected integer"},
+		{"default outside enum", Param{Identifier: "order", DataType: "string", Default: "up", Enum: []string{"asc", "desc"}}, "not one of: asc, desc"},
+		{"default in enum", Param{Identifier: "order", DataType: "string", Default: "desc", Enum: []string{"asc", "desc"}}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateParamDefault(&tt.param)
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("validateParamDefault() = %v, want nil", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("validateParamDefault() = %v, want an error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
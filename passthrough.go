@@ -0,0 +1,284 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// PassthroughToolHandler handles tools whose method and path are chosen by the LLM, within
+// the endpoint's allowlist. It reuses the HTTP tool handler's response handling.
+type PassthroughToolHandler struct {
+	*HTTPToolHandler
+	allowedPaths   []*regexp.Regexp
+	allowedMethods []string
+}
+
+// NewPassthroughToolHandler creates a new passthrough tool handler
+func NewPassthroughToolHandler(endpoint *Endpoint, backend *Backend, logger *slog.Logger, clientManager *ClientManager) (*PassthroughToolHandler, error) {
+	allowedPaths, err := compileAllowedPaths(endpoint.Passthrough.AllowedPaths)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PassthroughToolHandler{
+		HTTPToolHandler: NewHTTPToolHandler(endpoint, backend, logger, clientManager),
+		allowedPaths:    allowedPaths,
+		allowedMethods:  passthroughMethods(endpoint.Passthrough),
+	}, nil
+}
+
+// compileAllowedPaths compiles allowed_paths patterns so they must match a whole path
+func compileAllowedPaths(patterns []string) ([]*regexp.Regexp, error) {
+	allowedPaths := make([]*regexp.Regexp, 0, len(patterns))
+	for _, pattern := range patterns {
+		re, err := regexp.Compile("^(?:" + pattern + ")$")
+		if err != nil {
+			return nil, fmt.Errorf("invalid allowed_paths pattern '%s': %w", pattern, err)
+		}
+		allowedPaths = append(allowedPaths, re)
+	}
+	return allowedPaths, nil
+}
+
+// passthroughMethods returns the methods a passthrough tool may use, GET by default
+func passthroughMethods(passthrough *Passthrough) []string {
+	if len(passthrough.AllowedMethods) == 0 {
+		return []string{string(GET)}
+	}
+	methods := make([]string, 0, len(passthrough.AllowedMethods))
+	for _, method := range passthrough.AllowedMethods {
+		methods = append(methods, strings.ToUpper(string(method)))
+	}
+	return methods
+}
+
+// CreateMCPTool creates an MCP tool taking the method, path, query and body of the request
+func (h *PassthroughToolHandler) CreateMCPTool() mcp.Tool {
+	return mcp.NewTool(h.endpoint.Name,
+		mcp.WithDescription(h.endpoint.Description),
+		mcp.WithString("method",
+			mcp.Description("HTTP method of the request"),
+			mcp.Enum(h.allowedMethods...),
+			mcp.DefaultString(h.allowedMethods[0]),
+		),
+		mcp.WithString("path",
+			mcp.Description(fmt.Sprintf("Path of the request, starting with '/'. Must match one of: %s",
+				strings.Join(h.endpoint.Passthrough.AllowedPaths, ", "))),
+			mcp.Required(),
+		),
+		mcp.WithObject("query",
+			mcp.Description("Query parameters of the request, as an object of names to values"),
+		),
+		mcp.WithObject("body",
+			mcp.Description("JSON object sent as the request body"),
+		),
+	)
+}
+
+// Handler executes the tool by sending the requested method and path to the backend
+func (h *PassthroughToolHandler) Handler(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	// Tag logs and backend requests with the call's request ID
+	ctx, requestID := startRequest(ctx, req.Params.Meta)
+
+	call := *h
+	call.HTTPToolHandler = h.HTTPToolHandler.forRequest(requestID)
+	h = &call
+
+	// Let the endpoint's request transformer rewrite the arguments; its output is checked
+	// against the allowlist like the LLM's
+	arguments, err := h.transformArguments(ctx, req.GetArguments())
+	if err != nil {
+		return nil, err
+	}
+
+	method, path, err := h.checkRequest(arguments)
+	if err != nil {
+		h.logger.Warn("Passthrough request rejected", "tool", h.endpoint.Name, "error", err)
+		return toolErrorResult("Tool '%s' received invalid arguments: %v", h.endpoint.Name, err), nil
+	}
+
+	// The call's copy of the endpoint carries the requested method
+	endpoint := *h.endpoint
+	endpoint.Method = Method(method)
+	h.endpoint = &endpoint
+
+	// Serve the configured mock instead of calling the backend
+	if h.endpoint.MockResponse != nil {
+		return h.handleResponse(newMockHTTPResponse(h.endpoint.MockResponse))
+	}
+
+	requestURL, err := h.buildPassthroughURL(path, arguments["query"])
+	if err != nil {
+		return toolErrorResult("Tool '%s' failed to build URL: %v", h.endpoint.Name, err), nil
+	}
+
+	var body []byte
+	if value, ok := arguments["body"]; ok && value != nil {
+		if body, err = json.Marshal(value); err != nil {
+			return toolErrorResult("Tool '%s' failed to build request body: %v", h.endpoint.Name, err), nil
+		}
+		if err := checkRequestSize(h.endpoint, body); err != nil {
+			return toolErrorResult("Tool '%s' request body too large: %v", h.endpoint.Name, err), nil
+		}
+	}
+
+	// Bound the request, including reading the response, by the endpoint's response timeout
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(h.endpoint.ResponseTimeout))
+	defer cancel()
+
+	// Apply the endpoint's retry, limit and idempotency settings to the call
+	ctx, stats := h.callContext(ctx)
+
+	httpReq, err := http.NewRequestWithContext(ctx, method, requestURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create HTTP request: %w", err)
+	}
+
+	// Only configured headers are sent; the LLM can't set headers of its own
+	h.addHeaders(httpReq, nil)
+	if body != nil {
+		httpReq.Header.Set("Content-Type", "application/json")
+	}
+
+	h.logger.Debug("Making HTTP request for passthrough tool",
+		"tool", h.endpoint.Name,
+		"method", method,
+		"url", requestURL,
+	)
+
+	resp, err := h.clientManager.DoHedgedRequest(ctx, httpReq, h.endpoint.Name, time.Duration(h.endpoint.HedgeAfter))
+	if err != nil {
+		return nil, fmt.Errorf("HTTP request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// Let the endpoint's response transformer rewrite the response
+	if err := h.transformResponseBody(ctx, resp); err != nil {
+		return nil, err
+	}
+
+	result, err := h.handleResponse(resp)
+	if err != nil {
+		return nil, err
+	}
+
+	h.addResponseHeaders(result, resp)
+	result.Meta = backendResultMeta(resp, stats)
+
+	return result, nil
+}
+
+// checkRequest returns the requested method and path after checking them against the
+// endpoint's allowlist
+func (h *PassthroughToolHandler) checkRequest(arguments map[string]any) (string, string, error) {
+	method := h.allowedMethods[0]
+	if value, ok := arguments["method"]; ok && value != nil {
+		requested, ok := value.(string)
+		if !ok {
+			return "", "", fmt.Errorf("method must be a string")
+		}
+		method = strings.ToUpper(requested)
+	}
+	if !slices.Contains(h.allowedMethods, method) {
+		return "", "", fmt.Errorf("method '%s' is not allowed, must be one of: %s", method, strings.Join(h.allowedMethods, ", "))
+	}
+
+	path, ok := arguments["path"].(string)
+	if !ok || path == "" {
+		return "", "", fmt.Errorf("required argument 'path' not provided")
+	}
+	if err := checkPassthroughPath(path); err != nil {
+		return "", "", err
+	}
+	if !slices.ContainsFunc(h.allowedPaths, func(re *regexp.Regexp) bool { return re.MatchString(path) }) {
+		return "", "", fmt.Errorf("path '%s' is not allowed", path)
+	}
+
+	if query, ok := arguments["query"]; ok && query != nil {
+		params, ok := query.(map[string]any)
+		if !ok {
+			return "", "", fmt.Errorf("query must be an object")
+		}
+		for name, value := range params {
+			if _, ok := value.(map[string]any); ok {
+				return "", "", fmt.Errorf("query parameter '%s' must not be an object", name)
+			}
+		}
+	}
+	if body, ok := arguments["body"]; ok && body != nil {
+		if _, ok := body.(map[string]any); !ok {
+			return "", "", fmt.Errorf("body must be an object")
+		}
+	}
+
+	return method, path, nil
+}
+
+// checkPassthroughPath rejects paths that could reach outside what the allowlist
+// describes once the backend resolves them: relative segments, empty segments, query
+// strings, fragments, backslashes and control characters
+func checkPassthroughPath(path string) error {
+	if !strings.HasPrefix(path, "/") {
+		return fmt.Errorf("path must start with '/'")
+	}
+	if strings.ContainsAny(path, "?#\\") {
+		return fmt.Errorf("path must not contain '?', '#' or '\\'; pass query parameters as query")
+	}
+	if strings.ContainsFunc(path, func(r rune) bool { return r < 0x20 || r == 0x7f }) {
+		return fmt.Errorf("path must not contain control characters")
+	}
+
+	segments := strings.Split(path[1:], "/")
+	for i, segment := range segments {
+		if segment == "." || segment == ".." {
+			return fmt.Errorf("path must not contain '.' or '..' segments")
+		}
+		// A trailing slash is allowed, empty segments elsewhere are not
+		if segment == "" && i != len(segments)-1 {
+			return fmt.Errorf("path must not contain empty segments")
+		}
+	}
+
+	return nil
+}
+
+// buildPassthroughURL appends a checked path to the backend's BaseURL and adds the checked
+// query parameters. The path is escaped, so percent signs can't smuggle in encoded segments.
+func (h *PassthroughToolHandler) buildPassthroughURL(path string, query any) (string, error) {
+	u, err := url.Parse(h.backend.BaseURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid base_url: %w", err)
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/") + path
+	u.RawPath = ""
+
+	if params, ok := query.(map[string]any); ok {
+		values := u.Query()
+		for name, value := range params {
+			if items, ok := value.([]any); ok {
+				for _, item := range items {
+					values.Add(name, fmt.Sprintf("%v", item))
+				}
+			} else {
+				values.Add(name, fmt.Sprintf("%v", value))
+			}
+		}
+		u.RawQuery = values.Encode()
+	}
+
+	if err := checkTargetURL(h.backend, u.String()); err != nil {
+		return "", err
+	}
+	return u.String(), nil
+}
@@ -0,0 +1,201 @@
+package proxy
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestCheckPassthroughPath(t *testing.T) {
+	tests := []struct {
+		path    string
+		wantErr string
+	}{
+		{"/users", ""},
+		{"/users/42", ""},
+		{"/users/", ""},
+		{"/users/%2e%2e", ""},
+		{"users", "must start with '/'"},
+		{"/users/../admin", "'.' or '..' segments"},
+		{"/users/./42", "'.' or '..' segments"},
+		{"/users/..", "'.' or '..' segments"},
+		{"/users//42", "empty segments"},
+		{"//evil.example/users", "empty segments"},
+		{"/users?role=admin", "must not contain '?'"},
+		{"/users#admin", "must not contain '?'"},
+		{`/users\..\admin`, "must not contain '?'"},
+		{"/users/42\n", "control characters"},
+		{"/users/\x7f", "control characters"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			err := checkPassthroughPath(tt.path)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("checkPassthroughPath(%q) = %v, want nil", tt.path, err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("checkPassthroughPath(%q) = %v, want an error containing %q", tt.path, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCompileAllowedPaths(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		want    bool
+	}{
+		{"/users", "/users", true},
+		{"/users", "/users/42", false},
+		{"/users", "/admin/users", false},
+		{"/users(/[0-9]+)?", "/users/42", true},
+		{"/users(/[0-9]+)?", "/users/42/delete", false},
+		{"/users|/reports", "/reports", true},
+		{"/users|/reports", "/reports/secret", false},
+		{"/reports/.*", "/reports/2024/q1", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern+" "+tt.path, func(t *testing.T) {
+			allowedPaths, err := compileAllowedPaths([]string{tt.pattern})
+			if err != nil {
+				t.Fatalf("compileAllowedPaths(%q) failed: %v", tt.pattern, err)
+			}
+			if got := allowedPaths[0].MatchString(tt.path); got != tt.want {
+				t.Errorf("pattern %q matching %q = %v, want %v", tt.pattern, tt.path, got, tt.want)
+			}
+		})
+	}
+
+	if _, err := compileAllowedPaths([]string{"/users("}); err == nil {
+		t.Error("compileAllowedPaths accepted an invalid pattern")
+	}
+}
+
+func TestPassthroughCheckRequest(t *testing.T) {
+	allowedPaths, err := compileAllowedPaths([]string{"/users(/[0-9]+)?", "/reports/.*"})
+	if err != nil {
+		t.Fatalf("compileAllowedPaths failed: %v", err)
+	}
+	h := &PassthroughToolHandler{
+		allowedPaths:   allowedPaths,
+		allowedMethods: []string{"GET", "POST"},
+	}
+
+	tests := []struct {
+		name       string
+		arguments  map[string]any
+		wantMethod string
+		wantErr    string
+	}{
+		{"default method", map[string]any{"path": "/users"}, "GET", ""},
+		{"lowercase method", map[string]any{"method": "post", "path": "/users/42"}, "POST", ""},
+		{"disallowed method", map[string]any{"method": "DELETE", "path": "/users/42"}, "", "method 'DELETE' is not allowed"},
+		{"method not a string", map[string]any{"method": 1, "path": "/users"}, "", "method must be a string"},
+		{"missing path", map[string]any{}, "", "'path' not provided"},
+		{"path outside allowlist", map[string]any{"path": "/admin"}, "", "not allowed"},
+		{"partial match", map[string]any{"path": "/users/42/delete"}, "", "not allowed"},
+		{"traversal", map[string]any{"path": "/reports/../admin"}, "", "'.' or '..' segments"},
+		{"query in path", map[string]any{"path": "/users?admin=true"}, "", "must not contain '?'"},
+		{"encoded traversal", map[string]any{"path": "/users/%2e%2e"}, "", "not allowed"},
+		{"query not an object", map[string]any{"path": "/users", "query": "a=b"}, "", "query must be an object"},
+		{"nested query", map[string]any{"path": "/users", "query": map[string]any{"a": map[string]any{}}}, "", "must not be an object"},
+		{"body not an object", map[string]any{"path": "/users", "body": "x"}, "", "body must be an object"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			method, _, err := h.checkRequest(tt.arguments)
+			if tt.wantErr == "" {
+				if err != nil || method != tt.wantMethod {
+					t.Errorf("checkRequest(%v) = %q, %v, want %q", tt.arguments, method, err, tt.wantMethod)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("checkRequest(%v) = %v, want an error containing %q", tt.arguments, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPassthroughEscapesEncodedSegments(t *testing.T) {
+	backend := newEchoBackend(t)
+	s := newTestProxy(t, fmt.Sprintf(`
+backends:
+  - base_url: %s
+    endpoints:
+      - name: files
+        capability: tool
+        mode: client
+        passthrough:
+          allowed_paths: ["/files/.*"]
+`, backend.URL))
+
+	text, isError := toolText(t, s, "files", map[string]any{"path": "/files/%2e%2e%2fadmin"})
+	if isError {
+		t.Fatalf("files failed: %s", text)
+	}
+	requests := backend.received()
+	if len(requests) != 1 || requests[0].URL.Path != "/files/%2e%2e%2fadmin" {
+		t.Errorf("backend received %v, want one request for the literal path /files/%%2e%%2e%%2fadmin", requests)
+	}
+}
+
+func TestPassthroughChecksTransformedRequest(t *testing.T) {
+	backend := newEchoBackend(t)
+	s := newTestProxy(t, fmt.Sprintf(`
+backends:
+  - base_url: %s
+    endpoints:
+      - name: users
+        capability: tool
+        mode: client
+        transform_request: to_admin
+        passthrough:
+          allowed_paths: ["/users(/[0-9]+)?"]
+`, backend.URL),
+		WithRequestTransformer("to_admin", RequestTransformerFunc(
+			func(ctx context.Context, endpoint *Endpoint, arguments map[string]any) (map[string]any, error) {
+				arguments["path"] = "/admin"
+				return arguments, nil
+			})),
+	)
+
+	text, isError := toolText(t, s, "users", map[string]any{"path": "/users/42"})
+	if !isError || !strings.Contains(text, "path '/admin' is not allowed") {
+		t.Errorf("users returned %q (error %v), want the transformed path rejected", text, isError)
+	}
+	if requests := backend.received(); len(requests) != 0 {
+		t.Errorf("backend received %d requests, want none", len(requests))
+	}
+}
+
+func TestPassthroughBuildErrorsAreToolResults(t *testing.T) {
+	s := newTestProxy(t, `
+backends:
+  - base_url: http://localhost
+    endpoints:
+      - name: upload
+        capability: tool
+        mode: client
+        max_request_bytes: 16
+        passthrough:
+          allowed_paths: ["/upload"]
+          allowed_methods: [POST]
+`)
+
+	text, isError := toolText(t, s, "upload", map[string]any{
+		"path": "/upload",
+		"body": map[string]any{"data": strings.Repeat("x", 64)},
+	})
+	if !isError || !strings.Contains(text, "request body too large") {
+		t.Errorf("upload returned %q (error %v), want an error result for the oversized body", text, isError)
+	}
+}
@@ -0,0 +1,202 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// resourcePoller periodically fetches a resource and notifies subscribers when it changes
+type resourcePoller struct {
+	uri           string
+	interval      time.Duration
+	handler       server.ResourceHandlerFunc
+	subscriptions *resourceSubscriptions
+	logger        *slog.Logger
+}
+
+// newResourcePoller creates a poller for a static resource
+func newResourcePoller(uri string, interval time.Duration, handler server.ResourceHandlerFunc, subscriptions *resourceSubscriptions, logger *slog.Logger) *resourcePoller {
+	return &resourcePoller{
+		uri:           uri,
+		interval:      interval,
+		handler:       handler,
+		subscriptions: subscriptions,
+		logger:        logger,
+	}
+}
+
+// run polls the resource until the context is cancelled
+func (p *resourcePoller) run(ctx context.Context, mcpServer *server.MCPServer) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	// Establish the baseline so the first tick doesn't report a spurious change
+	lastHash, _ := p.fetchHash(ctx)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			hash, err := p.fetchHash(ctx)
+			if err != nil {
+				p.logger.Warn("Failed to poll resource", "uri", p.uri, "error", err)
+				continue
+			}
+
+			// Without a baseline there is nothing to compare to, so this poll becomes it
+			if lastHash == nil {
+				lastHash = hash
+				continue
+			}
+			if bytes.Equal(hash, lastHash) {
+				continue
+			}
+			lastHash = hash
+
+			p.notify(mcpServer)
+		}
+	}
+}
+
+// notify sends a resource updated notification to the sessions subscribed to the resource
+func (p *resourcePoller) notify(mcpServer *server.MCPServer) {
+	sessions := p.subscriptions.sessions(p.uri)
+	p.logger.Debug("Resource changed, notifying subscribers", "uri", p.uri, "subscribers", len(sessions))
+	for _, sessionID := range sessions {
+		err := mcpServer.SendNotificationToSpecificClient(sessionID, mcp.MethodNotificationResourceUpdated, map[string]any{
+			"uri": p.uri,
+		})
+		if err != nil {
+			p.logger.Warn("Failed to notify subscriber", "uri", p.uri, "session", sessionID, "error", err)
+		}
+	}
+}
+
+// fetchHash reads the resource and returns a hash of its contents
+func (p *resourcePoller) fetchHash(ctx context.Context) ([]byte, error) {
+	var req mcp.ReadResourceRequest
+	req.Params.URI = p.uri
+
+	contents, err := p.handler(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(contents)
+	if err != nil {
+		return nil, err
+	}
+
+	sum := sha256.Sum256(data)
+	return sum[:], nil
+}
+
+// Subscription methods, which mcp-go doesn't define constants for
+const (
+	methodResourcesSubscribe   = "resources/subscribe"
+	methodResourcesUnsubscribe = "resources/unsubscribe"
+)
+
+// resourceSubscriptions tracks which sessions subscribed to which resource URIs. The zero
+// value is ready to use.
+type resourceSubscriptions struct {
+	mu   sync.Mutex
+	uris map[string]map[string]struct{}
+}
+
+// subscribe records that a session subscribed to a resource
+func (r *resourceSubscriptions) subscribe(uri, sessionID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.uris == nil {
+		r.uris = make(map[string]map[string]struct{})
+	}
+	if r.uris[uri] == nil {
+		r.uris[uri] = make(map[string]struct{})
+	}
+	r.uris[uri][sessionID] = struct{}{}
+}
+
+// unsubscribe removes a session's subscription to a resource
+func (r *resourceSubscriptions) unsubscribe(uri, sessionID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.uris[uri], sessionID)
+	if len(r.uris[uri]) == 0 {
+		delete(r.uris, uri)
+	}
+}
+
+// removeSession drops every subscription of a session
+func (r *resourceSubscriptions) removeSession(sessionID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for uri, sessions := range r.uris {
+		delete(sessions, sessionID)
+		if len(sessions) == 0 {
+			delete(r.uris, uri)
+		}
+	}
+}
+
+// sessions returns the IDs of the sessions subscribed to a resource
+func (r *resourceSubscriptions) sessions(uri string) []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ids := make([]string, 0, len(r.uris[uri]))
+	for id := range r.uris[uri] {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// addHooks records resources/subscribe and resources/unsubscribe requests and forgets
+// the subscriptions of sessions that end. mcp-go routes every request through the
+// request initialization hook, including subscription requests it has no handler for.
+func (r *resourceSubscriptions) addHooks(hooks *server.Hooks) {
+	hooks.AddOnRequestInitialization(func(ctx context.Context, id any, message any) error {
+		raw, ok := message.(json.RawMessage)
+		if !ok {
+			return nil
+		}
+		session := server.ClientSessionFromContext(ctx)
+		if session == nil {
+			return nil
+		}
+
+		var request struct {
+			Method string `json:"method"`
+			Params struct {
+				URI string `json:"uri"`
+			} `json:"params"`
+		}
+		if err := json.Unmarshal(raw, &request); err != nil || request.Params.URI == "" {
+			return nil
+		}
+
+		switch request.Method {
+		case methodResourcesSubscribe:
+			r.subscribe(request.Params.URI, session.SessionID())
+		case methodResourcesUnsubscribe:
+			r.unsubscribe(request.Params.URI, session.SessionID())
+		}
+		return nil
+	})
+
+	hooks.AddOnUnregisterSession(func(ctx context.Context, session server.ClientSession) {
+		r.removeSession(session.SessionID())
+	})
+}
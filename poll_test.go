@@ -0,0 +1,130 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// testSession is a client session that collects the notifications sent to it
+type testSession struct {
+	id            string
+	notifications chan mcp.JSONRPCNotification
+}
+
+func newTestSession(id string) *testSession {
+	return &testSession{id: id, notifications: make(chan mcp.JSONRPCNotification, 10)}
+}
+
+func (s *testSession) Initialize()                                         {}
+func (s *testSession) Initialized() bool                                   { return true }
+func (s *testSession) NotificationChannel() chan<- mcp.JSONRPCNotification { return s.notifications }
+func (s *testSession) SessionID() string                                   { return s.id }
+
+// waitFor polls cond until it holds or a second has passed
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestResourcePollerNotifiesSubscribers(t *testing.T) {
+	const uri = "proxy://status"
+
+	var subscriptions resourceSubscriptions
+	hooks := &server.Hooks{}
+	subscriptions.addHooks(hooks)
+	mcpServer := server.NewMCPServer("test", "1.0.0", server.WithResourceCapabilities(true, false), server.WithHooks(hooks))
+
+	subscriber := newTestSession("subscriber")
+	bystander := newTestSession("bystander")
+	for _, session := range []*testSession{subscriber, bystander} {
+		if err := mcpServer.RegisterSession(context.Background(), session); err != nil {
+			t.Fatalf("failed to register session: %v", err)
+		}
+	}
+	subscribe := fmt.Sprintf(`{"jsonrpc":"2.0","id":1,"method":"resources/subscribe","params":{"uri":%q}}`, uri)
+	mcpServer.HandleMessage(mcpServer.WithContext(context.Background(), subscriber), []byte(subscribe))
+
+	// Version 0 fails, so the poller starts without a baseline
+	var version, polls atomic.Int64
+	handler := func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+		polls.Add(1)
+		v := version.Load()
+		if v == 0 {
+			return nil, errors.New("backend unavailable")
+		}
+		return []mcp.ResourceContents{mcp.TextResourceContents{URI: uri, Text: fmt.Sprintf("version %d", v)}}, nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	poller := newResourcePoller(uri, 5*time.Millisecond, handler, &subscriptions, discardLogger())
+	go poller.run(ctx, mcpServer)
+
+	waitFor(t, "the failed baseline", func() bool { return polls.Load() >= 2 })
+	version.Store(1)
+	seen := polls.Load()
+	waitFor(t, "polls of the first version", func() bool { return polls.Load() >= seen+3 })
+
+	select {
+	case notification := <-subscriber.notifications:
+		t.Fatalf("got %s after the first successful poll, want no notification", notification.Method)
+	default:
+	}
+
+	version.Store(2)
+	select {
+	case notification := <-subscriber.notifications:
+		if notification.Method != mcp.MethodNotificationResourceUpdated || notification.Params.AdditionalFields["uri"] != uri {
+			t.Errorf("got %s for %v, want %s for %s", notification.Method, notification.Params.AdditionalFields, mcp.MethodNotificationResourceUpdated, uri)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("subscriber wasn't notified of the change")
+	}
+
+	select {
+	case notification := <-bystander.notifications:
+		t.Errorf("session without a subscription got %s", notification.Method)
+	default:
+	}
+}
+
+func TestResourceSubscriptionsEndWithSession(t *testing.T) {
+	var subscriptions resourceSubscriptions
+	hooks := &server.Hooks{}
+	subscriptions.addHooks(hooks)
+	mcpServer := server.NewMCPServer("test", "1.0.0", server.WithHooks(hooks))
+
+	session := newTestSession("session")
+	if err := mcpServer.RegisterSession(context.Background(), session); err != nil {
+		t.Fatalf("failed to register session: %v", err)
+	}
+	ctx := mcpServer.WithContext(context.Background(), session)
+	mcpServer.HandleMessage(ctx, []byte(`{"jsonrpc":"2.0","id":1,"method":"resources/subscribe","params":{"uri":"proxy://a"}}`))
+	mcpServer.HandleMessage(ctx, []byte(`{"jsonrpc":"2.0","id":2,"method":"resources/subscribe","params":{"uri":"proxy://b"}}`))
+	mcpServer.HandleMessage(ctx, []byte(`{"jsonrpc":"2.0","id":3,"method":"resources/unsubscribe","params":{"uri":"proxy://a"}}`))
+
+	if got := subscriptions.sessions("proxy://a"); len(got) != 0 {
+		t.Errorf("sessions for proxy://a after unsubscribe = %v, want none", got)
+	}
+	if got := subscriptions.sessions("proxy://b"); len(got) != 1 || got[0] != "session" {
+		t.Errorf("sessions for proxy://b = %v, want [session]", got)
+	}
+
+	mcpServer.UnregisterSession(context.Background(), "session")
+	if got := subscriptions.sessions("proxy://b"); len(got) != 0 {
+		t.Errorf("sessions for proxy://b after the session ended = %v, want none", got)
+	}
+}
@@ -0,0 +1,334 @@
+package proxy
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// Query parameters of the SSE or Streamable HTTP connection that select a profile, or
+// a comma-separated list of tags
+const (
+	profileQueryParam = "profile"
+	tagsQueryParam    = "tags"
+)
+
+// sessionIDHeader carries the session of Streamable HTTP requests
+const sessionIDHeader = "Mcp-Session-Id"
+
+type profileKey struct{}
+
+// withProfileTags returns a context whose session sees only capabilities tagged with
+// one of tags. Applied more than once, each set of tags must match.
+func withProfileTags(ctx context.Context, tags []string) context.Context {
+	filters, _ := ctx.Value(profileKey{}).([][]string)
+	return context.WithValue(ctx, profileKey{}, append(slices.Clip(filters), tags))
+}
+
+// visibleTo reports whether a capability with tags is visible to the session of ctx.
+// Sessions without a profile or requested tags see everything.
+func visibleTo(ctx context.Context, tags []string) bool {
+	filters, _ := ctx.Value(profileKey{}).([][]string)
+	for _, filter := range filters {
+		if !slices.ContainsFunc(tags, func(tag string) bool { return slices.Contains(filter, tag) }) {
+			return false
+		}
+	}
+	return true
+}
+
+// requestedTags returns the tags a connection asked for with ?tags=a,b
+func requestedTags(r *http.Request) []string {
+	var tags []string
+	for _, value := range r.URL.Query()[tagsQueryParam] {
+		for _, tag := range strings.Split(value, ",") {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				tags = append(tags, tag)
+			}
+		}
+	}
+	return tags
+}
+
+// validateProfiles checks that the default profile is defined and that profiles have tags
+func validateProfiles(cfg *MCPConfig) error {
+	for name, tags := range cfg.Profiles {
+		if len(tags) == 0 {
+			return fmt.Errorf("profile '%s' has no tags", name)
+		}
+	}
+	if _, ok := cfg.Profiles[cfg.DefaultProfile]; cfg.DefaultProfile != "" && !ok {
+		return fmt.Errorf("default_profile '%s' is not defined in profiles", cfg.DefaultProfile)
+	}
+	return nil
+}
+
+// profileTags returns the tags of the named profile, or of the default profile when
+// name is empty. It reports false when there is no such profile.
+func (s *Proxy) profileTags(name string) ([]string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.mcpConfig == nil || s.mcpConfig.MCP == nil {
+		return nil, false
+	}
+	if name == "" {
+		name = s.mcpConfig.MCP.DefaultProfile
+	}
+	tags, ok := s.mcpConfig.MCP.Profiles[name]
+	return tags, ok
+}
+
+// withProfile limits the session of ctx to the named profile, or to the default one.
+// A profile removed by a reload hides everything from the sessions that use it.
+func (s *Proxy) withProfile(ctx context.Context, name string) context.Context {
+	if tags, ok := s.profileTags(name); ok {
+		return withProfileTags(ctx, tags)
+	}
+	if name != "" {
+		return withProfileTags(ctx, []string{})
+	}
+	return ctx
+}
+
+// profileSelection is the profile and tags a connection asked for with ?profile= and
+// ?tags=
+type profileSelection struct {
+	profile string
+	tags    []string
+}
+
+// Requests carry their connection's selection to the session hooks under these keys:
+// SSE streams under streamSelectionKey, and Streamable HTTP messages, one of which
+// initializes the session, under messageSelectionKey
+type (
+	streamSelectionKey  struct{}
+	messageSelectionKey struct{}
+)
+
+// sessionProfiles holds the selection of each session, keyed by session ID. It's
+// recorded when the session is created, so later requests can't change it through
+// their own URL.
+type sessionProfiles struct {
+	mu         sync.Mutex
+	selections map[string]profileSelection
+}
+
+func (p *sessionProfiles) set(sessionID string, selection profileSelection) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.selections == nil {
+		p.selections = make(map[string]profileSelection)
+	}
+	p.selections[sessionID] = selection
+}
+
+func (p *sessionProfiles) get(sessionID string) profileSelection {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.selections[sessionID]
+}
+
+func (p *sessionProfiles) remove(sessionID string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.selections, sessionID)
+}
+
+// addHooks records the selection of each session as it's created: SSE sessions when
+// their stream opens, and Streamable HTTP sessions when they initialize. SSE sessions
+// are forgotten when their stream closes.
+func (p *sessionProfiles) addHooks(hooks *server.Hooks) {
+	hooks.AddOnRegisterSession(func(ctx context.Context, session server.ClientSession) {
+		if selection, ok := ctx.Value(streamSelectionKey{}).(profileSelection); ok {
+			p.set(session.SessionID(), selection)
+		}
+	})
+	hooks.AddOnUnregisterSession(func(ctx context.Context, session server.ClientSession) {
+		if _, ok := ctx.Value(streamSelectionKey{}).(profileSelection); ok {
+			p.remove(session.SessionID())
+		}
+	})
+	hooks.AddBeforeInitialize(func(ctx context.Context, id any, message *mcp.InitializeRequest) {
+		selection, ok := ctx.Value(messageSelectionKey{}).(profileSelection)
+		if session := server.ClientSessionFromContext(ctx); ok && session != nil && session.SessionID() != "" {
+			p.set(session.SessionID(), selection)
+		}
+	})
+}
+
+// profileContextFunc returns a transport context function that limits each request's
+// session to the profile its connection asked for with ?profile=, and further to the
+// tags it asked for with ?tags=, then applies next. The selection is looked up by
+// session; sessions without one see the default profile.
+func (s *Proxy) profileContextFunc(next func(context.Context, *http.Request) context.Context) func(context.Context, *http.Request) context.Context {
+	return func(ctx context.Context, r *http.Request) context.Context {
+		var selection profileSelection
+		if session := server.ClientSessionFromContext(ctx); session != nil {
+			selection = s.sessionProfiles.get(session.SessionID())
+		}
+		ctx = s.withProfile(ctx, selection.profile)
+		if len(selection.tags) > 0 {
+			ctx = withProfileTags(ctx, selection.tags)
+		}
+		return next(ctx, r)
+	}
+}
+
+// sseProfile refuses SSE streams that ask for a profile the config doesn't define, and
+// passes the stream's selection on to the session hooks
+func (s *Proxy) sseProfile(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		selection, ok := s.requestedProfile(w, r)
+		if !ok {
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), streamSelectionKey{}, selection)))
+	})
+}
+
+// streamableProfile refuses Streamable HTTP requests that ask for a profile the config
+// doesn't define, and passes the selection of messages on to the session hooks. Sessions
+// the client deletes are forgotten.
+func (s *Proxy) streamableProfile(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		selection, ok := s.requestedProfile(w, r)
+		if !ok {
+			return
+		}
+		switch r.Method {
+		case http.MethodPost:
+			r = r.WithContext(context.WithValue(r.Context(), messageSelectionKey{}, selection))
+		case http.MethodDelete:
+			s.sessionProfiles.remove(r.Header.Get(sessionIDHeader))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// requestedProfile returns the profile and tags a request asks for. It refuses the
+// request and reports false if the config doesn't define the profile.
+func (s *Proxy) requestedProfile(w http.ResponseWriter, r *http.Request) (profileSelection, bool) {
+	name := r.URL.Query().Get(profileQueryParam)
+	if name != "" {
+		if _, ok := s.profileTags(name); !ok {
+			http.Error(w, fmt.Sprintf("Unknown profile '%s'", name), http.StatusBadRequest)
+			return profileSelection{}, false
+		}
+	}
+	return profileSelection{profile: name, tags: requestedTags(r)}, true
+}
+
+// capabilityTags holds the tags of the tools, prompts, resources and resource templates
+// built from config endpoints, keyed by name or URI. Capabilities added otherwise have
+// no entry and are visible to every session.
+type capabilityTags struct {
+	tools     map[string][]string
+	prompts   map[string][]string
+	resources map[string][]string
+	templates map[string][]string
+}
+
+// tagCapabilities records an endpoint's tags for the capabilities set up for it, which
+// start at the given offsets, and refuses calls to them from sessions that can't see them
+func (s *Proxy) tagCapabilities(endpoint *Endpoint, tools, prompts, resources, templates int) {
+	if s.capabilityTags.tools == nil {
+		s.capabilityTags = capabilityTags{
+			tools:     make(map[string][]string),
+			prompts:   make(map[string][]string),
+			resources: make(map[string][]string),
+			templates: make(map[string][]string),
+		}
+	}
+	tags := endpoint.Tags
+
+	for i := tools; i < len(s.tools); i++ {
+		tool := &s.tools[i]
+		s.capabilityTags.tools[tool.Tool.Name] = tags
+		handler := tool.Handler
+		tool.Handler = func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			if !visibleTo(ctx, tags) {
+				return nil, fmt.Errorf("%w: %s", server.ErrToolNotFound, request.Params.Name)
+			}
+			return handler(ctx, request)
+		}
+	}
+
+	for i := prompts; i < len(s.prompts); i++ {
+		prompt := &s.prompts[i]
+		s.capabilityTags.prompts[prompt.Prompt.Name] = tags
+		handler := prompt.Handler
+		prompt.Handler = func(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+			if !visibleTo(ctx, tags) {
+				return nil, fmt.Errorf("%w: %s", server.ErrPromptNotFound, request.Params.Name)
+			}
+			return handler(ctx, request)
+		}
+	}
+
+	for i := resources; i < len(s.resources); i++ {
+		resource := &s.resources[i]
+		s.capabilityTags.resources[resource.Resource.URI] = tags
+		handler := resource.Handler
+		resource.Handler = func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+			if !visibleTo(ctx, tags) {
+				return nil, fmt.Errorf("%w: %s", server.ErrResourceNotFound, request.Params.URI)
+			}
+			return handler(ctx, request)
+		}
+	}
+
+	for i := templates; i < len(s.resourceTemplates); i++ {
+		rt := &s.resourceTemplates[i]
+		s.capabilityTags.templates[rt.Template.URITemplate.Raw()] = tags
+		handler := rt.Handler
+		rt.Handler = func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+			if !visibleTo(ctx, tags) {
+				return nil, fmt.Errorf("%w: %s", server.ErrResourceNotFound, request.Params.URI)
+			}
+			return handler(ctx, request)
+		}
+	}
+}
+
+// visibleCapability reports whether the capability with key in tags is visible to the
+// session of ctx
+func (s *Proxy) visibleCapability(ctx context.Context, tags func(capabilityTags) map[string][]string, key string) bool {
+	s.mu.Lock()
+	capabilityTags, ok := tags(s.capabilityTags)[key]
+	s.mu.Unlock()
+	return !ok || visibleTo(ctx, capabilityTags)
+}
+
+// filterTools is a tool filter listing only the tools the session's profile includes
+func (s *Proxy) filterTools(ctx context.Context, tools []mcp.Tool) []mcp.Tool {
+	return slices.DeleteFunc(tools, func(tool mcp.Tool) bool {
+		return !s.visibleCapability(ctx, func(t capabilityTags) map[string][]string { return t.tools }, tool.Name)
+	})
+}
+
+// addProfileHooks filters prompt, resource and resource template listings by the
+// session's profile
+func (s *Proxy) addProfileHooks(hooks *server.Hooks) {
+	hooks.AddAfterListPrompts(func(ctx context.Context, id any, message *mcp.ListPromptsRequest, result *mcp.ListPromptsResult) {
+		result.Prompts = slices.DeleteFunc(result.Prompts, func(prompt mcp.Prompt) bool {
+			return !s.visibleCapability(ctx, func(t capabilityTags) map[string][]string { return t.prompts }, prompt.Name)
+		})
+	})
+	hooks.AddAfterListResources(func(ctx context.Context, id any, message *mcp.ListResourcesRequest, result *mcp.ListResourcesResult) {
+		result.Resources = slices.DeleteFunc(result.Resources, func(resource mcp.Resource) bool {
+			return !s.visibleCapability(ctx, func(t capabilityTags) map[string][]string { return t.resources }, resource.URI)
+		})
+	})
+	hooks.AddAfterListResourceTemplates(func(ctx context.Context, id any, message *mcp.ListResourceTemplatesRequest, result *mcp.ListResourceTemplatesResult) {
+		result.ResourceTemplates = slices.DeleteFunc(result.ResourceTemplates, func(rt mcp.ResourceTemplate) bool {
+			return !s.visibleCapability(ctx, func(t capabilityTags) map[string][]string { return t.templates }, rt.URITemplate.Raw())
+		})
+	})
+}
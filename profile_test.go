@@ -0,0 +1,152 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const profilesConfig = `
+mcp:
+  profiles:
+    orders: [orders]
+backends:
+  - base_url: %s
+    endpoints:
+      - name: get_order
+        capability: tool
+        mode: client
+        method: GET
+        path: /orders/1
+        tags: [orders]
+      - name: get_ticket
+        capability: tool
+        mode: client
+        method: GET
+        path: /tickets/1
+        tags: [support]
+`
+
+// startProfileProxy serves profilesConfig over transport and returns the proxy's address
+func startProfileProxy(t *testing.T, transport string) string {
+	t.Helper()
+
+	backend := newCallsBackend(t)
+	cfg, err := ParseConfigFromBytes([]byte(fmt.Sprintf(profilesConfig, backend.URL)))
+	if err != nil {
+		t.Fatalf("failed to parse config: %v", err)
+	}
+
+	addr := freeAddr(t)
+	s, err := NewServerFromConfig(cfg,
+		WithLogger(discardLogger()),
+		WithAddr(addr),
+		WithBaseURL("http://"+addr),
+		WithTransport(transport),
+	)
+	if err != nil {
+		t.Fatalf("failed to create proxy: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(func() {
+		cancel()
+		s.Close()
+	})
+	if err := s.Start(ctx); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	return addr
+}
+
+// postMessage sends a JSON-RPC message to the Streamable HTTP endpoint and returns the
+// response and the session it belongs to
+func postMessage(t *testing.T, url, sessionID, message string) (map[string]any, string) {
+	t.Helper()
+
+	req, err := http.NewRequest(http.MethodPost, url, strings.NewReader(message))
+	if err != nil {
+		t.Fatalf("failed to create request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if sessionID != "" {
+		req.Header.Set(sessionIDHeader, sessionID)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("POST %s failed: %v", url, err)
+	}
+	defer resp.Body.Close()
+
+	var response map[string]any
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	return response, resp.Header.Get(sessionIDHeader)
+}
+
+func TestStreamableSessionKeepsProfile(t *testing.T) {
+	addr := startProfileProxy(t, TransportStreamableHTTP)
+
+	initialize := `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-03-26","clientInfo":{"name":"test","version":"1.0.0"},"capabilities":{}}}`
+	_, sessionID := postMessage(t, "http://"+addr+"/mcp?profile=orders", "", initialize)
+	if sessionID == "" {
+		t.Fatal("initialize returned no session ID")
+	}
+
+	// Later requests drop or change the profile in their URL
+	for _, url := range []string{"/mcp", "/mcp?profile=", "/mcp?tags=support"} {
+		response, _ := postMessage(t, "http://"+addr+url, sessionID, `{"jsonrpc":"2.0","id":2,"method":"tools/list"}`)
+		result, _ := response["result"].(map[string]any)
+		tools, _ := result["tools"].([]any)
+		var names []string
+		for _, tool := range tools {
+			names = append(names, tool.(map[string]any)["name"].(string))
+		}
+		if !slices.Equal(names, []string{"get_order"}) {
+			t.Errorf("tools/list on %s listed %v, want the orders profile's [get_order]", url, names)
+		}
+
+		response, _ = postMessage(t, "http://"+addr+url, sessionID, `{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"get_ticket","arguments":{}}}`)
+		if _, ok := response["error"]; !ok {
+			t.Errorf("tools/call of get_ticket on %s returned %v, want an error", url, response)
+		}
+	}
+}
+
+func TestSSESessionKeepsProfile(t *testing.T) {
+	addr := startProfileProxy(t, TransportSSE)
+
+	c, err := client.NewSSEMCPClient("http://" + addr + "/sse?profile=orders")
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	defer c.Close()
+
+	ctx := context.Background()
+	if err := c.Start(ctx); err != nil {
+		t.Fatalf("failed to start client: %v", err)
+	}
+	var initRequest mcp.InitializeRequest
+	initRequest.Params.ProtocolVersion = mcp.LATEST_PROTOCOL_VERSION
+	initRequest.Params.ClientInfo = mcp.Implementation{Name: "test", Version: "1.0.0"}
+	if _, err := c.Initialize(ctx, initRequest); err != nil {
+		t.Fatalf("failed to initialize: %v", err)
+	}
+
+	result, err := c.ListTools(ctx, mcp.ListToolsRequest{})
+	if err != nil {
+		t.Fatalf("ListTools failed: %v", err)
+	}
+	if len(result.Tools) != 1 || result.Tools[0].Name != "get_order" {
+		t.Errorf("ListTools returned %v, want the orders profile's get_order", result.Tools)
+	}
+}
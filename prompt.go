@@ -8,14 +8,14 @@ import (
 	"log/slog"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/mark3labs/mcp-go/mcp"
 )
 
 // HTTPPromptHandler handles prompt requests by making HTTP requests
 type HTTPPromptHandler struct {
-	endpoint      *Endpoint
-	backend       *Backend
+	requestBuilder
 	logger        *slog.Logger
 	clientManager *ClientManager
 }
@@ -23,10 +23,9 @@ type HTTPPromptHandler struct {
 // NewHTTPPromptHandler creates a new HTTP prompt handler
 func NewHTTPPromptHandler(endpoint *Endpoint, backend *Backend, logger *slog.Logger, clientManager *ClientManager) *HTTPPromptHandler {
 	return &HTTPPromptHandler{
-		endpoint:      endpoint,
-		backend:       backend,
-		logger:        logger,
-		clientManager: clientManager,
+		requestBuilder: requestBuilder{endpoint: endpoint, backend: backend},
+		logger:         logger,
+		clientManager:  clientManager,
 	}
 }
 
@@ -35,14 +34,15 @@ func (h *HTTPPromptHandler) CreateMCPPrompt() mcp.Prompt {
 	var promptOptions []mcp.PromptOption
 	promptOptions = append(promptOptions, mcp.WithPromptDescription(h.endpoint.Description))
 
-	// Add arguments based on endpoint configuration
-	for _, param := range h.endpoint.BodyParams {
+	// Add arguments based on endpoint configuration; constant parameters are filled in
+	// by the proxy, so the LLM isn't asked for them
+	for _, param := range dynamicParams(h.endpoint.BodyParams) {
 		promptOptions = append(promptOptions, h.createArgumentOption(param))
 	}
-	for _, param := range h.endpoint.QueryParameters {
+	for _, param := range dynamicParams(h.endpoint.QueryParameters) {
 		promptOptions = append(promptOptions, h.createArgumentOption(param))
 	}
-	for _, param := range h.endpoint.PathParameters {
+	for _, param := range dynamicParams(h.endpoint.PathParameters) {
 		promptOptions = append(promptOptions, h.createArgumentOption(param))
 	}
 
@@ -51,8 +51,14 @@ func (h *HTTPPromptHandler) CreateMCPPrompt() mcp.Prompt {
 
 // createArgumentOption creates an argument option for the MCP prompt
 func (h *HTTPPromptHandler) createArgumentOption(param *Param) mcp.PromptOption {
+	description := param.Description
+	if len(param.Enum) > 0 {
+		// Prompt arguments have no schema, so advertise allowed values in the description
+		description = fmt.Sprintf("%s (one of: %s)", description, strings.Join(param.Enum, ", "))
+	}
+
 	options := []mcp.ArgumentOption{
-		mcp.ArgumentDescription(param.Description),
+		mcp.ArgumentDescription(description),
 	}
 
 	if param.Required {
@@ -62,8 +68,19 @@ func (h *HTTPPromptHandler) createArgumentOption(param *Param) mcp.PromptOption
 	return mcp.WithArgument(param.Identifier, options...)
 }
 
+// forRequest returns a copy of the handler that logs with a call's request ID
+func (h *HTTPPromptHandler) forRequest(requestID string) *HTTPPromptHandler {
+	call := *h
+	call.logger = h.logger.With("request_id", requestID)
+	return &call
+}
+
 // Handler handles prompt requests
 func (h *HTTPPromptHandler) Handler(ctx context.Context, req mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	// Tag logs and backend requests with the call's request ID
+	ctx, requestID := startRequest(ctx, nil)
+	h = h.forRequest(requestID)
+
 	// Get arguments from the request - convert from map[string]string to map[string]any
 	arguments := make(map[string]any)
 	if req.Params.Arguments != nil {
@@ -72,6 +89,22 @@ func (h *HTTPPromptHandler) Handler(ctx context.Context, req mcp.GetPromptReques
 		}
 	}
 
+	// Validate and coerce arguments before rendering or making any request
+	arguments, err := validateArguments(h.endpoint, arguments)
+	if err != nil {
+		return nil, err
+	}
+
+	// Render inline prompt templates without contacting a backend
+	if h.endpoint.HasInlineMessages() {
+		return h.renderInlineMessages(arguments), nil
+	}
+
+	// Serve the configured mock instead of calling the backend
+	if h.endpoint.MockResponse != nil {
+		return h.handleResponse(newMockHTTPResponse(h.endpoint.MockResponse))
+	}
+
 	// Build the URL with path parameters
 	url, err := h.buildURL(arguments)
 	if err != nil {
@@ -79,7 +112,10 @@ func (h *HTTPPromptHandler) Handler(ctx context.Context, req mcp.GetPromptReques
 	}
 
 	// Build query parameters
-	queryParams := h.buildQueryParams(arguments)
+	queryParams, err := h.buildQueryParams(arguments)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build query parameters: %w", err)
+	}
 	if len(queryParams) > 0 {
 		url += "?" + queryParams
 	}
@@ -89,6 +125,16 @@ func (h *HTTPPromptHandler) Handler(ctx context.Context, req mcp.GetPromptReques
 	if err != nil {
 		return nil, fmt.Errorf("failed to build request body: %w", err)
 	}
+	if err := checkRequestSize(h.endpoint, body); err != nil {
+		return nil, fmt.Errorf("request body too large: %w", err)
+	}
+
+	// Bound the request, including reading the response, by the endpoint's response timeout
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(h.endpoint.ResponseTimeout))
+	defer cancel()
+
+	// Apply the endpoint's retry, limit and idempotency settings to the call
+	ctx, _ = h.callContext(ctx)
 
 	// Create HTTP request
 	httpReq, err := http.NewRequestWithContext(ctx, string(h.endpoint.Method), url, bytes.NewReader(body))
@@ -106,7 +152,7 @@ func (h *HTTPPromptHandler) Handler(ctx context.Context, req mcp.GetPromptReques
 	)
 
 	// Make the HTTP request using client manager
-	resp, err := h.clientManager.DoRequest(ctx, httpReq, h.endpoint.Name)
+	resp, err := h.clientManager.DoHedgedRequest(ctx, httpReq, h.endpoint.Name, time.Duration(h.endpoint.HedgeAfter))
 	if err != nil {
 		return nil, fmt.Errorf("HTTP request failed: %w", err)
 	}
@@ -116,132 +162,49 @@ func (h *HTTPPromptHandler) Handler(ctx context.Context, req mcp.GetPromptReques
 	return h.handleResponse(resp)
 }
 
-// buildURL constructs the full URL with path parameters substituted
-func (h *HTTPPromptHandler) buildURL(arguments map[string]any) (string, error) {
-	url := h.backend.BaseURL + h.endpoint.Path
-
-	// Replace path parameters
-	for _, param := range h.endpoint.PathParameters {
-		var value any
-		var exists bool
-
-		if param.ValueType == CONSTANT {
-			// Use the predefined value for constant parameters
-			value = param.Value
-			exists = param.Value != ""
-		} else {
-			// Use the value from arguments for dynamic parameters
-			value, exists = arguments[param.Identifier]
-		}
-
-		if !exists && param.Required {
-			return "", fmt.Errorf("required path parameter '%s' not provided", param.Identifier)
-		}
-		if exists {
-			placeholder := fmt.Sprintf("{%s}", param.Identifier)
-			url = strings.ReplaceAll(url, placeholder, fmt.Sprintf("%v", value))
-		}
-	}
-
-	return url, nil
-}
-
-// buildQueryParams constructs query parameters from arguments
-func (h *HTTPPromptHandler) buildQueryParams(arguments map[string]any) string {
-	var params []string
-
-	for _, param := range h.endpoint.QueryParameters {
-		var value any
-		var exists bool
-
-		if param.ValueType == CONSTANT {
-			// Use the predefined value for constant parameters
-			value = param.Value
-			exists = param.Value != ""
-		} else {
-			// Use the value from arguments for dynamic parameters
-			value, exists = arguments[param.Identifier]
-		}
-
-		if exists {
-			params = append(params, fmt.Sprintf("%s=%v", param.Identifier, value))
-		}
-	}
-
-	return strings.Join(params, "&")
-}
-
-// buildRequestBody constructs the JSON request body
-func (h *HTTPPromptHandler) buildRequestBody(arguments map[string]any) ([]byte, error) {
-	if len(h.endpoint.BodyParams) == 0 {
-		return nil, nil
+// renderInlineMessages builds the prompt from the endpoint's configured messages,
+// substituting {identifier} placeholders with the supplied arguments
+func (h *HTTPPromptHandler) renderInlineMessages(arguments map[string]any) *mcp.GetPromptResult {
+	result := &mcp.GetPromptResult{
+		Description: h.endpoint.Description,
+		Messages:    make([]mcp.PromptMessage, 0, len(h.endpoint.Messages)),
 	}
 
-	body := make(map[string]any)
-	for _, param := range h.endpoint.BodyParams {
-		var value any
-		var exists bool
-
-		if param.ValueType == CONSTANT {
-			// Use the predefined value for constant parameters
-			value = param.Value
-			exists = param.Value != ""
-		} else {
-			// Use the value from arguments for dynamic parameters
-			value, exists = arguments[param.Identifier]
-		}
-
-		if exists {
-			body[param.Identifier] = value
-		} else if param.Required {
-			return nil, fmt.Errorf("required body parameter '%s' not provided", param.Identifier)
+	for _, message := range h.endpoint.Messages {
+		role := mcp.RoleUser
+		if message.Role == string(mcp.RoleAssistant) {
+			role = mcp.RoleAssistant
 		}
-	}
-
-	if len(body) == 0 {
-		return nil, nil
-	}
 
-	return json.Marshal(body)
-}
-
-// addHeaders adds headers to the HTTP request
-func (h *HTTPPromptHandler) addHeaders(req *http.Request, arguments map[string]any) {
-	// Add default headers from backend
-	for _, header := range h.backend.DefaultHeaders {
-		req.Header.Set(header.Name, header.Value)
+		result.Messages = append(result.Messages, mcp.PromptMessage{
+			Role: role,
+			Content: mcp.TextContent{
+				Type: "text",
+				Text: expandPlaceholders(message.Content, arguments),
+			},
+		})
 	}
 
-	// Add endpoint-specific headers
-	for _, header := range h.endpoint.Headers {
-		if header.Type == CONSTANT {
-			req.Header.Set(header.Name, header.Value)
-		} else if header.Type == DYNAMIC {
-			// For dynamic headers, try to get value from arguments
-			if value, exists := arguments[header.Name]; exists {
-				req.Header.Set(header.Name, fmt.Sprintf("%v", value))
-			}
-		}
-	}
+	h.logger.Debug("Rendered inline prompt",
+		"prompt", h.endpoint.Name,
+		"messages", len(result.Messages),
+	)
 
-	// Set content type for JSON if we have body parameters
-	if len(h.endpoint.BodyParams) > 0 {
-		req.Header.Set("Content-Type", "application/json")
-	}
+	return result
 }
 
 // handleResponse processes the HTTP response and returns MCP prompt result
 func (h *HTTPPromptHandler) handleResponse(resp *http.Response) (*mcp.GetPromptResult, error) {
 	// Read response body
 	var responseBody bytes.Buffer
-	if _, err := responseBody.ReadFrom(resp.Body); err != nil {
+	if _, err := responseBody.ReadFrom(limitBody(resp.Body, h.endpoint.MaxResponseBytes)); err != nil {
 		return nil, fmt.Errorf("failed to read response body: %w", err)
 	}
 
 	responseText := responseBody.String()
 
 	// Check if the request was successful
-	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+	if h.endpoint.isSuccessStatus(resp.StatusCode) {
 		h.logger.Debug("Prompt request successful",
 			"prompt", h.endpoint.Name,
 			"status", resp.StatusCode,
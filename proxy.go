@@ -6,9 +6,13 @@ import (
 	"fmt"
 	"io"
 	"log/slog"
+	"net"
 	"net/http"
+	"net/url"
 	"os"
+	"slices"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/mark3labs/mcp-go/client"
@@ -49,38 +53,242 @@ func WithLogger(logger *slog.Logger) Option {
 	}
 }
 
+// WithVersion sets the proxy build version reported to MCP clients
+func WithVersion(version string) Option {
+	return func(s *Proxy) {
+		s.config.Version = version
+	}
+}
+
+// WithSSEPath sets the path the SSE endpoint is mounted on (default "/sse")
+func WithSSEPath(path string) Option {
+	return func(s *Proxy) {
+		s.config.SSEPath = path
+	}
+}
+
+// WithMessagePath sets the path the SSE message endpoint is mounted on (default "/message").
+// Clients are told to post messages to the base URL joined with this path, so when a
+// reverse proxy serves the proxy under a prefix, include the prefix in WithBaseURL and
+// keep the paths relative to the proxy itself.
+func WithMessagePath(path string) Option {
+	return func(s *Proxy) {
+		s.config.MessagePath = path
+	}
+}
+
+// WithMockMode makes every endpoint return its mock_response, or a generic mock
+// when none is configured, instead of calling backends
+func WithMockMode(enabled bool) Option {
+	return func(s *Proxy) {
+		s.config.MockMode = enabled
+	}
+}
+
+// WithRecord records backend responses as cassette files in dir, for later replay.
+// Secret headers (Authorization, cookies, API keys and any passed to WithRedactHeaders)
+// are redacted from the saved cassettes.
+func WithRecord(dir string) Option {
+	return func(s *Proxy) {
+		s.config.RecordDir = dir
+	}
+}
+
+// WithReplay serves backend responses from cassettes recorded with WithRecord instead
+// of calling backends. Requests without a recorded cassette fail.
+func WithReplay(dir string) Option {
+	return func(s *Proxy) {
+		s.config.ReplayDir = dir
+	}
+}
+
+// WithRedactHeaders adds headers whose values are redacted from recorded cassettes
+func WithRedactHeaders(headers ...string) Option {
+	return func(s *Proxy) {
+		s.config.RedactHeaders = append(s.config.RedactHeaders, headers...)
+	}
+}
+
+// WithRedactLogKeys adds attribute, argument and query parameter names whose values
+// are masked in log output. Secret headers and params marked secret are always masked.
+func WithRedactLogKeys(keys ...string) Option {
+	return func(s *Proxy) {
+		s.config.RedactLogKeys = append(s.config.RedactLogKeys, keys...)
+	}
+}
+
+// WithTransport sets the MCP transport served by the proxy, either "sse" (default)
+// or "streamable-http"
+func WithTransport(transport string) Option {
+	return func(s *Proxy) {
+		s.config.Transport = transport
+	}
+}
+
+// WithErrorLogInterval sets how often repeated handler errors are logged per endpoint.
+// The first error is always logged; later ones are sampled once per interval with a
+// count of suppressed records. Zero disables sampling.
+func WithErrorLogInterval(interval time.Duration) Option {
+	return func(s *Proxy) {
+		s.config.ErrorLogInterval = interval
+	}
+}
+
+// WithToolAPI serves POST /api/tools/{name}, which calls a tool with a JSON object of
+// arguments over plain HTTP, for scripts and smoke tests. The API is unauthenticated,
+// so it is off by default; only enable it where the listener isn't publicly reachable.
+func WithToolAPI(enabled bool) Option {
+	return func(s *Proxy) {
+		s.config.ToolAPI = enabled
+	}
+}
+
+// WithAdminAddr serves the config API, web UI and tool API on a second listener at addr,
+// e.g. "127.0.0.1:8889", leaving only the MCP endpoints on the main address. This lets
+// the admin surface stay private while MCP traffic is exposed. Default: one listener
+func WithAdminAddr(addr string) Option {
+	return func(s *Proxy) {
+		s.config.AdminAddr = addr
+	}
+}
+
+// WithAllowedOrigins sets the origins whose pages may call the /api/ endpoints, e.g.
+// "https://admin.example.com". The request's Origin is echoed back when it is allowed and
+// other cross-origin requests are refused. "*" allows every origin. Default: localhost
+// and loopback origins on any port.
+func WithAllowedOrigins(origins ...string) Option {
+	return func(s *Proxy) {
+		s.config.AllowedOrigins = append(s.config.AllowedOrigins, origins...)
+	}
+}
+
+// WithStrictSetup controls what happens when an endpoint can't be set up, e.g. because
+// its gRPC method can't be resolved. When strict (the default), creating the server
+// fails. Otherwise the endpoint is logged and skipped so the others are still served;
+// SetupErrors reports the skipped endpoints.
+func WithStrictSetup(strict bool) Option {
+	return func(s *Proxy) {
+		s.config.StrictSetup = strict
+	}
+}
+
+// WithHooks adds MCP server hooks, e.g. to export metrics or audit tool calls. They run
+// after the proxy's own logging and counting hooks, which are kept.
+func WithHooks(hooks ...*server.Hooks) Option {
+	return func(s *Proxy) {
+		s.config.Hooks = append(s.config.Hooks, hooks...)
+	}
+}
+
+// WithInternalClient controls whether Start connects an MCP client to the proxy's own
+// server over HTTP, as returned by Client. The call helpers such as ListTools don't
+// need it; they call the server in process. Without it Start
+// returns as soon as the HTTP server is listening, with no self-connection; this suits
+// networks where the proxy can't reach its own address. Default: true
+func WithInternalClient(enabled bool) Option {
+	return func(s *Proxy) {
+		s.config.InternalClient = enabled
+	}
+}
+
+// Supported MCP transports
+const (
+	TransportSSE            = "sse"
+	TransportStreamableHTTP = "streamable-http"
+)
+
 // config holds server configuration
 type config struct {
-	Name    string
-	Addr    string
-	BaseURL string
+	Name                 string
+	Version              string
+	Addr                 string
+	BaseURL              string
+	Transport            string
+	SSEPath              string
+	MessagePath          string
+	MockMode             bool
+	RecordDir            string
+	ReplayDir            string
+	RedactHeaders        []string
+	RedactLogKeys        []string
+	ErrorLogInterval     time.Duration
+	ToolAPI              bool
+	AllowedOrigins       []string
+	AdminAddr            string
+	StrictSetup          bool
+	Hooks                []*server.Hooks
+	InternalClient       bool
+	RequestTransformers  map[string]RequestTransformer
+	ResponseTransformers map[string]ResponseTransformer
+}
+
+// serverResourceTemplate combines a resource template with its handler function.
+type serverResourceTemplate struct {
+	Template mcp.ResourceTemplate
+	Handler  server.ResourceTemplateHandlerFunc
 }
 
 // Proxy encapsulates an MCP server and manages resources like pipes and context.
 type Proxy struct {
 	config        config
 	logger        *slog.Logger
+	maskedLogger  *slog.Logger
+	handlerLogger *slog.Logger
 	clientManager *ClientManager
 
-	tools     []server.ServerTool
-	prompts   []server.ServerPrompt
-	resources []server.ServerResource
+	tools             []server.ServerTool
+	prompts           []server.ServerPrompt
+	resources         []server.ServerResource
+	resourceTemplates []serverResourceTemplate
+	pollers           []*resourcePoller
+	grpcBackends      map[*Backend]*grpcBackend
+
+	// mu guards the served MCP server and pollers against concurrent reloads
+	mu          sync.Mutex
+	editMu      sync.Mutex // serializes Reload, AddEndpoint and RemoveEndpoint
+	mcpServer   *server.MCPServer
+	serveCtx    context.Context
+	stopPollers context.CancelFunc
 
 	transport transport.Interface
 	client    *client.Client
+	inProcess *client.Client // Calls the MCP server directly, for ListTools and the other helpers; guarded by mu
 
 	wg         sync.WaitGroup
-	configFile string  // Path to the configuration file
-	mcpConfig  *Config // Current configuration
+	configFile string       // Path to the configuration file
+	mcpConfig  *Config      // Current configuration
+	configHash atomic.Value // Short hash of the current configuration
+
+	// setupErrors holds the endpoints skipped because they failed to set up
+	setupErrors []error
+
+	// capabilityTags holds the endpoint tags profiles filter by; guarded by mu once serving
+	capabilityTags capabilityTags
+
+	// toolStats counts tool calls and errors for /api/status
+	toolStats toolCallStats
+
+	// subscriptions tracks the sessions subscribed to each resource, for the pollers
+	subscriptions resourceSubscriptions
+
+	// sessionProfiles holds the profile and tags each session connected with
+	sessionProfiles sessionProfiles
 }
 
 // NewServer creates a new MCP server with the given options.
 func NewServer(opts ...Option) (*Proxy, error) {
 	server := &Proxy{
 		config: config{
-			Name:    "mpc-proxy",
-			Addr:    ":8888",
-			BaseURL: "",
+			Name:             "mpc-proxy",
+			Version:          "dev",
+			Addr:             ":8888",
+			BaseURL:          "",
+			Transport:        TransportSSE,
+			SSEPath:          "/sse",
+			MessagePath:      "/message",
+			ErrorLogInterval: 10 * time.Second,
+			StrictSetup:      true,
+			InternalClient:   true,
 		},
 		logger:        slog.Default(),
 		clientManager: NewClientManager(),
@@ -98,9 +306,16 @@ func NewServer(opts ...Option) (*Proxy, error) {
 func NewServerFromConfig(cfg *Config, opts ...Option) (*Proxy, error) {
 	server := &Proxy{
 		config: config{
-			Name:    cfg.MCP.ServerName,
-			Addr:    ":8888",
-			BaseURL: "",
+			Name:             cfg.MCP.ServerName,
+			Version:          "dev",
+			Addr:             ":8888",
+			BaseURL:          "",
+			Transport:        TransportSSE,
+			SSEPath:          "/sse",
+			MessagePath:      "/message",
+			ErrorLogInterval: 10 * time.Second,
+			StrictSetup:      true,
+			InternalClient:   true,
 		},
 		logger:        slog.Default(),
 		clientManager: NewClientManager(),
@@ -112,6 +327,8 @@ func NewServerFromConfig(cfg *Config, opts ...Option) (*Proxy, error) {
 		opt(server)
 	}
 
+	server.configHash.Store(hashConfig(cfg))
+
 	// Setup endpoints from configuration
 	if err := server.setupEndpointsFromConfig(cfg); err != nil {
 		return nil, fmt.Errorf("failed to setup endpoints: %w", err)
@@ -129,9 +346,16 @@ func NewServerFromConfigFile(configFile string, opts ...Option) (*Proxy, error)
 
 	server := &Proxy{
 		config: config{
-			Name:    cfg.MCP.ServerName,
-			Addr:    ":8888",
-			BaseURL: "",
+			Name:             cfg.MCP.ServerName,
+			Version:          "dev",
+			Addr:             ":8888",
+			BaseURL:          "",
+			Transport:        TransportSSE,
+			SSEPath:          "/sse",
+			MessagePath:      "/message",
+			ErrorLogInterval: 10 * time.Second,
+			StrictSetup:      true,
+			InternalClient:   true,
 		},
 		logger:        slog.Default(),
 		clientManager: NewClientManager(),
@@ -144,6 +368,8 @@ func NewServerFromConfigFile(configFile string, opts ...Option) (*Proxy, error)
 		opt(server)
 	}
 
+	server.configHash.Store(hashConfig(cfg))
+
 	// Setup endpoints from configuration
 	if err := server.setupEndpointsFromConfig(cfg); err != nil {
 		return nil, fmt.Errorf("failed to setup endpoints: %w", err)
@@ -154,48 +380,308 @@ func NewServerFromConfigFile(configFile string, opts ...Option) (*Proxy, error)
 
 // setupEndpointsFromConfig configures MCP endpoints from the config
 func (s *Proxy) setupEndpointsFromConfig(cfg *Config) error {
+	defaults := endpointDefaults{
+		clientConfig:     DefaultClientConfig(),
+		responseTimeout:  Duration(30 * time.Second),
+		maxResponseBytes: defaultMaxBodyBytes,
+		maxRequestBytes:  defaultMaxBodyBytes,
+	}
+
+	// Build the backend client from the configured timeouts. Endpoint response
+	// timeouts bound each request, so the client itself sets no overall timeout.
+	clientConfig := defaults.clientConfig
+	clientConfig.Timeout = 0
+	clientConfig.Logger = s.getMaskedLogger()
+	if cfg.MCP != nil {
+		if cfg.MCP.DefaultTimeout > 0 {
+			defaults.responseTimeout = cfg.MCP.DefaultTimeout
+		}
+		if cfg.MCP.MaxResponseBytes > 0 {
+			defaults.maxResponseBytes = cfg.MCP.MaxResponseBytes
+		}
+		if cfg.MCP.MaxRequestBytes > 0 {
+			defaults.maxRequestBytes = cfg.MCP.MaxRequestBytes
+		}
+		if cfg.MCP.ConnectTimeout > 0 {
+			clientConfig.ConnectTimeout = time.Duration(cfg.MCP.ConnectTimeout)
+		}
+		if cfg.MCP.TLSHandshakeTimeout > 0 {
+			clientConfig.TLSHandshakeTimeout = time.Duration(cfg.MCP.TLSHandshakeTimeout)
+		}
+		clientConfig.DefaultHeaders = globalHeaders(cfg.MCP)
+		clientConfig.RequestIDHeader = requestIDHeader(cfg)
+	}
+	clientConfig.SecretHeaders = append(secretHeaderNames(cfg), s.config.RedactHeaders...)
+	s.clientManager.SetDefaultClient(clientConfig)
+
+	switch {
+	case s.config.ReplayDir != "":
+		s.clientManager.SetReplay(s.config.ReplayDir)
+	case s.config.RecordDir != "":
+		s.clientManager.SetRecord(s.config.RecordDir, slices.Concat(defaultRedactedHeaders, defaultLogRedactKeys, s.config.RedactHeaders, s.config.RedactLogKeys, secretNames(cfg)))
+	}
+
+	// Endpoints referencing a backend share its session and concurrency limit with the
+	// backend's own endpoints
+	clients := make(map[*Backend]*backendClients, len(cfg.Backends))
+	for _, backend := range cfg.Backends {
+		clients[backend] = newBackendClients(backend, defaults.clientConfig)
+
+		// State for configured hosts is kept however many hosts arguments fill in
+		if !backend.hostFromArguments() {
+			base, _ := url.Parse(backend.BaseURL)
+			s.clientManager.addFixedHost(base.Host)
+		}
+	}
+
 	for _, backend := range cfg.Backends {
-		if err := s.setupBackendEndpoints(backend); err != nil {
+		if err := s.setupBackendEndpoints(cfg, backend, clients, defaults); err != nil {
 			return fmt.Errorf("failed to setup backend endpoints: %w", err)
 		}
 	}
+
+	if len(s.setupErrors) > 0 {
+		s.logger.Warn("Some endpoints failed to set up and are not served",
+			"skipped", len(s.setupErrors),
+			"errors", s.setupErrors,
+		)
+	}
 	return nil
 }
 
-// setupBackendEndpoints sets up all endpoints for a backend
-func (s *Proxy) setupBackendEndpoints(backend *Backend) error {
-	for _, endpoint := range backend.Endpoints {
-		switch endpoint.Capability {
-		case TOOL:
-			if err := s.setupToolEndpoint(&endpoint, backend); err != nil {
-				return fmt.Errorf("failed to setup tool endpoint '%s': %w", endpoint.Name, err)
+// SetupErrors returns why each endpoint skipped during setup failed, when strict
+// setup is disabled
+func (s *Proxy) SetupErrors() []error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.setupErrors)
+}
+
+// globalHeaders returns the headers sent with every backend request
+func globalHeaders(mcpConfig *MCPConfig) http.Header {
+	headers := make(http.Header)
+	for _, header := range mcpConfig.DefaultHeaders {
+		headers.Set(header.Name, header.Value)
+	}
+	if mcpConfig.UserAgent != "" {
+		headers.Set("User-Agent", mcpConfig.UserAgent)
+	}
+	return headers
+}
+
+// endpointDefaults holds config-level settings endpoints inherit unless they override them
+type endpointDefaults struct {
+	clientConfig     *ClientConfig
+	responseTimeout  Duration
+	maxResponseBytes int64
+	maxRequestBytes  int64
+}
+
+// backendClients holds the client state shared by every endpoint calling a backend
+type backendClients struct {
+	// client serves the backend's endpoints when it needs a client of its own: one that
+	// keeps its cookies, for a cookie jar or login, follows its redirect policy, or
+	// refuses private addresses for a host filled in from arguments
+	client *HTTPClient
+
+	// limit caps concurrent requests when the backend sets max_concurrency
+	limit *concurrencyLimit
+}
+
+// newBackendClients creates the session client and concurrency limit a backend configures
+func newBackendClients(backend *Backend, clientConfig *ClientConfig) *backendClients {
+	clients := &backendClients{}
+	ownConfig := backend.Redirects != "" || backend.guardsPrivateTargets()
+	if ownConfig {
+		backendConfig := *clientConfig
+		if backend.Redirects != "" {
+			backendConfig.Redirects = backend.Redirects
+		}
+		backendConfig.BlockPrivateTargets = backend.guardsPrivateTargets()
+		clientConfig = &backendConfig
+	}
+	if backend.CookieJar || backend.Login != nil {
+		clients.client = newSessionClient(clientConfig, backend)
+	} else if ownConfig {
+		clients.client = NewHTTPClient(clientConfig)
+	}
+	if backend.MaxConcurrency > 0 {
+		clients.limit = newConcurrencyLimit(fmt.Sprintf("backend %s", backend.BaseURL), backend.MaxConcurrency)
+	}
+	return clients
+}
+
+// setupBackendEndpoints sets up all endpoints nested under a backend. Each endpoint calls
+// the backend named by its backend_ref, if any, and inherits unset timeouts and body
+// limits from defaults.
+func (s *Proxy) setupBackendEndpoints(cfg *Config, nested *Backend, clients map[*Backend]*backendClients, defaults endpointDefaults) error {
+	for _, endpoint := range nested.Endpoints {
+		backend := cfg.endpointBackend(nested, &endpoint)
+		shared := clients[backend]
+
+		if shared.client != nil {
+			s.clientManager.AddClient(endpoint.Name, shared.client)
+		}
+		var endpointLimit *concurrencyLimit
+		if endpoint.MaxConcurrency > 0 {
+			endpointLimit = newConcurrencyLimit(fmt.Sprintf("endpoint '%s'", endpoint.Name), endpoint.MaxConcurrency)
+			s.clientManager.addConcurrencyLimit(endpoint.Name, endpointLimit)
+		}
+		if shared.limit != nil {
+			s.clientManager.addConcurrencyLimit(endpoint.Name, shared.limit)
+		}
+
+		// Composite steps calling another backend use its client and limit, along with
+		// the endpoint's own limit
+		for _, step := range endpoint.Steps {
+			if step.BackendRef == "" {
+				continue
 			}
-		case RESOURCE:
-			if err := s.setupResourceEndpoint(&endpoint, backend); err != nil {
-				return fmt.Errorf("failed to setup resource endpoint '%s': %w", endpoint.Name, err)
+			stepShared := clients[cfg.findBackend(step.BackendRef)]
+			name := stepClientName(endpoint.Name, step.Name)
+			if stepShared.client != nil {
+				s.clientManager.AddClient(name, stepShared.client)
 			}
-		case PROMPT:
-			if err := s.setupPromptEndpoint(&endpoint, backend); err != nil {
-				return fmt.Errorf("failed to setup prompt endpoint '%s': %w", endpoint.Name, err)
+			if endpointLimit != nil {
+				s.clientManager.addConcurrencyLimit(name, endpointLimit)
 			}
-		default:
-			return fmt.Errorf("unknown capability '%s' for endpoint '%s'", endpoint.Capability, endpoint.Name)
+			if stepShared.limit != nil {
+				s.clientManager.addConcurrencyLimit(name, stepShared.limit)
+			}
+		}
+		if endpoint.ResponseTimeout == 0 {
+			endpoint.ResponseTimeout = defaults.responseTimeout
+		}
+		if endpoint.MaxResponseBytes == 0 {
+			endpoint.MaxResponseBytes = defaults.maxResponseBytes
 		}
+		if endpoint.MaxRequestBytes == 0 {
+			endpoint.MaxRequestBytes = defaults.maxRequestBytes
+		}
+		if s.config.MockMode && endpoint.MockResponse == nil && !endpoint.HasInlineMessages() {
+			endpoint.MockResponse = defaultMockResponse(&endpoint)
+		}
+
+		if err := s.setupEndpoint(&endpoint, backend); err != nil {
+			if s.config.StrictSetup {
+				return err
+			}
+			s.logger.Warn("Skipping endpoint that failed to set up", "endpoint", endpoint.Name, "error", err)
+			s.setupErrors = append(s.setupErrors, &endpointSetupError{endpoint: endpoint.Name, err: err})
+		}
+	}
+	return nil
+}
+
+// setupEndpoint registers an endpoint's tool, resource or prompt
+func (s *Proxy) setupEndpoint(endpoint *Endpoint, backend *Backend) error {
+	tools, prompts, resources, templates := len(s.tools), len(s.prompts), len(s.resources), len(s.resourceTemplates)
+	defer s.tagCapabilities(endpoint, tools, prompts, resources, templates)
+
+	switch endpoint.Capability {
+	case TOOL:
+		if err := s.setupToolEndpoint(endpoint, backend); err != nil {
+			return fmt.Errorf("failed to setup tool endpoint '%s': %w", endpoint.Name, err)
+		}
+	case RESOURCE:
+		if err := s.setupResourceEndpoint(endpoint, backend); err != nil {
+			return fmt.Errorf("failed to setup resource endpoint '%s': %w", endpoint.Name, err)
+		}
+	case PROMPT:
+		if err := s.setupPromptEndpoint(endpoint, backend); err != nil {
+			return fmt.Errorf("failed to setup prompt endpoint '%s': %w", endpoint.Name, err)
+		}
+	default:
+		return fmt.Errorf("unknown capability '%s' for endpoint '%s'", endpoint.Capability, endpoint.Name)
 	}
 	return nil
 }
 
+// getGRPCBackend returns the shared connection for a grpc backend, creating it on first use
+func (s *Proxy) getGRPCBackend(backend *Backend) (*grpcBackend, error) {
+	if conn, ok := s.grpcBackends[backend]; ok {
+		return conn, nil
+	}
+
+	conn, err := newGRPCBackend(backend.BaseURL)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.grpcBackends == nil {
+		s.grpcBackends = make(map[*Backend]*grpcBackend)
+	}
+	s.grpcBackends[backend] = conn
+	return conn, nil
+}
+
+// getMaskedLogger returns the logger used where requests and responses are logged,
+// which masks secret headers, params and configured keys
+func (s *Proxy) getMaskedLogger() *slog.Logger {
+	if s.maskedLogger == nil {
+		keys := slices.Concat(defaultRedactedHeaders, defaultLogRedactKeys, s.config.RedactHeaders, s.config.RedactLogKeys)
+		if s.mcpConfig != nil {
+			keys = append(keys, secretNames(s.mcpConfig)...)
+		}
+		s.maskedLogger = slog.New(newRedactingHandler(s.logger.Handler(), keys))
+	}
+	return s.maskedLogger
+}
+
+// getHandlerLogger returns the logger shared by endpoint handlers, which samples
+// repeated errors so a failing backend doesn't flood the logs
+func (s *Proxy) getHandlerLogger() *slog.Logger {
+	if s.handlerLogger == nil {
+		s.handlerLogger = slog.New(newThrottledHandler(s.getMaskedLogger().Handler(), s.config.ErrorLogInterval))
+	}
+	return s.handlerLogger
+}
+
 // setupToolEndpoint sets up a tool endpoint
 func (s *Proxy) setupToolEndpoint(endpoint *Endpoint, backend *Backend) error {
-	// Set default timeout if not specified
-	if endpoint.ResponseTimeout == 0 {
-		endpoint.ResponseTimeout = Duration(30 * time.Second)
+	transformers, err := s.endpointTransformers(endpoint)
+	if err != nil {
+		return err
 	}
 
-	handler := NewHTTPToolHandler(endpoint, backend, s.logger, s.clientManager)
-	tool := handler.CreateMCPTool()
+	switch backend.Type {
+	case GRAPHQL:
+		handler := NewGraphQLToolHandler(endpoint, backend, s.getHandlerLogger(), s.clientManager)
+		handler.transformers = transformers
+		s.AddTool(handler.CreateMCPTool(), handler.Handler)
+	case GRPC:
+		grpcBackend, err := s.getGRPCBackend(backend)
+		if err != nil {
+			return err
+		}
+		handler := NewGRPCToolHandler(endpoint, backend, s.getHandlerLogger(), s.clientManager, grpcBackend)
+		s.AddTool(handler.CreateMCPTool(), handler.Handler)
+	default:
+		if len(endpoint.Steps) > 0 {
+			handler, err := NewCompositeToolHandler(endpoint, backend, s.getHandlerLogger(), s.clientManager)
+			if err != nil {
+				return err
+			}
+			handler.transformers = transformers
+			handler.useStepBackends(s.mcpConfig)
+			s.AddTool(handler.CreateMCPTool(), handler.Handler)
+			break
+		}
 
-	s.AddTool(tool, handler.Handler)
+		if endpoint.Passthrough != nil {
+			handler, err := NewPassthroughToolHandler(endpoint, backend, s.getHandlerLogger(), s.clientManager)
+			if err != nil {
+				return err
+			}
+			handler.transformers = transformers
+			s.AddTool(handler.CreateMCPTool(), handler.Handler)
+			break
+		}
+
+		handler := NewHTTPToolHandler(endpoint, backend, s.getHandlerLogger(), s.clientManager)
+		handler.transformers = transformers
+		s.AddTool(handler.CreateMCPTool(), handler.Handler)
+	}
 
 	s.logger.Info("Added tool endpoint",
 		"name", endpoint.Name,
@@ -209,12 +695,13 @@ func (s *Proxy) setupToolEndpoint(endpoint *Endpoint, backend *Backend) error {
 
 // setupResourceEndpoint sets up a resource endpoint
 func (s *Proxy) setupResourceEndpoint(endpoint *Endpoint, backend *Backend) error {
-	// Set default timeout if not specified
-	if endpoint.ResponseTimeout == 0 {
-		endpoint.ResponseTimeout = Duration(30 * time.Second)
+	transformers, err := s.endpointTransformers(endpoint)
+	if err != nil {
+		return err
 	}
 
-	handler := NewHTTPResourceHandler(endpoint, backend, s.logger, s.clientManager)
+	handler := NewHTTPResourceHandler(endpoint, backend, s.getHandlerLogger(), s.clientManager)
+	handler.transformers = transformers
 
 	// Check if this is a dynamic resource (has path parameters)
 	if resourceTemplate := handler.CreateMCPResourceTemplate(); resourceTemplate != nil {
@@ -236,6 +723,11 @@ func (s *Proxy) setupResourceEndpoint(endpoint *Endpoint, backend *Backend) erro
 			"path", endpoint.Path,
 			"method", endpoint.Method,
 		)
+
+		// Poll for changes if requested
+		if endpoint.PollInterval > 0 {
+			s.pollers = append(s.pollers, newResourcePoller(resource.URI, time.Duration(endpoint.PollInterval), handler.Handler, &s.subscriptions, s.getHandlerLogger()))
+		}
 	}
 
 	return nil
@@ -243,12 +735,7 @@ func (s *Proxy) setupResourceEndpoint(endpoint *Endpoint, backend *Backend) erro
 
 // setupPromptEndpoint sets up a prompt endpoint
 func (s *Proxy) setupPromptEndpoint(endpoint *Endpoint, backend *Backend) error {
-	// Set default timeout if not specified
-	if endpoint.ResponseTimeout == 0 {
-		endpoint.ResponseTimeout = Duration(30 * time.Second)
-	}
-
-	handler := NewHTTPPromptHandler(endpoint, backend, s.logger, s.clientManager)
+	handler := NewHTTPPromptHandler(endpoint, backend, s.getHandlerLogger(), s.clientManager)
 	prompt := handler.CreateMCPPrompt()
 
 	s.AddPrompt(prompt, handler.Handler)
@@ -302,80 +789,92 @@ func (s *Proxy) AddResources(resources ...server.ServerResource) {
 }
 
 // AddResourceTemplate adds a resource template to an server.
+// Reads of URIs matching the template are routed to the handler by the MCP server.
+// The handler keeps the server.ResourceHandlerFunc type it had before templates were
+// served as templates; a server.ResourceTemplateHandlerFunc converts to it.
 func (s *Proxy) AddResourceTemplate(template mcp.ResourceTemplate, handler server.ResourceHandlerFunc) {
-	// Convert template to resource for the server
-	// The MCP server handles templates internally
-	var uriTemplate string
-	if template.URITemplate != nil {
-		uriTemplate = template.URITemplate.Template.Raw()
-	}
+	s.resourceTemplates = append(s.resourceTemplates, serverResourceTemplate{
+		Template: template,
+		Handler:  server.ResourceTemplateHandlerFunc(handler),
+	})
+}
 
-	resource := mcp.Resource{
-		URI:         uriTemplate,
-		Name:        template.Name,
-		Description: template.Description,
-		MIMEType:    template.MIMEType,
+// Inventory lists what a proxy serves, by name
+type Inventory struct {
+	Tools             []string
+	Resources         []string
+	ResourceTemplates []string
+	Prompts           []string
+}
+
+// Inventory returns the names of the tools, resources, resource templates and prompts
+// the proxy serves
+func (s *Proxy) Inventory() Inventory {
+	var inventory Inventory
+	for _, tool := range s.tools {
+		inventory.Tools = append(inventory.Tools, tool.Tool.Name)
 	}
-	s.resources = append(s.resources, server.ServerResource{
-		Resource: resource,
-		Handler:  handler,
-	})
+	for _, resource := range s.resources {
+		inventory.Resources = append(inventory.Resources, resource.Resource.Name)
+	}
+	for _, rt := range s.resourceTemplates {
+		inventory.ResourceTemplates = append(inventory.ResourceTemplates, rt.Template.Name)
+	}
+	for _, prompt := range s.prompts {
+		inventory.Prompts = append(inventory.Prompts, prompt.Prompt.Name)
+	}
+	return inventory
 }
 
 // configAPIHandler handles configuration API requests
 func (s *Proxy) configAPIHandler() http.Handler {
 	mux := http.NewServeMux()
 
-	// Enable CORS for all config endpoints
-	corsHandler := func(h http.HandlerFunc) http.HandlerFunc {
-		return func(w http.ResponseWriter, r *http.Request) {
-			w.Header().Set("Access-Control-Allow-Origin", "*")
-			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-			w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
-
-			if r.Method == "OPTIONS" {
-				w.WriteHeader(http.StatusOK)
-				return
-			}
-
-			h(w, r)
-		}
-	}
-
 	// /api/config - Handle GET and PUT requests for configuration
-	mux.HandleFunc("/api/config", corsHandler(func(w http.ResponseWriter, r *http.Request) {
+	mux.HandleFunc("/api/config", s.corsHandler(func(w http.ResponseWriter, r *http.Request) {
 		switch r.Method {
 		case http.MethodGet:
-			if s.mcpConfig == nil {
+			cfg := s.currentConfig()
+			if cfg == nil {
 				http.Error(w, "No configuration available", http.StatusNotFound)
 				return
 			}
 
+			// Never return secret values; clients send "***" back unchanged to keep them
+			redacted, err := RedactConfig(cfg)
+			if err != nil {
+				s.logger.Error("Failed to redact config", "error", err)
+				http.Error(w, "Internal server error", http.StatusInternalServerError)
+				return
+			}
+
 			w.Header().Set("Content-Type", "application/json")
-			if err := json.NewEncoder(w).Encode(s.mcpConfig); err != nil {
+			if err := json.NewEncoder(w).Encode(redacted); err != nil {
 				s.logger.Error("Failed to encode config", "error", err)
 				http.Error(w, "Internal server error", http.StatusInternalServerError)
 				return
 			}
 		case http.MethodPut:
+			// A config merged from several files can't be written back as one
+			if s.configFile != "" && (isConfigSet(s.configFile) || configOverlay(s.configFile) != "") {
+				http.Error(w, "Configuration is loaded from multiple files; edit the files instead", http.StatusConflict)
+				return
+			}
+
 			body, err := io.ReadAll(r.Body)
 			if err != nil {
 				http.Error(w, "Failed to read request body", http.StatusBadRequest)
 				return
 			}
 
-			fmt.Println(">?>>>>>>>>>>>>>>>>>>", string(body))
 			var newConfig Config
 			if err := json.Unmarshal(body, &newConfig); err != nil {
 				http.Error(w, fmt.Sprintf("Invalid JSON: %s", err.Error()), http.StatusBadRequest)
 				return
 			}
 
-			// Validate the new configuration
-			if err := validateParsedConfig(&newConfig); err != nil {
-				http.Error(w, fmt.Sprintf("Configuration validation failed: %v", err), http.StatusBadRequest)
-				return
-			}
+			// Keep secrets the client received redacted and sent back unchanged
+			restoreRedacted(&newConfig, s.currentConfig())
 
 			// Set defaults
 			if err := setConfigDefaults(&newConfig); err != nil {
@@ -383,12 +882,25 @@ func (s *Proxy) configAPIHandler() http.Handler {
 				return
 			}
 
+			// Validate the new configuration
+			if err := validateParsedConfig(&newConfig); err != nil {
+				http.Error(w, fmt.Sprintf("Configuration validation failed: %v", err), http.StatusBadRequest)
+				return
+			}
+
 			// Post-process the configuration
 			if err := postProcessParsedConfig(&newConfig); err != nil {
 				http.Error(w, fmt.Sprintf("Failed to post-process config: %v", err), http.StatusInternalServerError)
 				return
 			}
 
+			// Serve the new configuration; if its endpoints can't be set up, the current
+			// one is kept and nothing is saved
+			if err := s.Reload(&newConfig); err != nil {
+				http.Error(w, fmt.Sprintf("Failed to apply configuration: %v", err), http.StatusBadRequest)
+				return
+			}
+
 			// Save to file if configFile is set
 			if s.configFile != "" {
 				yamlData, err := yaml.Marshal(&newConfig)
@@ -404,9 +916,6 @@ func (s *Proxy) configAPIHandler() http.Handler {
 				}
 			}
 
-			// Update the current configuration
-			s.mcpConfig = &newConfig
-
 			s.logger.Info("Configuration updated successfully")
 
 			w.Header().Set("Content-Type", "application/json")
@@ -416,87 +925,244 @@ func (s *Proxy) configAPIHandler() http.Handler {
 		}
 	}))
 
+	// /api/config/schema - Serve the JSON Schema of the configuration file
+	mux.HandleFunc("/api/config/schema", s.corsHandler(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/schema+json")
+		w.Write(ConfigJSONSchema())
+	}))
+
+	// /api/endpoints - List configured endpoints, with secret header values redacted
+	mux.HandleFunc("/api/endpoints", s.corsHandler(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		cfg := s.currentConfig()
+		if cfg == nil {
+			http.Error(w, "No configuration available", http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(summarizeEndpoints(cfg)); err != nil {
+			s.logger.Error("Failed to encode endpoints", "error", err)
+		}
+	}))
+
+	// /api/status - Report circuit breaker state and request counters per backend host
+	mux.HandleFunc("/api/status", s.corsHandler(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		status := map[string]any{
+			"backends": s.currentClients().Status(),
+			"tools":    s.toolStats.snapshot(),
+		}
+		if err := json.NewEncoder(w).Encode(status); err != nil {
+			s.logger.Error("Failed to encode status", "error", err)
+		}
+	}))
+
+	// /api/circuit-breaker/{backend}/reset - Close a backend's circuit breaker without
+	// waiting for its reset timeout
+	mux.HandleFunc("/api/circuit-breaker/{backend}/reset", s.corsHandler(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		host := s.backendHost(r.PathValue("backend"))
+		snapshot, ok := s.currentClients().ResetCircuitBreaker(host)
+		if !ok {
+			http.Error(w, fmt.Sprintf("No circuit breaker for backend '%s'", host), http.StatusNotFound)
+			return
+		}
+
+		s.logger.Info("Circuit breaker reset", "host", host)
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(map[string]any{"host": host, "circuit_breaker": snapshot}); err != nil {
+			s.logger.Error("Failed to encode circuit breaker", "error", err)
+		}
+	}))
+
 	return mux
 }
 
+// backendHost resolves a backend named in the config to the host its breaker is kept
+// under. Other names are taken to be hosts, as reported by /api/status.
+func (s *Proxy) backendHost(name string) string {
+	if cfg := s.currentConfig(); cfg != nil {
+		if backend := cfg.findBackend(name); backend != nil {
+			if u, err := url.Parse(backend.BaseURL); err == nil {
+				return u.Host
+			}
+		}
+	}
+	return name
+}
+
 // Start starts the server in a goroutine. Make sure to defer Close() after Start().
 // When using NewServer(), the returned server is already started.
+// The listeners are bound before the internal client connects, and a Start that
+// returns an error leaves no server running.
 func (s *Proxy) Start(ctx context.Context) error {
-	s.wg.Add(1)
+	switch s.config.Transport {
+	case TransportSSE, TransportStreamableHTTP:
+	default:
+		return fmt.Errorf("unsupported transport: %s", s.config.Transport)
+	}
+
+	// Probe backends before serving; a critical backend that never responds fails startup
+	if err := s.checkBackends(ctx); err != nil {
+		return err
+	}
 
 	addr := s.config.Addr
 	baseURL := s.config.BaseURL
 	if baseURL == "" {
 		baseURL = fmt.Sprintf("http://localhost%s", addr)
 	}
-	hooks := newServerHooks(s.logger)
 
-	// Start the MCP server in a goroutine
-	go func() {
-		defer s.wg.Done()
+	// Bind before serving so the server is reachable by the time Start returns
+	listeners, err := s.listen()
+	if err != nil {
+		return err
+	}
 
-		mcpServer := server.NewMCPServer(
-			s.config.Name, "1.0.0",
-			server.WithResourceCapabilities(true, true),
-			server.WithPromptCapabilities(true),
-			server.WithToolCapabilities(true),
-			server.WithLogging(),
-			server.WithHooks(hooks),
-		)
+	// The server runs until ctx is cancelled, or until Start fails to connect its client
+	serveCtx, stopServing := context.WithCancel(ctx)
 
-		mcpServer.AddTools(s.tools...)
-		mcpServer.AddPrompts(s.prompts...)
-		mcpServer.AddResources(s.resources...)
+	// Create the MCP server before Start returns, so the call helpers work right away
+	mcpServer := s.newMCPServer(serveCtx)
 
-		sseServer := server.NewSSEServer(mcpServer,
-			server.WithBaseURL(baseURL),
-			server.WithUseFullURLForMessageEndpoint(true),
-		)
+	s.wg.Add(1)
+
+	// Start the MCP server in a goroutine
+	go func() {
+		defer s.wg.Done()
+		defer stopServing()
 
 		mux := http.NewServeMux()
 		webHandler := webHandler()
 		configAPI := s.configAPIHandler()
-		mux.Handle("/sse", sseServer.SSEHandler())
-		mux.Handle("/message", sseServer.MessageHandler())
-		mux.Handle("/api/", configAPI)
-		mux.Handle("/config/", webHandler)
-		mux.Handle("/assets/", webHandler)
 
-		httpServer := &http.Server{
+		switch s.config.Transport {
+		case TransportStreamableHTTP:
+			streamableServer := server.NewStreamableHTTPServer(mcpServer,
+				server.WithEndpointPath("/mcp"),
+				server.WithHTTPContextFunc(s.profileContextFunc(requestIDContextFunc(requestIDHeader(s.currentConfig())))),
+			)
+			mux.Handle("/mcp", s.streamableProfile(streamableServer))
+		default:
+			sseServer := server.NewSSEServer(mcpServer,
+				server.WithBaseURL(baseURL),
+				server.WithSSEEndpoint(s.config.SSEPath),
+				server.WithMessageEndpoint(s.config.MessagePath),
+				server.WithUseFullURLForMessageEndpoint(true),
+				server.WithSSEContextFunc(s.profileContextFunc(requestIDContextFunc(requestIDHeader(s.currentConfig())))),
+			)
+			mux.Handle(s.config.SSEPath, s.sseProfile(sseServer.SSEHandler()))
+			mux.Handle(s.config.MessagePath, sseServer.MessageHandler())
+		}
+
+		// The config API, web UI and tool API move to the admin listener when one is set,
+		// so the admin surface can be firewalled separately from MCP traffic
+		adminMux := mux
+		if s.config.AdminAddr != "" {
+			adminMux = http.NewServeMux()
+		}
+
+		adminMux.Handle("/api/", configAPI)
+		if s.config.ToolAPI {
+			adminMux.Handle("/api/tools/", s.corsHandler(s.toolAPIHandler(mcpServer).ServeHTTP))
+		}
+		adminMux.Handle("/config/", webHandler)
+		adminMux.Handle("/assets/", webHandler)
+
+		httpServers := []*http.Server{{
 			Addr:    addr,
 			Handler: mux,
+		}}
+		s.logger.Info("MCP server listening", "addr", addr, "transport", s.config.Transport)
+
+		if s.config.AdminAddr != "" {
+			httpServers = append(httpServers, &http.Server{
+				Addr:    s.config.AdminAddr,
+				Handler: adminMux,
+			})
+			s.logger.Info("Admin server listening", "addr", s.config.AdminAddr)
 		}
 
-		s.logger.Info("MCP SSE server listening", "addr", addr)
-
-		// Start HTTP server in a goroutine
-		go func() {
-			if err := httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-				s.logger.Error("MCP Proxy error", "error", err)
-			}
-		}()
+		// Start HTTP servers in goroutines
+		for i, httpServer := range httpServers {
+			go func() {
+				if err := httpServer.Serve(listeners[i]); err != nil && err != http.ErrServerClosed {
+					s.logger.Error("MCP Proxy error", "addr", httpServer.Addr, "error", err)
+				}
+			}()
+		}
 
-		// Wait for context cancellation to shutdown server
-		<-ctx.Done()
+		// Wait for context cancellation to shutdown servers
+		<-serveCtx.Done()
 		s.logger.Info("Shutting down HTTP server...")
 
 		// Create shutdown context with timeout
 		shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 5*time.Second)
 		defer shutdownCancel()
 
-		if err := httpServer.Shutdown(shutdownCtx); err != nil {
-			s.logger.Error("Failed to shutdown HTTP server gracefully", "error", err)
-		} else {
-			s.logger.Info("HTTP server shutdown successfully")
+		for _, httpServer := range httpServers {
+			if err := httpServer.Shutdown(shutdownCtx); err != nil {
+				s.logger.Error("Failed to shutdown HTTP server gracefully", "addr", httpServer.Addr, "error", err)
+			} else {
+				s.logger.Info("HTTP server shutdown successfully", "addr", httpServer.Addr)
+			}
 		}
 	}()
 
-	transport, err := transport.NewSSE(fmt.Sprintf("%s/sse", baseURL))
-	if err != nil {
-		return fmt.Errorf("transport.NewSSE(): %w", err)
+	if !s.config.InternalClient {
+		return nil
+	}
+
+	// A failed Start shuts its server down again, so Close doesn't wait on a server
+	// nobody can use and Start can be retried
+	if err := s.connectClient(ctx, baseURL); err != nil {
+		if s.transport != nil {
+			s.transport.Close()
+			s.transport = nil
+		}
+		s.client = nil
+		stopServing()
+		return err
 	}
 
-	s.transport = transport
+	return nil
+}
+
+// connectClient connects and initializes the internal client against the proxy's own server
+func (s *Proxy) connectClient(ctx context.Context, baseURL string) error {
+	switch s.config.Transport {
+	case TransportStreamableHTTP:
+		streamable, err := transport.NewStreamableHTTP(fmt.Sprintf("%s/mcp", baseURL))
+		if err != nil {
+			return fmt.Errorf("transport.NewStreamableHTTP(): %w", err)
+		}
+		s.transport = streamable
+	default:
+		sse, err := transport.NewSSE(baseURL + s.config.SSEPath)
+		if err != nil {
+			return fmt.Errorf("transport.NewSSE(): %w", err)
+		}
+		s.transport = sse
+	}
 
 	if err := s.transport.Start(ctx); err != nil {
 		return fmt.Errorf("transport.Start(): %w", err)
@@ -513,6 +1179,123 @@ func (s *Proxy) Start(ctx context.Context) error {
 	return nil
 }
 
+// listen binds the MCP listener and, when one is set, the admin listener
+func (s *Proxy) listen() ([]net.Listener, error) {
+	addrs := []string{s.config.Addr}
+	if s.config.AdminAddr != "" {
+		addrs = append(addrs, s.config.AdminAddr)
+	}
+
+	var listeners []net.Listener
+	for _, addr := range addrs {
+		listener, err := net.Listen("tcp", addr)
+		if err != nil {
+			for _, listener := range listeners {
+				listener.Close()
+			}
+			return nil, fmt.Errorf("failed to listen on %s: %w", addr, err)
+		}
+		listeners = append(listeners, listener)
+	}
+	return listeners, nil
+}
+
+// newMCPServer creates an MCP server with all configured tools, prompts and resources
+// registered, and starts the resource pollers. Pollers stop when ctx is cancelled.
+func (s *Proxy) newMCPServer(ctx context.Context) *server.MCPServer {
+	hooks := newServerHooks(s.getMaskedLogger(), &s.toolStats)
+
+	// Report the proxy build and active configuration so clients can tell what's deployed
+	hooks.AddAfterInitialize(func(ctx context.Context, id any, message *mcp.InitializeRequest, result *mcp.InitializeResult) {
+		if result.Meta == nil {
+			result.Meta = make(map[string]any)
+		}
+		result.Meta["proxyVersion"] = s.config.Version
+		result.Meta["configHash"] = s.ConfigHash()
+	})
+
+	// Sessions using a profile only list what it includes
+	s.addProfileHooks(hooks)
+	s.sessionProfiles.addHooks(hooks)
+
+	// Pollers only notify the sessions subscribed to a resource
+	s.subscriptions.addHooks(hooks)
+
+	// User hooks run after the proxy's own
+	for _, extra := range s.config.Hooks {
+		appendHooks(hooks, extra)
+	}
+
+	serverVersion := "1.0.0"
+	if cfg := s.currentConfig(); cfg != nil && cfg.MCP != nil && cfg.MCP.Version != "" {
+		serverVersion = cfg.MCP.Version
+	}
+	if hash := s.ConfigHash(); hash != "" {
+		serverVersion += "+config." + hash
+	}
+
+	mcpServer := server.NewMCPServer(
+		s.config.Name, serverVersion,
+		server.WithResourceCapabilities(true, true),
+		server.WithPromptCapabilities(true),
+		server.WithToolCapabilities(true),
+		server.WithLogging(),
+		server.WithHooks(hooks),
+		server.WithToolFilter(s.filterTools),
+	)
+
+	mcpServer.AddTools(s.tools...)
+	mcpServer.AddPrompts(s.prompts...)
+	mcpServer.AddResources(s.resources...)
+	for _, rt := range s.resourceTemplates {
+		mcpServer.AddResourceTemplate(rt.Template, rt.Handler)
+	}
+
+	// The call helpers go straight to the server, without the HTTP transport
+	inProcess, err := client.NewInProcessClient(mcpServer)
+	if err == nil {
+		var initRequest mcp.InitializeRequest
+		initRequest.Params.ProtocolVersion = mcp.LATEST_PROTOCOL_VERSION
+		initRequest.Params.ClientInfo = mcp.Implementation{Name: s.config.Name, Version: s.config.Version}
+		_, err = inProcess.Initialize(ctx, initRequest)
+	}
+	if err != nil {
+		s.logger.Warn("Failed to connect the in-process client", "error", err)
+		inProcess = nil
+	}
+
+	s.mu.Lock()
+	s.mcpServer = mcpServer
+	s.inProcess = inProcess
+	s.serveCtx = ctx
+	s.startPollers()
+	s.mu.Unlock()
+
+	return mcpServer
+}
+
+// ServeStdio serves the configured tools, resources and prompts over JSON-RPC on
+// the given reader and writer instead of starting the HTTP listener. It blocks
+// until ctx is cancelled or the input is closed.
+func (s *Proxy) ServeStdio(ctx context.Context, stdin io.Reader, stdout io.Writer) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	stdioServer := server.NewStdioServer(s.newMCPServer(ctx))
+	stdioServer.SetErrorLogger(slog.NewLogLogger(s.logger.Handler(), slog.LevelError))
+	stdioServer.SetContextFunc(func(ctx context.Context) context.Context {
+		return s.withProfile(ctx, "")
+	})
+
+	s.logger.Info("MCP server listening on stdio")
+
+	if err := stdioServer.Listen(ctx, stdin, stdout); err != nil && err != context.Canceled {
+		return fmt.Errorf("stdioServer.Listen(): %w", err)
+	}
+
+	return nil
+}
+
 // Close stops the server and cleans up resources like temporary directories.
 func (s *Proxy) Close() {
 	if s.transport != nil {
@@ -521,12 +1304,25 @@ func (s *Proxy) Close() {
 		s.client = nil
 	}
 
-	// Wait for server goroutine to finish
+	// Wait for server goroutine and pollers to finish
 	s.wg.Wait()
+
+	// No more backend requests can be in flight; release idle connections
+	s.clientManager.Close()
+	for _, grpcBackend := range s.grpcBackends {
+		grpcBackend.Close()
+	}
+}
+
+// ConfigHash returns a short hash of the configuration the proxy is currently serving
+func (s *Proxy) ConfigHash() string {
+	hash, _ := s.configHash.Load().(string)
+	return hash
 }
 
 // Client returns an MCP client connected to the server.
 // The client is already initialized, i.e. you do _not_ need to call Client.Initialize().
+// It is nil when the internal client is disabled with WithInternalClient(false).
 func (s *Proxy) Client() *client.Client {
 	return s.client
 }
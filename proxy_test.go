@@ -0,0 +1,57 @@
+package proxy
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// discardLogger drops the proxy's logs in tests
+func discardLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+// newTestProxy builds a proxy from a YAML config and serves it in process, so the call
+// helpers work without listening. It's closed when the test ends.
+func newTestProxy(t *testing.T, config string, opts ...Option) *Proxy {
+	t.Helper()
+
+	cfg, err := ParseConfigFromBytes([]byte(config))
+	if err != nil {
+		t.Fatalf("failed to parse config: %v", err)
+	}
+
+	s, err := NewServerFromConfig(cfg, append([]Option{WithLogger(discardLogger())}, opts...)...)
+	if err != nil {
+		t.Fatalf("failed to create proxy: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.newMCPServer(ctx)
+	t.Cleanup(func() {
+		cancel()
+		s.Close()
+	})
+	return s
+}
+
+// toolText returns the text of a tool result's first content block
+func toolText(t *testing.T, s *Proxy, name string, arguments map[string]any) (string, bool) {
+	t.Helper()
+
+	result, err := s.CallTool(context.Background(), name, arguments)
+	if err != nil {
+		t.Fatalf("CallTool(%s) failed: %v", name, err)
+	}
+	if len(result.Content) == 0 {
+		t.Fatalf("CallTool(%s) returned no content", name)
+	}
+	text, ok := result.Content[0].(mcp.TextContent)
+	if !ok {
+		t.Fatalf("CallTool(%s) returned %T, want text", name, result.Content[0])
+	}
+	return text.Text, result.IsError
+}
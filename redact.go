@@ -0,0 +1,356 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// redactedValue replaces secret values in API responses
+const redactedValue = "***"
+
+// isSecretHeader reports whether a header typically carries credentials
+func isSecretHeader(name string) bool {
+	return slices.ContainsFunc(defaultRedactedHeaders, func(secret string) bool {
+		return strings.EqualFold(secret, name)
+	})
+}
+
+// isSecret reports whether a header's value is hidden from API responses
+func (h *Header) isSecret() bool {
+	return h.Secret || isSecretHeader(h.Name)
+}
+
+// RedactConfig returns a copy of cfg with the values of secret headers and params
+// replaced by "***". cfg itself is left untouched.
+func RedactConfig(cfg *Config) (*Config, error) {
+	redacted, err := copyConfigValue(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	forEachSecret(redacted, func(_ string, value *string) {
+		if *value != "" {
+			*value = redactedValue
+		}
+	})
+
+	return redacted, nil
+}
+
+// copyConfigValue returns a deep copy of a config or part of one, made through its JSON form
+func copyConfigValue[T any](value *T) (*T, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to copy config: %w", err)
+	}
+
+	var copied T
+	if err := json.Unmarshal(data, &copied); err != nil {
+		return nil, fmt.Errorf("failed to copy config: %w", err)
+	}
+	return &copied, nil
+}
+
+// restoreRedacted puts back secret values that a client sent unchanged as "***", taking
+// them from current, so saving a config read from the API doesn't erase its secrets
+func restoreRedacted(cfg, current *Config) {
+	if current == nil {
+		return
+	}
+
+	secrets := make(map[string]string)
+	forEachSecret(current, func(key string, value *string) {
+		secrets[key] = *value
+	})
+
+	forEachSecret(cfg, func(key string, value *string) {
+		if secret, ok := secrets[key]; ok && *value == redactedValue {
+			*value = secret
+		}
+	})
+}
+
+// forEachSecret calls fn with a pointer to the value of every secret header and param in
+// cfg. The key identifies the value by backend, endpoint and name rather than position.
+func forEachSecret(cfg *Config, fn func(key string, value *string)) {
+	for _, backend := range cfg.Backends {
+		for _, header := range backend.DefaultHeaders {
+			if header.isSecret() {
+				fn(fmt.Sprintf("%s|header|%s", backend.BaseURL, header.Name), &header.Value)
+			}
+		}
+
+		for i := range backend.Endpoints {
+			endpoint := &backend.Endpoints[i]
+			prefix := fmt.Sprintf("%s|%s", backend.BaseURL, endpoint.Name)
+
+			for _, header := range endpoint.Headers {
+				if header.isSecret() {
+					fn(fmt.Sprintf("%s|header|%s", prefix, header.Name), &header.Value)
+				}
+			}
+			for _, params := range [][]*Param{endpoint.PathParameters, endpoint.QueryParameters, endpoint.BodyParams} {
+				for _, param := range params {
+					if param.Secret {
+						fn(fmt.Sprintf("%s|param|%s", prefix, param.Identifier), &param.Value)
+					}
+				}
+			}
+			for _, step := range endpoint.Steps {
+				for _, header := range step.Headers {
+					if header.isSecret() {
+						fn(fmt.Sprintf("%s|step|%s|header|%s", prefix, step.Name, header.Name), &header.Value)
+					}
+				}
+			}
+		}
+	}
+
+	for name, template := range cfg.Templates {
+		if template == nil {
+			continue
+		}
+		for _, header := range template.Headers {
+			if header.isSecret() {
+				fn(fmt.Sprintf("template|%s|header|%s", name, header.Name), &header.Value)
+			}
+		}
+		for _, params := range [][]*Param{template.PathParameters, template.QueryParameters, template.BodyParams} {
+			for _, param := range params {
+				if param.Secret {
+					fn(fmt.Sprintf("template|%s|param|%s", name, param.Identifier), &param.Value)
+				}
+			}
+		}
+	}
+}
+
+// defaultLogRedactKeys are log attribute, argument and query parameter names whose
+// values are masked in log output, in addition to the secret headers
+var defaultLogRedactKeys = []string{"api_key", "apikey", "access_token", "client_secret", "password", "secret", "token"}
+
+// secretNames returns the names of headers and params marked secret in cfg
+func secretNames(cfg *Config) []string {
+	var names []string
+	for _, backend := range cfg.Backends {
+		for _, header := range backend.DefaultHeaders {
+			if header.Secret {
+				names = append(names, header.Name)
+			}
+		}
+		for _, endpoint := range backend.Endpoints {
+			for _, header := range endpoint.Headers {
+				if header.Secret {
+					names = append(names, header.Name)
+				}
+			}
+			for _, params := range [][]*Param{endpoint.PathParameters, endpoint.QueryParameters, endpoint.BodyParams} {
+				for _, param := range params {
+					if param.Secret {
+						names = append(names, param.Identifier)
+					}
+				}
+			}
+			for _, step := range endpoint.Steps {
+				for _, header := range step.Headers {
+					if header.Secret {
+						names = append(names, header.Name)
+					}
+				}
+			}
+		}
+	}
+	return names
+}
+
+// redactingHandler is a slog.Handler that masks the values of secret keys before
+// records reach the wrapped handler. Keys are matched case-insensitively against
+// attribute names, the fields of logged structs and maps, and URL query parameters.
+type redactingHandler struct {
+	next slog.Handler
+	keys secretKeys
+}
+
+// newRedactingHandler wraps next, masking the values of the given keys
+func newRedactingHandler(next slog.Handler, keys []string) *redactingHandler {
+	return &redactingHandler{next: next, keys: newSecretKeys(keys)}
+}
+
+// Enabled reports whether the wrapped handler handles records at the given level
+func (h *redactingHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+// Handle masks the record's attributes and passes it on
+func (h *redactingHandler) Handle(ctx context.Context, record slog.Record) error {
+	redacted := slog.NewRecord(record.Time, record.Level, record.Message, record.PC)
+	record.Attrs(func(attr slog.Attr) bool {
+		redacted.AddAttrs(h.redactAttr(attr))
+		return true
+	})
+	return h.next.Handle(ctx, redacted)
+}
+
+// WithAttrs returns a handler that masks attrs and the attributes of later records
+func (h *redactingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	redacted := make([]slog.Attr, len(attrs))
+	for i, attr := range attrs {
+		redacted[i] = h.redactAttr(attr)
+	}
+	return &redactingHandler{next: h.next.WithAttrs(redacted), keys: h.keys}
+}
+
+// WithGroup returns a handler with the same keys
+func (h *redactingHandler) WithGroup(name string) slog.Handler {
+	return &redactingHandler{next: h.next.WithGroup(name), keys: h.keys}
+}
+
+// redactAttr masks an attribute whose key is secret, and secrets nested in its value
+func (h *redactingHandler) redactAttr(attr slog.Attr) slog.Attr {
+	attr.Value = attr.Value.Resolve()
+	if h.keys.has(attr.Key) {
+		return slog.String(attr.Key, redactedValue)
+	}
+
+	switch attr.Value.Kind() {
+	case slog.KindGroup:
+		group := attr.Value.Group()
+		redacted := make([]slog.Attr, len(group))
+		for i, member := range group {
+			redacted[i] = h.redactAttr(member)
+		}
+		return slog.Attr{Key: attr.Key, Value: slog.GroupValue(redacted...)}
+	case slog.KindString:
+		if value, changed := h.redactString(attr.Value.String()); changed {
+			return slog.String(attr.Key, value)
+		}
+	case slog.KindAny:
+		if value, changed := h.redactAny(attr.Value.Any()); changed {
+			return slog.Any(attr.Key, value)
+		}
+	}
+
+	return attr
+}
+
+// redactString masks secrets in a logged string: secret fields when it holds JSON, such
+// as a backend's response body, and secret query parameters when it holds a URL
+func (h *redactingHandler) redactString(value string) (string, bool) {
+	trimmed := strings.TrimSpace(value)
+	if strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "[") {
+		var decoded any
+		if json.Unmarshal([]byte(trimmed), &decoded) == nil {
+			redacted, changed := h.redactJSON(decoded)
+			if !changed {
+				return value, false
+			}
+			data, err := json.Marshal(redacted)
+			if err != nil {
+				return redactedValue, true
+			}
+			return string(data), true
+		}
+	}
+	return h.keys.redactURL(value)
+}
+
+// redactAny masks secrets in a logged struct or map by way of its JSON form. It
+// reports false, leaving the value as is, when there was nothing to mask.
+func (h *redactingHandler) redactAny(value any) (any, bool) {
+	if _, isErr := value.(error); isErr {
+		return value, false
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return value, false
+	}
+
+	var decoded any
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return value, false
+	}
+
+	if redacted, changed := h.redactJSON(decoded); changed {
+		return redacted, true
+	}
+	return value, false
+}
+
+// redactJSON masks secret fields of decoded JSON in place
+func (h *redactingHandler) redactJSON(value any) (any, bool) {
+	changed := false
+	switch v := value.(type) {
+	case map[string]any:
+		for key, field := range v {
+			if h.keys.has(key) {
+				v[key] = redactedValue
+				changed = true
+			} else if redacted, ok := h.redactJSON(field); ok {
+				v[key] = redacted
+				changed = true
+			}
+		}
+	case []any:
+		for i, item := range v {
+			if redacted, ok := h.redactJSON(item); ok {
+				v[i] = redacted
+				changed = true
+			}
+		}
+	case string:
+		return h.keys.redactURL(v)
+	}
+	return value, changed
+}
+
+// secretKeys is a set of secret names, matched case-insensitively
+type secretKeys map[string]struct{}
+
+// newSecretKeys returns the set of the given names
+func newSecretKeys(keys []string) secretKeys {
+	set := make(secretKeys, len(keys))
+	for _, key := range keys {
+		set[strings.ToLower(key)] = struct{}{}
+	}
+	return set
+}
+
+// has reports whether name is a secret key
+func (k secretKeys) has(name string) bool {
+	_, ok := k[strings.ToLower(name)]
+	return ok
+}
+
+// redactURL masks secret query parameters of an absolute URL
+func (k secretKeys) redactURL(value string) (string, bool) {
+	if !strings.Contains(value, "?") {
+		return value, false
+	}
+
+	u, err := url.Parse(value)
+	if err != nil || u.Scheme == "" {
+		return value, false
+	}
+
+	query := u.Query()
+	changed := false
+	for name := range query {
+		if k.has(name) {
+			query[name] = []string{redactedValue}
+			changed = true
+		}
+	}
+	if !changed {
+		return value, false
+	}
+
+	// Keep the placeholder readable rather than percent-encoded
+	u.RawQuery = strings.ReplaceAll(query.Encode(), url.QueryEscape(redactedValue), redactedValue)
+	return u.String(), true
+}
@@ -0,0 +1,196 @@
+package proxy
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRedactingHandler(t *testing.T) {
+	tests := []struct {
+		name   string
+		attr   slog.Attr
+		hidden string
+		kept   string
+	}{
+		{"secret key", slog.String("Authorization", "Bearer abc123"), "abc123", `"Authorization":"***"`},
+		{"url query", slog.String("url", "https://api.example.com/users?api_key=abc123&page=2"), "abc123", "page=2"},
+		{"json body", slog.String("response", `{"error":"denied","token":"abc123"}`), "abc123", `\"error\":\"denied\"`},
+		{"json array body", slog.String("response", `[{"password":"abc123","user":"ada"}]`), "abc123", `\"user\":\"ada\"`},
+		{"json with url", slog.String("response", `{"next":"https://api.example.com/?access_token=abc123"}`), "abc123", "api.example.com"},
+		{"group", slog.Group("request", slog.String("secret", "abc123"), slog.Int("attempt", 2)), "abc123", `"attempt":2`},
+		{"map", slog.Any("arguments", map[string]any{"password": "abc123", "user": "ada"}), "abc123", `"user":"ada"`},
+		{"plain string", slog.String("response", "internal error"), "", `"response":"internal error"`},
+		{"invalid json", slog.String("response", `{"token": abc123`), "", `{\"token\": abc123`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			logger := slog.New(newRedactingHandler(slog.NewJSONHandler(&out, nil), []string{"authorization", "api_key", "token", "password", "secret", "access_token"}))
+			logger.Error("request failed", tt.attr)
+
+			logged := out.String()
+			if tt.hidden != "" && strings.Contains(logged, tt.hidden) {
+				t.Errorf("log %s contains the secret %q", logged, tt.hidden)
+			}
+			if !strings.Contains(logged, tt.kept) {
+				t.Errorf("log %s doesn't contain %s", logged, tt.kept)
+			}
+		})
+	}
+}
+
+func TestRedactingHandlerWithAttrs(t *testing.T) {
+	var out bytes.Buffer
+	logger := slog.New(newRedactingHandler(slog.NewJSONHandler(&out, nil), []string{"token"}))
+	logger.With("token", "abc123").WithGroup("call").Info("request", "response", `{"token":"def456"}`)
+
+	logged := out.String()
+	if strings.Contains(logged, "abc123") || strings.Contains(logged, "def456") {
+		t.Errorf("log %s contains a secret", logged)
+	}
+}
+
+func TestConfigAPIRedactsAndRestoresSecrets(t *testing.T) {
+	backend := newEchoBackend(t)
+	s := newTestProxy(t, fmt.Sprintf(`
+mcp: {}
+templates:
+  auth:
+    headers:
+      - name: Authorization
+        type: constant
+        value: "Bearer template-secret"
+        secret: true
+backends:
+  - base_url: %s
+    default_headers:
+      - name: X-Api-Key
+        type: constant
+        value: backend-secret
+        secret: true
+    endpoints:
+      - name: get_user
+        capability: tool
+        mode: client
+        method: GET
+        path: /users
+        uses: [auth]
+      - name: steps
+        capability: tool
+        mode: client
+        steps:
+          - name: first
+            method: GET
+            path: /first
+            headers:
+              - name: X-Step-Token
+                value: step-secret
+                secret: true
+`, backend.URL))
+	api := httptest.NewServer(s.configAPIHandler())
+	t.Cleanup(api.Close)
+
+	resp, err := http.Get(api.URL + "/api/config")
+	if err != nil {
+		t.Fatalf("GET /api/config failed: %v", err)
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		t.Fatalf("failed to read config: %v", err)
+	}
+	for _, secret := range []string{"template-secret", "backend-secret", "step-secret"} {
+		if strings.Contains(string(body), secret) {
+			t.Errorf("GET /api/config returned the secret %q", secret)
+		}
+	}
+	if !strings.Contains(string(body), redactedValue) {
+		t.Errorf("GET /api/config returned %s, want redacted values", body)
+	}
+
+	// Sending the redacted config back keeps the secrets
+	req, err := http.NewRequest(http.MethodPut, api.URL+"/api/config", bytes.NewReader(body))
+	if err != nil {
+		t.Fatalf("failed to create request: %v", err)
+	}
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("PUT /api/config failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("PUT /api/config returned %d, want 200", resp.StatusCode)
+	}
+
+	for _, tool := range []string{"get_user", "steps"} {
+		if text, isError := toolText(t, s, tool, nil); isError {
+			t.Fatalf("%s failed: %s", tool, text)
+		}
+	}
+	requests := backend.received()
+	if len(requests) != 2 {
+		t.Fatalf("backend received %d requests, want 2", len(requests))
+	}
+	if got := requests[0].Header.Get("Authorization"); got != "Bearer template-secret" {
+		t.Errorf("get_user sent Authorization %q, want the template's secret", got)
+	}
+	if got := requests[0].Header.Get("X-Api-Key"); got != "backend-secret" {
+		t.Errorf("get_user sent X-Api-Key %q, want the backend's secret", got)
+	}
+	if got := requests[1].Header.Get("X-Step-Token"); got != "step-secret" {
+		t.Errorf("steps sent X-Step-Token %q, want the step's secret", got)
+	}
+}
+
+func TestRestoreRedacted(t *testing.T) {
+	current := &Config{
+		Templates: map[string]*Template{
+			"auth": {Headers: []*Header{{Name: "Authorization", Value: "Bearer old"}}},
+		},
+		Backends: []*Backend{{
+			BaseURL:        "http://localhost",
+			DefaultHeaders: []*Header{{Name: "X-Api-Key", Value: "old-key", Secret: true}},
+			Endpoints: []Endpoint{{
+				Name:  "steps",
+				Steps: []*Step{{Name: "first", Headers: []*Header{{Name: "X-Token", Value: "old-token", Secret: true}}}},
+			}},
+		}},
+	}
+
+	cfg, err := RedactConfig(current)
+	if err != nil {
+		t.Fatalf("RedactConfig failed: %v", err)
+	}
+	if current.Backends[0].DefaultHeaders[0].Value != "old-key" {
+		t.Error("RedactConfig changed the original config")
+	}
+	if got := cfg.Templates["auth"].Headers[0].Value; got != redactedValue {
+		t.Errorf("redacted template header = %q, want %q", got, redactedValue)
+	}
+
+	// The client changed the backend key and left the others redacted
+	cfg.Backends[0].DefaultHeaders[0].Value = "new-key"
+	restoreRedacted(cfg, current)
+
+	tests := []struct {
+		name string
+		got  string
+		want string
+	}{
+		{"template header", cfg.Templates["auth"].Headers[0].Value, "Bearer old"},
+		{"changed backend header", cfg.Backends[0].DefaultHeaders[0].Value, "new-key"},
+		{"step header", cfg.Backends[0].Endpoints[0].Steps[0].Headers[0].Value, "old-token"},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s = %q, want %q", tt.name, tt.got, tt.want)
+		}
+	}
+}
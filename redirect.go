@@ -0,0 +1,98 @@
+package proxy
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// RedirectPolicy selects how backend redirects are followed
+type RedirectPolicy string
+
+// RedirectPolicy constants
+const (
+	// REDIRECT_SAME_HOST follows redirects, but removes credential headers from requests
+	// redirected to a different host or scheme (default)
+	REDIRECT_SAME_HOST RedirectPolicy = "same_host"
+
+	// REDIRECT_FOLLOW follows every redirect with Go's default handling, which only
+	// removes Authorization, WWW-Authenticate and Cookie on redirects to another domain
+	REDIRECT_FOLLOW RedirectPolicy = "follow"
+
+	// REDIRECT_NONE returns the 3xx response instead of following it
+	REDIRECT_NONE RedirectPolicy = "none"
+)
+
+// maxRedirects is how many redirects a request follows before failing
+const maxRedirects = 10
+
+// checkRedirect returns the http.Client CheckRedirect function for a policy. Under
+// same_host, secretHeaders are removed along with Authorization, Cookie and the other
+// credential headers when a redirect leaves the original request's host or scheme, so
+// an https to http downgrade doesn't send them in the clear.
+func checkRedirect(policy RedirectPolicy, secretHeaders []string) func(*http.Request, []*http.Request) error {
+	switch policy {
+	case REDIRECT_FOLLOW:
+		return nil
+	case REDIRECT_NONE:
+		return func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		}
+	}
+
+	return func(req *http.Request, via []*http.Request) error {
+		if len(via) >= maxRedirects {
+			return errors.New("stopped after 10 redirects")
+		}
+		if strings.EqualFold(req.URL.Scheme, via[0].URL.Scheme) && strings.EqualFold(req.URL.Host, via[0].URL.Host) {
+			return nil
+		}
+
+		for _, name := range defaultRedactedHeaders {
+			req.Header.Del(name)
+		}
+		for _, name := range secretHeaders {
+			req.Header.Del(name)
+		}
+		return nil
+	}
+}
+
+// checkPublicRedirect wraps a CheckRedirect function, nil for Go's default, so it also
+// refuses redirects to private addresses
+func checkPublicRedirect(next func(*http.Request, []*http.Request) error) func(*http.Request, []*http.Request) error {
+	return func(req *http.Request, via []*http.Request) error {
+		if next != nil {
+			if err := next(req, via); err != nil {
+				return err
+			}
+		} else if len(via) >= maxRedirects {
+			return errors.New("stopped after 10 redirects")
+		}
+		return checkPublicTarget(req.URL)
+	}
+}
+
+// secretHeaderNames returns the names of the headers a config marks as secret, which
+// aren't sent along on cross-host redirects
+func secretHeaderNames(cfg *Config) []string {
+	var names []string
+	add := func(headers []*Header) {
+		for _, header := range headers {
+			if header.isSecret() {
+				names = append(names, header.Name)
+			}
+		}
+	}
+
+	if cfg.MCP != nil {
+		add(cfg.MCP.DefaultHeaders)
+	}
+	for _, backend := range cfg.Backends {
+		add(backend.DefaultHeaders)
+		for _, endpoint := range backend.Endpoints {
+			add(endpoint.Headers)
+		}
+	}
+	return names
+}
@@ -0,0 +1,44 @@
+package proxy
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestSameHostRedirectDropsCredentials(t *testing.T) {
+	tests := []struct {
+		name     string
+		from, to string
+		keep     bool
+	}{
+		{"same host", "https://api.example.com/a", "https://api.example.com/b", true},
+		{"other host", "https://api.example.com/a", "https://evil.example.net/b", false},
+		{"scheme downgrade", "https://api.example.com/a", "http://api.example.com/b", false},
+		{"other port", "https://api.example.com/a", "https://api.example.com:8443/b", false},
+	}
+
+	check := checkRedirect(REDIRECT_SAME_HOST, []string{"X-Tenant-Token"})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			original, _ := http.NewRequest(http.MethodGet, tt.from, nil)
+			redirected, _ := http.NewRequest(http.MethodGet, tt.to, nil)
+			for _, name := range []string{"Authorization", "X-Api-Key", "X-Tenant-Token"} {
+				redirected.Header.Set(name, "secret")
+			}
+			redirected.Header.Set("Accept", "application/json")
+
+			if err := check(redirected, []*http.Request{original}); err != nil {
+				t.Fatalf("checkRedirect failed: %v", err)
+			}
+
+			for _, name := range []string{"Authorization", "X-Api-Key", "X-Tenant-Token"} {
+				if kept := redirected.Header.Get(name) != ""; kept != tt.keep {
+					t.Errorf("%s kept = %v, want %v", name, kept, tt.keep)
+				}
+			}
+			if redirected.Header.Get("Accept") == "" {
+				t.Error("Accept was dropped, want other headers kept")
+			}
+		})
+	}
+}
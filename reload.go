@@ -0,0 +1,286 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// Reload replaces the served tools, resources and prompts with those built from cfg,
+// which should come from ParseConfig. The MCP server keeps running, and connected
+// clients are notified that the lists changed. If cfg's endpoints can't be set up, the
+// current configuration keeps being served and the error is returned.
+//
+// Resource templates removed from the configuration keep being served until restart,
+// as the MCP server can't unregister them.
+func (s *Proxy) Reload(cfg *Config) error {
+	s.editMu.Lock()
+	defer s.editMu.Unlock()
+
+	staged, err := s.stageConfig(cfg)
+	if err != nil {
+		return err
+	}
+	s.commitConfig(staged, cfg)
+	return nil
+}
+
+// AddEndpoint adds an endpoint to the named backend and starts serving it, as if it had
+// been in the configuration file. Connected clients are notified that the lists changed.
+// The endpoint is validated like the rest of the configuration, and its name must not
+// be used by any other endpoint. The configuration file isn't written, so a later
+// reload from the file drops the endpoint.
+func (s *Proxy) AddEndpoint(backend string, endpoint Endpoint) error {
+	s.editMu.Lock()
+	defer s.editMu.Unlock()
+
+	cfg, err := s.editableConfig()
+	if err != nil {
+		return err
+	}
+
+	target := cfg.findBackend(backend)
+	if target == nil {
+		return fmt.Errorf("backend '%s' is not configured", backend)
+	}
+	if owner, _ := cfg.findEndpoint(endpoint.Name); owner != nil {
+		return fmt.Errorf("endpoint '%s' already exists", endpoint.Name)
+	}
+
+	// Copy the endpoint so the caller's headers and params aren't shared with the config
+	added, err := copyConfigValue(&endpoint)
+	if err != nil {
+		return err
+	}
+	target.Endpoints = append(target.Endpoints, *added)
+
+	// Prepare the endpoint like ParseConfig does; the rest of cfg already was
+	if err := setConfigDefaults(cfg); err != nil {
+		return fmt.Errorf("failed to set config defaults: %w", err)
+	}
+	if err := validateParsedConfig(cfg); err != nil {
+		return fmt.Errorf("endpoint '%s' validation failed: %w", endpoint.Name, err)
+	}
+	processEndpointEnvironmentVars(&target.Endpoints[len(target.Endpoints)-1])
+
+	staged, err := s.stageConfig(cfg)
+	if err != nil {
+		return err
+	}
+	// Without strict setup the endpoint would be skipped rather than fail the reload
+	if err := staged.setupErrorFor(endpoint.Name); err != nil {
+		staged.clientManager.Close()
+		return err
+	}
+	s.commitConfig(staged, cfg)
+
+	s.logger.Info("Endpoint added", "endpoint", endpoint.Name, "backend", backend)
+	return nil
+}
+
+// RemoveEndpoint stops serving the named endpoint and removes it from the
+// configuration. Connected clients are notified that the lists changed. It fails if the
+// configuration without the endpoint doesn't validate. A removed resource template
+// keeps being served until restart, as the MCP server can't unregister it. The
+// configuration file isn't written, so a later reload from the file brings the
+// endpoint back.
+func (s *Proxy) RemoveEndpoint(name string) error {
+	s.editMu.Lock()
+	defer s.editMu.Unlock()
+
+	cfg, err := s.editableConfig()
+	if err != nil {
+		return err
+	}
+
+	backend, i := cfg.findEndpoint(name)
+	if backend == nil {
+		return fmt.Errorf("endpoint '%s' is not configured", name)
+	}
+	backend.Endpoints = slices.Delete(backend.Endpoints, i, i+1)
+
+	if err := validateParsedConfig(cfg); err != nil {
+		return fmt.Errorf("can't remove endpoint '%s': %w", name, err)
+	}
+
+	staged, err := s.stageConfig(cfg)
+	if err != nil {
+		return err
+	}
+	s.commitConfig(staged, cfg)
+
+	s.logger.Info("Endpoint removed", "endpoint", name)
+	return nil
+}
+
+// currentConfig returns the configuration being served. Reloads replace it rather than
+// change it, so callers can read it after the lock is released.
+func (s *Proxy) currentConfig() *Config {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.mcpConfig
+}
+
+// currentClients returns the client manager of the configuration being served
+func (s *Proxy) currentClients() *ClientManager {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.clientManager
+}
+
+// editableConfig returns a copy of the current configuration to change and reload
+func (s *Proxy) editableConfig() (*Config, error) {
+	current := s.currentConfig()
+	if current == nil {
+		return nil, fmt.Errorf("no configuration loaded")
+	}
+	return copyConfigValue(current)
+}
+
+// stageConfig builds cfg's endpoints on their own, without touching what's being served
+func (s *Proxy) stageConfig(cfg *Config) (*Proxy, error) {
+	staged := &Proxy{
+		config:        s.config,
+		logger:        s.logger,
+		clientManager: NewClientManager(),
+		configFile:    s.configFile,
+		mcpConfig:     cfg,
+	}
+	if err := staged.setupEndpointsFromConfig(cfg); err != nil {
+		staged.clientManager.Close()
+		return nil, fmt.Errorf("failed to setup endpoints: %w", err)
+	}
+	return staged, nil
+}
+
+// commitConfig serves staged's endpoints in place of the current ones and makes cfg
+// the current configuration
+func (s *Proxy) commitConfig(staged *Proxy, cfg *Config) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.mcpServer != nil {
+		s.swapCapabilities(staged)
+	}
+
+	// Requests already in flight finish on the previous clients. gRPC connections are
+	// kept until Close, since closing them would fail calls still running on them.
+	// Host state is shared, so breakers and counters carry on across the reload.
+	previousClients := s.clientManager
+	staged.clientManager.keepHosts(previousClients)
+	for backend, conn := range s.grpcBackends {
+		if staged.grpcBackends == nil {
+			staged.grpcBackends = make(map[*Backend]*grpcBackend)
+		}
+		staged.grpcBackends[backend] = conn
+	}
+
+	s.tools = staged.tools
+	s.prompts = staged.prompts
+	s.resources = staged.resources
+	s.resourceTemplates = staged.resourceTemplates
+	s.pollers = staged.pollers
+	s.setupErrors = staged.setupErrors
+	s.capabilityTags = staged.capabilityTags
+	s.grpcBackends = staged.grpcBackends
+	s.clientManager = staged.clientManager
+	s.maskedLogger = staged.maskedLogger
+	s.handlerLogger = staged.handlerLogger
+	s.mcpConfig = cfg
+	s.configHash.Store(hashConfig(cfg))
+
+	previousClients.Close()
+
+	if s.mcpServer != nil {
+		s.startPollers()
+	}
+
+	s.logger.Info("Configuration reloaded",
+		"tools", len(s.tools),
+		"resources", len(s.resources),
+		"resource_templates", len(s.resourceTemplates),
+		"prompts", len(s.prompts),
+		"skipped", len(s.setupErrors),
+	)
+}
+
+// swapCapabilities replaces what the running MCP server serves with staged's
+// endpoints. The caller must hold s.mu.
+func (s *Proxy) swapCapabilities(staged *Proxy) {
+	s.mcpServer.SetTools(staged.tools...)
+
+	promptNames := make([]string, 0, len(s.prompts))
+	for _, prompt := range s.prompts {
+		promptNames = append(promptNames, prompt.Prompt.Name)
+	}
+	s.mcpServer.DeletePrompts(promptNames...)
+	s.mcpServer.AddPrompts(staged.prompts...)
+
+	for _, resource := range s.resources {
+		s.mcpServer.RemoveResource(resource.Resource.URI)
+	}
+	s.mcpServer.AddResources(staged.resources...)
+
+	for _, rt := range staged.resourceTemplates {
+		s.mcpServer.AddResourceTemplate(rt.Template, rt.Handler)
+	}
+}
+
+// findEndpoint returns the backend an endpoint is configured under and its index there,
+// or a nil backend if no endpoint has the name
+func (cfg *Config) findEndpoint(name string) (*Backend, int) {
+	for _, backend := range cfg.Backends {
+		for i := range backend.Endpoints {
+			if backend.Endpoints[i].Name == name {
+				return backend, i
+			}
+		}
+	}
+	return nil, -1
+}
+
+// endpointSetupError records why an endpoint was skipped during setup
+type endpointSetupError struct {
+	endpoint string
+	err      error
+}
+
+func (e *endpointSetupError) Error() string {
+	return e.err.Error()
+}
+
+func (e *endpointSetupError) Unwrap() error {
+	return e.err
+}
+
+// setupErrorFor returns why the named endpoint was skipped during setup, if it was
+func (s *Proxy) setupErrorFor(name string) error {
+	for _, err := range s.setupErrors {
+		var setupErr *endpointSetupError
+		if errors.As(err, &setupErr) && setupErr.endpoint == name {
+			return setupErr.err
+		}
+	}
+	return nil
+}
+
+// startPollers starts the resource pollers. They stop when the serving context is
+// cancelled or the next reload replaces them. The caller must hold s.mu.
+func (s *Proxy) startPollers() {
+	if s.stopPollers != nil {
+		s.stopPollers()
+	}
+
+	var ctx context.Context
+	ctx, s.stopPollers = context.WithCancel(s.serveCtx)
+
+	mcpServer := s.mcpServer
+	for _, poller := range s.pollers {
+		s.wg.Add(1)
+		go func(p *resourcePoller) {
+			defer s.wg.Done()
+			p.run(ctx, mcpServer)
+		}(poller)
+	}
+}
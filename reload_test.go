@@ -0,0 +1,99 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestConfigAPIPutReloads(t *testing.T) {
+	backend := newCallsBackend(t)
+	s := newTestProxy(t, fmt.Sprintf(callsConfig, backend.URL))
+	api := httptest.NewServer(s.configAPIHandler())
+	t.Cleanup(api.Close)
+
+	cfg, err := s.editableConfig()
+	if err != nil {
+		t.Fatalf("failed to copy config: %v", err)
+	}
+	cfg.Backends[0].Endpoints[0].Name = "fetch_user"
+	body, err := json.Marshal(cfg)
+	if err != nil {
+		t.Fatalf("failed to encode config: %v", err)
+	}
+
+	// Readers of the config API run alongside the update
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for _, path := range []string{"/api/config", "/api/endpoints", "/api/status"} {
+				if resp, err := http.Get(api.URL + path); err == nil {
+					resp.Body.Close()
+				}
+			}
+		}()
+	}
+
+	req, err := http.NewRequest(http.MethodPut, api.URL+"/api/config", bytes.NewReader(body))
+	if err != nil {
+		t.Fatalf("failed to create request: %v", err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("PUT /api/config failed: %v", err)
+	}
+	resp.Body.Close()
+	wg.Wait()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("PUT /api/config returned %d, want 200", resp.StatusCode)
+	}
+
+	tools, err := s.ListTools(context.Background())
+	if err != nil {
+		t.Fatalf("ListTools failed: %v", err)
+	}
+	if !slices.ContainsFunc(tools, func(tool mcp.Tool) bool { return tool.Name == "fetch_user" }) {
+		t.Errorf("ListTools after PUT returned %v, want fetch_user", tools)
+	}
+	if got := s.currentConfig().Backends[0].Endpoints[0].Name; got != "fetch_user" {
+		t.Errorf("current config has endpoint %q, want fetch_user", got)
+	}
+}
+
+func TestReloadKeepsBackendHostState(t *testing.T) {
+	backend := newCallsBackend(t)
+	config := fmt.Sprintf(callsConfig, backend.URL)
+	s := newTestProxy(t, config)
+
+	if text, isError := toolText(t, s, "get_user", map[string]any{"user_id": "42"}); isError {
+		t.Fatalf("get_user failed: %s", text)
+	}
+	before := s.currentClients().Status()
+	if len(before) != 1 || before[0].Requests != 1 {
+		t.Fatalf("status before reload = %+v, want one host with 1 request", before)
+	}
+	s.currentClients().host(before[0].Host).breaker.RecordFailure()
+
+	cfg, err := ParseConfigFromBytes([]byte(config))
+	if err != nil {
+		t.Fatalf("failed to parse config: %v", err)
+	}
+	if err := s.Reload(cfg); err != nil {
+		t.Fatalf("Reload failed: %v", err)
+	}
+
+	after := s.currentClients().Status()
+	if len(after) != 1 || after[0].Requests != 1 || after[0].CircuitBreaker.FailureCount != 1 {
+		t.Errorf("status after reload = %+v, want the host's request count and breaker failures kept", after)
+	}
+}
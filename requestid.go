@@ -0,0 +1,75 @@
+package proxy
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// defaultRequestIDHeader carries request IDs to and from the proxy unless configured otherwise
+const defaultRequestIDHeader = "X-Request-ID"
+
+// requestIDMetaKey is the _meta field of a tool call that can carry the caller's request ID
+const requestIDMetaKey = "requestId"
+
+type requestIDKey struct{}
+
+// withRequestID returns a context carrying the ID of the MCP call it serves
+func withRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// requestIDFromContext returns the request ID carried by ctx, or "" if there is none
+func requestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// startRequest returns a context carrying the call's request ID. The caller's own ID is
+// reused when the MCP request or the HTTP request that delivered it carries one;
+// otherwise a new one is generated.
+func startRequest(ctx context.Context, meta *mcp.Meta) (context.Context, string) {
+	if meta != nil {
+		if id, ok := meta.AdditionalFields[requestIDMetaKey].(string); ok && id != "" {
+			return withRequestID(ctx, id), id
+		}
+	}
+	if id := requestIDFromContext(ctx); id != "" {
+		return ctx, id
+	}
+
+	id := uuid.NewString()
+	return withRequestID(ctx, id), id
+}
+
+// requestIDContextFunc returns a transport context function that picks up the request
+// ID a client sent in header with its MCP message
+func requestIDContextFunc(header string) func(context.Context, *http.Request) context.Context {
+	return func(ctx context.Context, r *http.Request) context.Context {
+		if id := r.Header.Get(header); id != "" {
+			return withRequestID(ctx, id)
+		}
+		return ctx
+	}
+}
+
+// setRequestIDHeader sends the request ID of the call being served in header, unless the
+// request already sets it
+func setRequestIDHeader(ctx context.Context, req *http.Request, header string) {
+	if header == "" || req.Header.Get(header) != "" {
+		return
+	}
+	if id := requestIDFromContext(ctx); id != "" {
+		req.Header.Set(header, id)
+	}
+}
+
+// requestIDHeader returns the header request IDs are exchanged in
+func requestIDHeader(cfg *Config) string {
+	if cfg == nil || cfg.MCP == nil || cfg.MCP.RequestIDHeader == "" {
+		return defaultRequestIDHeader
+	}
+	return cfg.MCP.RequestIDHeader
+}
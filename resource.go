@@ -7,27 +7,55 @@ import (
 	"fmt"
 	"log/slog"
 	"net/http"
+	"net/url"
+	"strconv"
 	"strings"
+	"text/template"
+	"time"
 
 	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/yosida95/uritemplate/v3"
 )
 
 // HTTPResourceHandler handles resource requests by making HTTP requests
 type HTTPResourceHandler struct {
-	endpoint      *Endpoint
-	backend       *Backend
-	logger        *slog.Logger
-	clientManager *ClientManager
+	requestBuilder
+	logger          *slog.Logger
+	clientManager   *ClientManager
+	contentTemplate *template.Template
 }
 
 // NewHTTPResourceHandler creates a new HTTP resource handler
 func NewHTTPResourceHandler(endpoint *Endpoint, backend *Backend, logger *slog.Logger, clientManager *ClientManager) *HTTPResourceHandler {
-	return &HTTPResourceHandler{
-		endpoint:      endpoint,
-		backend:       backend,
-		logger:        logger,
-		clientManager: clientManager,
+	h := &HTTPResourceHandler{
+		requestBuilder: requestBuilder{endpoint: endpoint, backend: backend},
+		logger:         logger,
+		clientManager:  clientManager,
 	}
+
+	if endpoint.ContentTemplate != "" {
+		tmpl, err := parseContentTemplate(endpoint.Name, endpoint.ContentTemplate)
+		if err != nil {
+			logger.Warn("Invalid content template, returning raw responses",
+				"resource", endpoint.Name,
+				"error", err,
+			)
+		} else {
+			h.contentTemplate = tmpl
+		}
+	}
+
+	return h
+}
+
+// parseContentTemplate parses a resource content template
+func parseContentTemplate(name, text string) (*template.Template, error) {
+	return template.New(name).Funcs(template.FuncMap{
+		"json": func(v any) (string, error) {
+			data, err := json.MarshalIndent(v, "", "  ")
+			return string(data), err
+		},
+	}).Parse(text)
 }
 
 // CreateMCPResource creates an MCP resource from endpoint configuration
@@ -40,9 +68,10 @@ func (h *HTTPResourceHandler) CreateMCPResource() mcp.Resource {
 	)
 }
 
-// CreateMCPResourceTemplate creates an MCP resource template if the resource has path parameters
+// CreateMCPResourceTemplate creates an MCP resource template if the resource has dynamic
+// path parameters
 func (h *HTTPResourceHandler) CreateMCPResourceTemplate() *mcp.ResourceTemplate {
-	if len(h.endpoint.PathParameters) == 0 {
+	if len(dynamicParams(h.endpoint.PathParameters)) == 0 {
 		return nil // No template needed for static resources
 	}
 
@@ -63,10 +92,10 @@ func (h *HTTPResourceHandler) generateResourceURI() string {
 func (h *HTTPResourceHandler) generateResourceURITemplate() string {
 	uri := fmt.Sprintf("proxy://%s", h.endpoint.Name)
 
-	// Add path parameters to the URI template
-	if len(h.endpoint.PathParameters) > 0 {
+	// Add dynamic path parameters to the URI template
+	if pathParams := dynamicParams(h.endpoint.PathParameters); len(pathParams) > 0 {
 		var params []string
-		for _, param := range h.endpoint.PathParameters {
+		for _, param := range pathParams {
 			params = append(params, fmt.Sprintf("{%s}", param.Identifier))
 		}
 		uri += "/" + strings.Join(params, "/")
@@ -75,10 +104,41 @@ func (h *HTTPResourceHandler) generateResourceURITemplate() string {
 	return uri
 }
 
+// forRequest returns a copy of the handler that logs with a call's request ID
+func (h *HTTPResourceHandler) forRequest(requestID string) *HTTPResourceHandler {
+	call := *h
+	call.logger = h.logger.With("request_id", requestID)
+	return &call
+}
+
 // Handler handles resource read requests
 func (h *HTTPResourceHandler) Handler(ctx context.Context, req mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	// Tag logs and backend requests with the call's request ID
+	ctx, requestID := startRequest(ctx, nil)
+	h = h.forRequest(requestID)
+
 	// Extract parameters from URI for dynamic resources
-	arguments := h.extractArgumentsFromURI(req.Params.URI)
+	arguments, err := h.extractArgumentsFromURI(req.Params.URI)
+	if err != nil {
+		return nil, err
+	}
+
+	// Validate and coerce arguments before making any request
+	arguments, err = validateArguments(h.endpoint, arguments)
+	if err != nil {
+		return nil, err
+	}
+
+	// Let the endpoint's request transformer rewrite the arguments
+	arguments, err = h.transformArguments(ctx, arguments)
+	if err != nil {
+		return nil, err
+	}
+
+	// Serve the configured mock instead of calling the backend
+	if h.endpoint.MockResponse != nil {
+		return h.handleResponse(newMockHTTPResponse(h.endpoint.MockResponse), req.Params.URI)
+	}
 
 	// Build the URL with path parameters
 	url, err := h.buildURL(arguments)
@@ -87,7 +147,10 @@ func (h *HTTPResourceHandler) Handler(ctx context.Context, req mcp.ReadResourceR
 	}
 
 	// Build query parameters
-	queryParams := h.buildQueryParams(arguments)
+	queryParams, err := h.buildQueryParams(arguments)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build query parameters: %w", err)
+	}
 	if len(queryParams) > 0 {
 		url += "?" + queryParams
 	}
@@ -97,6 +160,29 @@ func (h *HTTPResourceHandler) Handler(ctx context.Context, req mcp.ReadResourceR
 	if err != nil {
 		return nil, fmt.Errorf("failed to build request body: %w", err)
 	}
+	if err := checkRequestSize(h.endpoint, body); err != nil {
+		return nil, fmt.Errorf("request body too large: %w", err)
+	}
+
+	// Apply the endpoint's retry, limit and idempotency settings to the call
+	ctx, _ = h.callContext(ctx)
+
+	// Follow pagination cursors when configured
+	if h.endpoint.Pagination != nil {
+		return h.handlePaginated(ctx, url, body, arguments, req.Params.URI)
+	}
+
+	// Bound the request, including reading the response, by the endpoint's response timeout.
+	// Streamed responses have no deadline and fail only when the backend goes quiet
+	var watch *idleWatch
+	if h.endpoint.Stream {
+		ctx, watch = withIdleTimeout(ctx, time.Duration(h.endpoint.IdleTimeout))
+		defer watch.stop()
+	} else {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(h.endpoint.ResponseTimeout))
+		defer cancel()
+	}
 
 	// Create HTTP request
 	httpReq, err := http.NewRequestWithContext(ctx, string(h.endpoint.Method), url, bytes.NewReader(body))
@@ -114,172 +200,153 @@ func (h *HTTPResourceHandler) Handler(ctx context.Context, req mcp.ReadResourceR
 	)
 
 	// Make the HTTP request using client manager
-	resp, err := h.clientManager.DoRequest(ctx, httpReq, h.endpoint.Name)
+	resp, err := h.clientManager.DoHedgedRequest(ctx, httpReq, h.endpoint.Name, time.Duration(h.endpoint.HedgeAfter))
 	if err != nil {
 		return nil, fmt.Errorf("HTTP request failed: %w", err)
 	}
 	defer resp.Body.Close()
 
-	// Handle response
-	return h.handleResponse(resp, req.Params.URI)
-}
-
-// extractArgumentsFromURI extracts parameters from the resource URI
-func (h *HTTPResourceHandler) extractArgumentsFromURI(uri string) map[string]any {
-	arguments := make(map[string]any)
-
-	// Simple URI parsing - in a real implementation you might want more sophisticated parsing
-	// For template URIs like "proxy://resource/{param1}/{param2}"
-	// This is a simplified implementation
-	parts := strings.Split(uri, "/")
-	if len(parts) > 2 {
-		// Extract path parameters based on template structure
-		templateParts := strings.Split(h.generateResourceURITemplate(), "/")
-		for i, part := range parts {
-			if i < len(templateParts) && strings.HasPrefix(templateParts[i], "{") && strings.HasSuffix(templateParts[i], "}") {
-				paramName := strings.Trim(templateParts[i], "{}")
-				arguments[paramName] = part
-			}
-		}
+	// Restart the idle timer as streamed chunks arrive
+	if watch != nil {
+		resp.Body = watch.wrap(resp.Body, nil)
 	}
 
-	return arguments
-}
-
-// buildURL constructs the full URL with path parameters substituted
-func (h *HTTPResourceHandler) buildURL(arguments map[string]any) (string, error) {
-	url := h.backend.BaseURL + h.endpoint.Path
-
-	// Replace path parameters
-	for _, param := range h.endpoint.PathParameters {
-		var value any
-		var exists bool
-
-		if param.ValueType == CONSTANT {
-			// Use the predefined value for constant parameters
-			value = param.Value
-			exists = param.Value != ""
-		} else {
-			// Use the value from arguments for dynamic parameters
-			value, exists = arguments[param.Identifier]
-		}
-
-		if !exists && param.Required {
-			return "", fmt.Errorf("required path parameter '%s' not provided", param.Identifier)
-		}
-		if exists {
-			placeholder := fmt.Sprintf("{%s}", param.Identifier)
-			url = strings.ReplaceAll(url, placeholder, fmt.Sprintf("%v", value))
-		}
+	// Let the endpoint's response transformer rewrite the response
+	if err := h.transformResponseBody(ctx, resp); err != nil {
+		return nil, err
 	}
 
-	return url, nil
+	// Handle response
+	return h.handleResponse(resp, req.Params.URI)
 }
 
-// buildQueryParams constructs query parameters from arguments
-func (h *HTTPResourceHandler) buildQueryParams(arguments map[string]any) string {
-	var params []string
-
-	for _, param := range h.endpoint.QueryParameters {
-		var value any
-		var exists bool
-
-		if param.ValueType == CONSTANT {
-			// Use the predefined value for constant parameters
-			value = param.Value
-			exists = param.Value != ""
-		} else {
-			// Use the value from arguments for dynamic parameters
-			value, exists = arguments[param.Identifier]
-		}
-
-		if exists {
-			params = append(params, fmt.Sprintf("%s=%v", param.Identifier, value))
-		}
-	}
-
-	return strings.Join(params, "&")
-}
+// extractArgumentsFromURI extracts path parameters by matching the resource URI
+// against the endpoint's RFC 6570 URI template. Captured values are taken from the
+// raw URI and unescaped once, so encoded slashes survive as part of a single value.
+func (h *HTTPResourceHandler) extractArgumentsFromURI(uri string) (map[string]any, error) {
+	arguments := make(map[string]any)
 
-// buildRequestBody constructs the JSON request body
-func (h *HTTPResourceHandler) buildRequestBody(arguments map[string]any) ([]byte, error) {
-	if len(h.endpoint.BodyParams) == 0 {
-		return nil, nil
+	if len(dynamicParams(h.endpoint.PathParameters)) == 0 {
+		return arguments, nil
 	}
 
-	body := make(map[string]any)
-	for _, param := range h.endpoint.BodyParams {
-		var value any
-		var exists bool
-
-		if param.ValueType == CONSTANT {
-			// Use the predefined value for constant parameters
-			value = param.Value
-			exists = param.Value != ""
-		} else {
-			// Use the value from arguments for dynamic parameters
-			value, exists = arguments[param.Identifier]
-		}
-
-		if exists {
-			body[param.Identifier] = value
-		} else if param.Required {
-			return nil, fmt.Errorf("required body parameter '%s' not provided", param.Identifier)
-		}
+	template, err := uritemplate.New(h.generateResourceURITemplate())
+	if err != nil {
+		return nil, fmt.Errorf("invalid resource URI template: %w", err)
 	}
 
-	if len(body) == 0 {
-		return nil, nil
+	matches := template.Regexp().FindStringSubmatch(uri)
+	if matches == nil {
+		return nil, fmt.Errorf("resource URI '%s' does not match template '%s'", uri, template.Raw())
 	}
 
-	return json.Marshal(body)
-}
+	// Capture groups follow the order of the template's variables
+	for i, name := range template.Varnames() {
+		if i+1 >= len(matches) || matches[i+1] == "" {
+			continue
+		}
 
-// addHeaders adds headers to the HTTP request
-func (h *HTTPResourceHandler) addHeaders(req *http.Request, arguments map[string]any) {
-	// Add default headers from backend
-	for _, header := range h.backend.DefaultHeaders {
-		req.Header.Set(header.Name, header.Value)
-	}
-
-	// Add endpoint-specific headers
-	for _, header := range h.endpoint.Headers {
-		if header.Type == CONSTANT {
-			req.Header.Set(header.Name, header.Value)
-		} else if header.Type == DYNAMIC {
-			// For dynamic headers, try to get value from arguments
-			if value, exists := arguments[header.Name]; exists {
-				req.Header.Set(header.Name, fmt.Sprintf("%v", value))
-			}
+		value, err := url.PathUnescape(matches[i+1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid escaping in resource URI parameter '%s': %w", name, err)
 		}
+		arguments[name] = value
 	}
 
-	// Set content type for JSON if we have body parameters
-	if len(h.endpoint.BodyParams) > 0 {
-		req.Header.Set("Content-Type", "application/json")
-	}
+	return arguments, nil
 }
 
 // handleResponse processes the HTTP response and returns MCP resource contents
 func (h *HTTPResourceHandler) handleResponse(resp *http.Response, uri string) ([]mcp.ResourceContents, error) {
 	// Read response body
 	var responseBody bytes.Buffer
-	if _, err := responseBody.ReadFrom(resp.Body); err != nil {
+	if _, err := responseBody.ReadFrom(limitBody(resp.Body, h.endpoint.MaxResponseBytes)); err != nil {
 		return nil, fmt.Errorf("failed to read response body: %w", err)
 	}
 
 	responseText := responseBody.String()
 
 	// Check if the request was successful
-	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+	if h.endpoint.isSuccessStatus(resp.StatusCode) {
 		h.logger.Debug("Resource request successful",
 			"resource", h.endpoint.Name,
 			"status", resp.StatusCode,
 		)
 
+		// HEAD and OPTIONS responses carry their information in the status and headers
+		if responseBody.Len() == 0 && isHeaderOnlyMethod(h.endpoint.Method) {
+			return []mcp.ResourceContents{
+				mcp.TextResourceContents{
+					URI:      uri,
+					MIMEType: "application/json",
+					Text:     string(summarizeHeaderResponse(resp)),
+				},
+			}, nil
+		}
+
+		// Return binary data as a base64 blob so it isn't corrupted
+		contentType := resp.Header.Get("Content-Type")
+		if h.endpoint.Binary || isBinaryContentType(contentType) {
+			return []mcp.ResourceContents{
+				newBlobResourceContents(uri, contentType, responseBody.Bytes()),
+			}, nil
+		}
+
+		// Convert XML and CSV responses to JSON
+		body := responseBody.Bytes()
+		format := detectResponseFormat(h.endpoint.ResponseFormat, contentType)
+		if converted, ok := convertResponseBody(format, body); ok {
+			body = converted
+			responseText = string(converted)
+		} else if format == XML || format == CSV {
+			h.logger.Warn("Failed to convert response, returning raw response",
+				"resource", h.endpoint.Name,
+				"format", format,
+			)
+			return []mcp.ResourceContents{
+				mcp.TextResourceContents{
+					URI:      uri,
+					MIMEType: mimeTypeForFormat(format),
+					Text:     responseText,
+				},
+			}, nil
+		}
+
+		// Reshape JSON responses with the configured jq expression
+		if h.endpoint.ResponseJQ != "" && format != TEXT {
+			if transformed, err := applyJQ(h.endpoint.ResponseJQ, body); err == nil {
+				body = transformed
+				responseText = string(transformed)
+			} else {
+				h.logger.Warn("Failed to apply response_jq, returning full response",
+					"resource", h.endpoint.Name,
+					"error", err,
+				)
+			}
+		}
+
 		// Try to determine if response is JSON
 		var jsonData interface{}
-		if json.Unmarshal(responseBody.Bytes(), &jsonData) == nil {
+		if format != TEXT && json.Unmarshal(body, &jsonData) == nil {
+			// Render through the content template when configured
+			if h.contentTemplate != nil {
+				var rendered bytes.Buffer
+				if err := h.contentTemplate.Execute(&rendered, jsonData); err == nil {
+					return []mcp.ResourceContents{
+						mcp.TextResourceContents{
+							URI:      uri,
+							MIMEType: "text/markdown",
+							Text:     rendered.String(),
+						},
+					}, nil
+				} else {
+					h.logger.Warn("Failed to render content template, returning raw response",
+						"resource", h.endpoint.Name,
+						"error", err,
+					)
+				}
+			}
+
 			// Response is valid JSON, return as JSON
 			return []mcp.ResourceContents{
 				mcp.TextResourceContents{
@@ -308,3 +375,130 @@ func (h *HTTPResourceHandler) handleResponse(resp *http.Response, uri string) ([
 		return nil, fmt.Errorf("resource request failed with status %d: %s", resp.StatusCode, responseText)
 	}
 }
+
+// handlePaginated follows the backend's next-page cursor and concatenates all pages
+// into a single JSON array. Pages are fetched until the cursor runs out, max_pages is
+// reached, or the response timeout expires. If a page fails after at least one page
+// was collected, the partial result is returned and a warning is logged.
+func (h *HTTPResourceHandler) handlePaginated(ctx context.Context, baseURL string, body []byte, arguments map[string]any, uri string) ([]mcp.ResourceContents, error) {
+	pagination := h.endpoint.Pagination
+	maxPages := pagination.MaxPages
+	if maxPages == 0 {
+		maxPages = 10
+	}
+
+	// Bound the whole pagination loop by the endpoint's response timeout
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(h.endpoint.ResponseTimeout))
+	defer cancel()
+
+	items := make([]any, 0)
+	cursor := ""
+
+	for page := 0; page < maxPages; page++ {
+		pageURL := baseURL
+		if cursor != "" {
+			separator := "?"
+			if strings.Contains(pageURL, "?") {
+				separator = "&"
+			}
+			pageURL += separator + url.QueryEscape(pagination.PageParam) + "=" + url.QueryEscape(cursor)
+		}
+
+		pageItems, next, err := h.fetchPage(ctx, pageURL, body, arguments)
+		if err != nil {
+			if page == 0 {
+				return nil, err
+			}
+			h.logger.Warn("Pagination stopped early, returning collected pages",
+				"resource", h.endpoint.Name,
+				"pages", page,
+				"error", err,
+			)
+			break
+		}
+
+		items = append(items, pageItems...)
+
+		if next == "" {
+			break
+		}
+		if page == maxPages-1 {
+			h.logger.Warn("Pagination reached max_pages, results may be incomplete",
+				"resource", h.endpoint.Name,
+				"max_pages", maxPages,
+			)
+		}
+		cursor = next
+	}
+
+	data, err := json.Marshal(items)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode aggregated pages: %w", err)
+	}
+
+	return []mcp.ResourceContents{
+		mcp.TextResourceContents{
+			URI:      uri,
+			MIMEType: "application/json",
+			Text:     string(data),
+		},
+	}, nil
+}
+
+// fetchPage fetches a single page and returns its items and the next cursor
+func (h *HTTPResourceHandler) fetchPage(ctx context.Context, pageURL string, body []byte, arguments map[string]any) ([]any, string, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, string(h.endpoint.Method), pageURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create HTTP request: %w", err)
+	}
+
+	h.addHeaders(httpReq, arguments)
+
+	h.logger.Debug("Fetching resource page",
+		"resource", h.endpoint.Name,
+		"url", pageURL,
+	)
+
+	resp, err := h.clientManager.DoHedgedRequest(ctx, httpReq, h.endpoint.Name, time.Duration(h.endpoint.HedgeAfter))
+	if err != nil {
+		return nil, "", fmt.Errorf("HTTP request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var responseBody bytes.Buffer
+	if _, err := responseBody.ReadFrom(limitBody(resp.Body, h.endpoint.MaxResponseBytes)); err != nil {
+		return nil, "", fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	if !h.endpoint.isSuccessStatus(resp.StatusCode) {
+		return nil, "", fmt.Errorf("resource request failed with status %d: %s", resp.StatusCode, responseBody.String())
+	}
+
+	var data any
+	if err := json.Unmarshal(responseBody.Bytes(), &data); err != nil {
+		return nil, "", fmt.Errorf("paginated response is not valid JSON: %w", err)
+	}
+
+	rawItems, found := lookupJSONPath(data, h.endpoint.Pagination.ItemsPath)
+	if !found {
+		return nil, "", fmt.Errorf("items path '%s' not found in response", h.endpoint.Pagination.ItemsPath)
+	}
+	pageItems, ok := rawItems.([]any)
+	if !ok {
+		return nil, "", fmt.Errorf("items path '%s' is not an array", h.endpoint.Pagination.ItemsPath)
+	}
+
+	next := ""
+	if cursor, found := lookupJSONPath(data, h.endpoint.Pagination.CursorPath); found && cursor != nil {
+		switch v := cursor.(type) {
+		case string:
+			next = v
+		case float64:
+			next = strconv.FormatFloat(v, 'f', -1, 64)
+		default:
+			next = fmt.Sprintf("%v", v)
+		}
+	}
+
+	return pageItems, next, nil
+}
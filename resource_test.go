@@ -0,0 +1,127 @@
+package proxy
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+func TestExtractArgumentsFromURI(t *testing.T) {
+	endpoint := &Endpoint{
+		Name: "user_orders",
+		PathParameters: []*Param{
+			{Identifier: "user_id", ValueType: DYNAMIC},
+			{Identifier: "order_id", ValueType: DYNAMIC},
+		},
+	}
+	h := NewHTTPResourceHandler(endpoint, &Backend{}, discardLogger(), NewClientManager())
+
+	tests := []struct {
+		uri     string
+		want    map[string]any
+		wantErr bool
+	}{
+		{uri: "proxy://user_orders/42/1001", want: map[string]any{"user_id": "42", "order_id": "1001"}},
+		{uri: "proxy://user_orders/a%2Fb/1001", want: map[string]any{"user_id": "a/b", "order_id": "1001"}},
+		{uri: "proxy://user_orders/j%C3%BCrgen/x%20y", want: map[string]any{"user_id": "jürgen", "order_id": "x y"}},
+		{uri: "proxy://other/42/1001", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.uri, func(t *testing.T) {
+			got, err := h.extractArgumentsFromURI(tt.uri)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("got %v, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadTwoParameterResourceTemplate(t *testing.T) {
+	var gotPath string
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.EscapedPath()
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"ok":true}`))
+	}))
+	defer backend.Close()
+
+	s := newTestProxy(t, fmt.Sprintf(`
+mcp: {}
+backends:
+  - base_url: %s
+    endpoints:
+      - name: user_orders
+        capability: resource
+        method: GET
+        path: /users/{user_id}/orders/{order_id}
+        path_parameters:
+          - identifier: user_id
+            data_type: string
+            value_type: dynamic
+            required: true
+          - identifier: order_id
+            data_type: string
+            value_type: dynamic
+            required: true
+`, backend.URL))
+
+	result, err := s.ReadResource(context.Background(), "proxy://user_orders/a%2Fb/1001")
+	if err != nil {
+		t.Fatalf("ReadResource failed: %v", err)
+	}
+	contents, ok := result.Contents[0].(mcp.TextResourceContents)
+	if !ok || !strings.Contains(contents.Text, `"ok":true`) {
+		t.Errorf("ReadResource returned %v, want the backend response", result.Contents)
+	}
+
+	// The encoded slash stays part of the user ID rather than adding a path segment
+	if gotPath != "/users/a%2Fb/orders/1001" {
+		t.Errorf("backend got path %q, want /users/a%%2Fb/orders/1001", gotPath)
+	}
+}
+
+func TestAddResourceTemplateWithResourceHandler(t *testing.T) {
+	cfg, err := ParseConfigFromBytes([]byte(fmt.Sprintf(callsConfig, "http://localhost")))
+	if err != nil {
+		t.Fatalf("failed to parse config: %v", err)
+	}
+	s, err := NewServerFromConfig(cfg, WithLogger(discardLogger()))
+	if err != nil {
+		t.Fatalf("failed to create proxy: %v", err)
+	}
+	defer s.Close()
+
+	// Handlers typed as resource handlers, as before templates were served as templates
+	var handler server.ResourceHandlerFunc = func(ctx context.Context, req mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+		return []mcp.ResourceContents{mcp.TextResourceContents{URI: req.Params.URI, Text: "note " + req.Params.URI}}, nil
+	}
+	s.AddResourceTemplate(mcp.NewResourceTemplate("notes://{id}", "notes"), handler)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	s.newMCPServer(ctx)
+
+	result, err := s.ReadResource(ctx, "notes://7")
+	if err != nil {
+		t.Fatalf("ReadResource failed: %v", err)
+	}
+	if contents, ok := result.Contents[0].(mcp.TextResourceContents); !ok || contents.Text != "note notes://7" {
+		t.Errorf("ReadResource returned %v, want the template handler's contents", result.Contents)
+	}
+}
@@ -0,0 +1,135 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+)
+
+// retryOnBodyMaxBytes is how much of a response body is read to check it against
+// retry_on_body. Longer bodies are checked by their first part.
+const retryOnBodyMaxBytes = 1 << 20
+
+// errRetryOnBody reports a response whose body matched the endpoint's retry_on_body
+type errRetryOnBody struct {
+	status int
+	body   string
+}
+
+func (e *errRetryOnBody) Error() string {
+	return fmt.Sprintf("backend returned status %d with an error body: %s", e.status, e.body)
+}
+
+// validate checks that the rule has something to match and that its pattern compiles
+func (r *RetryOnBody) validate() error {
+	if r.Path == "" && r.Pattern == "" {
+		return fmt.Errorf("retry_on_body requires path or pattern")
+	}
+	if r.Value != nil && r.Path == "" {
+		return fmt.Errorf("retry_on_body value requires path")
+	}
+	if r.Value != nil && r.Pattern != "" {
+		return fmt.Errorf("retry_on_body can't set both value and pattern")
+	}
+	if _, err := r.compiledPattern(); err != nil {
+		return fmt.Errorf("invalid retry_on_body pattern: %w", err)
+	}
+	return nil
+}
+
+// compiledPattern returns Pattern compiled, compiling it on the first call
+func (r *RetryOnBody) compiledPattern() (*regexp.Regexp, error) {
+	r.compileOnce.Do(func() {
+		r.pattern, r.patternErr = regexp.Compile(r.Pattern)
+	})
+	return r.pattern, r.patternErr
+}
+
+// matches reports whether a response body is a failure by the rule. With a path, the
+// JSON value there must equal value or match pattern, or merely exist when neither is
+// set. Without a path, pattern is matched against the whole body.
+func (r *RetryOnBody) matches(body []byte) bool {
+	pattern, err := r.compiledPattern()
+	if err != nil {
+		return false
+	}
+	if r.Path == "" {
+		return pattern.Match(body)
+	}
+
+	var data any
+	if err := json.Unmarshal(body, &data); err != nil {
+		return false
+	}
+	found, ok := lookupJSONPath(data, r.Path)
+	if !ok {
+		return false
+	}
+
+	if r.Value != nil {
+		// Compare encoded values so 429 from YAML equals 429 from JSON
+		want, err := json.Marshal(r.Value)
+		if err != nil {
+			return false
+		}
+		got, _ := json.Marshal(found)
+		return bytes.Equal(got, want)
+	}
+	if r.Pattern != "" {
+		if s, ok := found.(string); ok {
+			return pattern.MatchString(s)
+		}
+		encoded, _ := json.Marshal(found)
+		return pattern.Match(encoded)
+	}
+	return true
+}
+
+type retryOnBodyKey struct{}
+
+// withRetryOnBody returns a context whose successful responses are checked against rule
+// and, when they match, retried and finally failed like a 5xx response
+func withRetryOnBody(ctx context.Context, rule *RetryOnBody) context.Context {
+	if rule == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, retryOnBodyKey{}, rule)
+}
+
+// checkRetryOnBody returns an errRetryOnBody when resp's body matches the retry_on_body
+// rule of ctx. A compressed body is decoded first, so the rule sees what the handler
+// would. The body read for the check is put back so the response can be read as usual
+// when it doesn't match.
+func checkRetryOnBody(ctx context.Context, resp *http.Response) error {
+	rule, _ := ctx.Value(retryOnBodyKey{}).(*RetryOnBody)
+	if rule == nil {
+		return nil
+	}
+
+	if err := decodeContentEncoding(resp); err != nil {
+		return err
+	}
+
+	head, err := io.ReadAll(io.LimitReader(resp.Body, retryOnBodyMaxBytes))
+	if err != nil {
+		return fmt.Errorf("failed to read response body: %w", err)
+	}
+	resp.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(head), resp.Body), resp.Body}
+
+	if !rule.matches(head) {
+		return nil
+	}
+
+	body := string(head)
+	if len(body) > 200 {
+		body = body[:200] + "..."
+	}
+	return &errRetryOnBody{status: resp.StatusCode, body: body}
+}
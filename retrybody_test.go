@@ -0,0 +1,113 @@
+package proxy
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestRetryOnCompressedBody(t *testing.T) {
+	var attempts atomic.Int64
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if attempts.Add(1) == 1 {
+			// The soft error only matches once the body is decoded
+			w.Header().Set("Content-Encoding", "br")
+			bw := brotli.NewWriter(w)
+			fmt.Fprint(bw, `{"error":{"code":"busy"}}`)
+			bw.Close()
+			return
+		}
+		fmt.Fprint(w, `{"status":"ok"}`)
+	}))
+	defer backend.Close()
+
+	s := newTestProxy(t, fmt.Sprintf(`
+backends:
+  - base_url: %s
+    endpoints:
+      - name: status
+        capability: tool
+        mode: client
+        method: GET
+        path: /status
+        retry_on_body:
+          path: error.code
+          pattern: "^busy$"
+`, backend.URL))
+
+	text, isError := toolText(t, s, "status", nil)
+	if isError || !strings.Contains(text, `"status":"ok"`) {
+		t.Errorf("status returned %q (error %v), want the retried response", text, isError)
+	}
+	if got := attempts.Load(); got != 2 {
+		t.Errorf("backend received %d requests, want 2", got)
+	}
+}
+
+func TestRetryOnBodyForEveryHandler(t *testing.T) {
+	var attempts atomic.Int64
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if attempts.Add(1)%2 == 1 {
+			fmt.Fprint(w, `{"error":{"code":"busy"}}`)
+			return
+		}
+		fmt.Fprint(w, `{"data":{"status":"ok"}}`)
+	}))
+	defer backend.Close()
+
+	s := newTestProxy(t, fmt.Sprintf(`
+mcp: {}
+backends:
+  - base_url: %[1]s
+    endpoints:
+      - name: status
+        capability: resource
+        method: GET
+        path: /status
+        retry_on_body:
+          path: error.code
+          pattern: "^busy$"
+  - type: graphql
+    base_url: %[1]s/graphql
+    endpoints:
+      - name: graphql_status
+        capability: tool
+        mode: client
+        query: "{ status }"
+        retry_non_idempotent: true
+        retry_on_body:
+          path: error.code
+          pattern: "^busy$"
+`, backend.URL))
+
+	attempts.Store(0)
+	resource, err := s.ReadResource(context.Background(), "proxy://status")
+	if err != nil {
+		t.Fatalf("ReadResource failed: %v", err)
+	}
+	contents, ok := resource.Contents[0].(mcp.TextResourceContents)
+	if !ok || !strings.Contains(contents.Text, `"status":"ok"`) {
+		t.Errorf("ReadResource returned %v, want the retried response", resource.Contents)
+	}
+	if got := attempts.Load(); got != 2 {
+		t.Errorf("resource backend received %d requests, want 2", got)
+	}
+
+	attempts.Store(0)
+	text, isError := toolText(t, s, "graphql_status", nil)
+	if isError || !strings.Contains(text, `"status":"ok"`) {
+		t.Errorf("graphql_status returned %q (error %v), want the retried response", text, isError)
+	}
+	if got := attempts.Load(); got != 2 {
+		t.Errorf("graphql backend received %d requests, want 2", got)
+	}
+}
@@ -0,0 +1,64 @@
+package proxy
+
+import (
+	"context"
+	"sync"
+)
+
+// Retry budget defaults. Each request earns retryBudgetRatio of a retry and each retry
+// spends a whole one, so retries settle at about 10% of a backend's requests once the
+// initial burst of retryBudgetMax is used up.
+const (
+	retryBudgetRatio = 0.1
+	retryBudgetMax   = 10
+)
+
+// retryBudget is a token bucket shared by all requests to one backend. It throttles
+// retries during widespread failures, when per-request retries would multiply the load
+// on a struggling backend, while leaving occasional retries unaffected.
+type retryBudget struct {
+	mu     sync.Mutex
+	tokens float64
+}
+
+// newRetryBudget creates a budget that starts full
+func newRetryBudget() *retryBudget {
+	return &retryBudget{tokens: retryBudgetMax}
+}
+
+// deposit credits the budget for a request sent for the first time
+func (b *retryBudget) deposit() {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.tokens = min(b.tokens+retryBudgetRatio, retryBudgetMax)
+}
+
+// withdraw spends a token for a retry, reporting false when the budget is exhausted
+func (b *retryBudget) withdraw() bool {
+	if b == nil {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+type retryBudgetKey struct{}
+
+// withRetryBudget returns a context whose requests draw their retries from budget
+func withRetryBudget(ctx context.Context, budget *retryBudget) context.Context {
+	return context.WithValue(ctx, retryBudgetKey{}, budget)
+}
+
+// retryBudgetFromContext returns the retry budget of a context, or nil if it has none
+func retryBudgetFromContext(ctx context.Context) *retryBudget {
+	budget, _ := ctx.Value(retryBudgetKey{}).(*retryBudget)
+	return budget
+}
@@ -0,0 +1,130 @@
+package proxy
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// schemaEnums lists the values accepted for the config's string types
+var schemaEnums = map[reflect.Type][]string{
+	reflect.TypeOf(Capability("")):     {string(TOOL), string(RESOURCE), string(PROMPT)},
+	reflect.TypeOf(Mode("")):           {string(WEBHOOK), string(CLIENT)},
+	reflect.TypeOf(Value("")):          {string(DYNAMIC), string(CONSTANT)},
+	reflect.TypeOf(Method("")):         {string(GET), string(POST), string(PUT), string(PATCH), string(DELETE), string(HEAD), string(OPTIONS), string(UPDATE)},
+	reflect.TypeOf(Data("")):           {"string", "number", "integer", "boolean", "object", "array", string(RAW_BODY)},
+	reflect.TypeOf(ResponseFormat("")): {string(JSON), string(XML), string(CSV), string(TEXT)},
+	reflect.TypeOf(QueryStyle("")):     {string(REPEAT), string(COMMA_SEPARATED)},
+	reflect.TypeOf(ResultAs("")):       {string(RESULT_TEXT), string(RESULT_JSON), string(RESULT_RESOURCE)},
+	reflect.TypeOf(BackendType("")):    {string(HTTP), string(GRAPHQL), string(GRPC)},
+	reflect.TypeOf(RedirectPolicy("")): {string(REDIRECT_SAME_HOST), string(REDIRECT_FOLLOW), string(REDIRECT_NONE)},
+}
+
+// schemaRequired lists the fields a config must set for each type, as enforced by
+// validateParsedConfig
+var schemaRequired = map[reflect.Type][]string{
+	reflect.TypeOf(Config{}):      {"backends"},
+	reflect.TypeOf(Endpoint{}):    {"name", "capability"},
+	reflect.TypeOf(Step{}):        {"name", "path"},
+	reflect.TypeOf(Login{}):       {"path"},
+	reflect.TypeOf(Passthrough{}): {"allowed_paths"},
+}
+
+var configJSONSchema = sync.OnceValue(func() []byte {
+	defs := make(map[string]any)
+	root := structSchema(reflect.TypeOf(Config{}), defs)
+	root["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	root["title"] = "MCP Proxy configuration"
+	root["$defs"] = defs
+
+	// Allow extension keys at the top level for YAML anchors, e.g. "x-auth: &auth"
+	root["patternProperties"] = map[string]any{"^x-": map[string]any{}}
+
+	schema, err := json.MarshalIndent(root, "", "  ")
+	if err != nil {
+		panic(err)
+	}
+	return schema
+})
+
+// ConfigJSONSchema returns a JSON Schema describing the configuration file, for editor
+// completion and for validating configs in CI. It lists the valid capabilities, modes,
+// value types, methods and other enumerated values.
+func ConfigJSONSchema() []byte {
+	return configJSONSchema()
+}
+
+// typeSchema returns the schema of a config field type. Structs are added to defs and
+// referenced by name.
+func typeSchema(t reflect.Type, defs map[string]any) map[string]any {
+	if values, ok := schemaEnums[t]; ok {
+		return map[string]any{"type": "string", "enum": values}
+	}
+
+	// Durations accept "30s" style strings or a number of seconds
+	if t == reflect.TypeOf(Duration(0)) {
+		return map[string]any{"type": []string{"string", "number"}}
+	}
+
+	// Status ranges accept a code, a "2xx" class or a "200-299" range
+	if t == reflect.TypeOf(StatusRange{}) {
+		return map[string]any{"type": []string{"string", "integer"}}
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		return typeSchema(t.Elem(), defs)
+	case reflect.Struct:
+		if _, ok := defs[t.Name()]; !ok {
+			defs[t.Name()] = nil // Placeholder for recursive types
+			defs[t.Name()] = structSchema(t, defs)
+		}
+		return map[string]any{"$ref": "#/$defs/" + t.Name()}
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": "array", "items": typeSchema(t.Elem(), defs)}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": typeSchema(t.Elem(), defs)}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	default:
+		return map[string]any{}
+	}
+}
+
+// structSchema describes a struct as an object with one property per JSON field.
+// Unknown properties are rejected so that misspelled keys are caught.
+func structSchema(t reflect.Type, defs map[string]any) map[string]any {
+	properties := make(map[string]any)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		properties[name] = typeSchema(field.Type, defs)
+	}
+
+	schema := map[string]any{
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+	if required, ok := schemaRequired[t]; ok {
+		schema["required"] = required
+	}
+	return schema
+}
@@ -0,0 +1,173 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+)
+
+// Login describes the request that establishes a session with a backend. The session
+// cookies it sets are kept in the backend's cookie jar and sent on later requests.
+type Login struct {
+	// Method is the HTTP method of the login request. Default: POST
+	Method Method `json:"method,omitempty" yaml:"method,omitempty"`
+
+	// Path is appended to the backend's BaseURL, e.g. "/auth/login"
+	Path string `json:"path" yaml:"path"`
+
+	// Body is sent as the JSON request body, e.g. '{"user": "${API_USER}", "password": "${API_PASSWORD}"}'
+	// Environment variables are expanded
+	Body string `json:"body,omitempty" yaml:"body,omitempty"`
+
+	// Headers are added to the login request after the backend's constant default headers.
+	// Global default headers are sent unless these or the backend's headers override them
+	Headers []*Header `json:"headers,omitempty" yaml:"headers,omitempty"`
+}
+
+// backendSession logs in to a backend once and again whenever a request is rejected
+// with 401. Concurrent requests rejected by the same expired session log in once.
+type backendSession struct {
+	login    *Login
+	baseURL  string
+	headers  []*Header
+	defaults http.Header
+	client   *http.Client
+
+	mu         sync.Mutex
+	generation int
+	loggedIn   bool
+}
+
+// newSessionClient creates a client with a cookie jar for a backend, which logs in with
+// the backend's login request when one is configured
+func newSessionClient(config *ClientConfig, backend *Backend) *HTTPClient {
+	sessionConfig := *config
+	sessionConfig.CookieJar = true
+
+	client := NewHTTPClient(&sessionConfig)
+	if backend.Login != nil {
+		client.session = &backendSession{
+			login:    backend.Login,
+			baseURL:  backend.BaseURL,
+			headers:  backend.DefaultHeaders,
+			defaults: sessionConfig.DefaultHeaders,
+			client:   client.client,
+		}
+	}
+
+	return client
+}
+
+// ensure logs in if no session has been established yet and returns the generation
+// of the current session
+func (s *backendSession) ensure(ctx context.Context) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.loggedIn {
+		if err := s.doLogin(ctx); err != nil {
+			return 0, err
+		}
+	}
+	return s.generation, nil
+}
+
+// refresh logs in again unless another request already did since generation
+func (s *backendSession) refresh(ctx context.Context, generation int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.loggedIn && s.generation != generation {
+		return nil
+	}
+	return s.doLogin(ctx)
+}
+
+// doLogin sends the login request. The caller must hold s.mu.
+func (s *backendSession) doLogin(ctx context.Context) error {
+	s.loggedIn = false
+
+	method := s.login.Method
+	if method == "" {
+		method = POST
+	}
+
+	var body io.Reader
+	if s.login.Body != "" {
+		body = bytes.NewReader([]byte(s.login.Body))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, string(method), s.baseURL+s.login.Path, body)
+	if err != nil {
+		return fmt.Errorf("failed to create login request: %w", err)
+	}
+
+	if s.login.Body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for _, headers := range [][]*Header{s.headers, s.login.Headers} {
+		for _, header := range headers {
+			if header.Type == CONSTANT {
+				req.Header.Set(header.Name, header.Value)
+			}
+		}
+	}
+	setDefaultHeaders(req, s.defaults)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("login request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("login failed with status %d", resp.StatusCode)
+	}
+
+	s.generation++
+	s.loggedIn = true
+	return nil
+}
+
+// doWithSession sends the request within the client's backend session, logging in
+// first if needed. A 401 response triggers one login and resend of the request.
+func (c *HTTPClient) doWithSession(ctx context.Context, req *http.Request) (*http.Response, error) {
+	if c.session == nil {
+		return c.doWithRetries(ctx, req)
+	}
+
+	generation, err := c.session.ensure(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// The client adds jar cookies to the request's own headers, so keep the original
+	// headers to resend with the new session's cookies
+	header := req.Header.Clone()
+
+	resp, err := c.doWithRetries(ctx, req)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+
+	// The session may have expired; log in again and resend once
+	if req.Body != nil && req.GetBody == nil {
+		return resp, nil
+	}
+	resp.Body.Close()
+
+	if err := c.session.refresh(ctx, generation); err != nil {
+		return nil, err
+	}
+
+	retry, err := cloneRequest(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	retry.Header = header
+	return c.doWithRetries(ctx, retry)
+}
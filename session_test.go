@@ -0,0 +1,120 @@
+package proxy
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// sessionBackend issues a new session cookie on each login and accepts only the latest
+type sessionBackend struct {
+	*httptest.Server
+
+	mu        sync.Mutex
+	logins    int
+	requests  int
+	valid     string
+	rejectAll bool
+}
+
+func newSessionBackend(t *testing.T) *sessionBackend {
+	backend := &sessionBackend{}
+	backend.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		backend.mu.Lock()
+		defer backend.mu.Unlock()
+
+		if r.URL.Path == "/login" {
+			backend.logins++
+			backend.valid = fmt.Sprintf("session-%d", backend.logins)
+			http.SetCookie(w, &http.Cookie{Name: "session", Value: backend.valid, Path: "/"})
+			return
+		}
+
+		backend.requests++
+		cookie, err := r.Cookie("session")
+		if err != nil || backend.rejectAll || cookie.Value != backend.valid {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"session":%q}`, cookie.Value)
+	}))
+	t.Cleanup(backend.Close)
+	return backend
+}
+
+// expire invalidates the current session, as a backend does when it times out
+func (b *sessionBackend) expire() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.valid = ""
+}
+
+// reject refuses every session, as for a revoked account
+func (b *sessionBackend) reject() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.rejectAll = true
+}
+
+// counts returns the logins and other requests served so far
+func (b *sessionBackend) counts() (int, int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.logins, b.requests
+}
+
+func TestBackendSessionLogsInAgainOn401(t *testing.T) {
+	backend := newSessionBackend(t)
+	s := newTestProxy(t, fmt.Sprintf(`
+backends:
+  - base_url: %s
+    login:
+      path: /login
+      body: '{"user": "proxy"}'
+    endpoints:
+      - name: get_data
+        capability: tool
+        mode: client
+        method: GET
+        path: /data
+`, backend.URL))
+
+	text, isError := toolText(t, s, "get_data", nil)
+	if isError || !strings.Contains(text, "session-1") {
+		t.Fatalf("get_data returned %q (error %v), want a response within the first session", text, isError)
+	}
+	if logins, requests := backend.counts(); logins != 1 || requests != 1 {
+		t.Errorf("after the first call: %d logins and %d requests, want 1 and 1", logins, requests)
+	}
+
+	// The expired session is rejected once, then the proxy logs in and resends
+	backend.expire()
+	text, isError = toolText(t, s, "get_data", nil)
+	if isError || !strings.Contains(text, "session-2") {
+		t.Fatalf("get_data returned %q (error %v), want a response within a new session", text, isError)
+	}
+	if logins, requests := backend.counts(); logins != 2 || requests != 3 {
+		t.Errorf("after the session expired: %d logins and %d requests, want 2 and 3", logins, requests)
+	}
+
+	// Later calls reuse the new session without logging in
+	if text, isError = toolText(t, s, "get_data", nil); isError {
+		t.Fatalf("get_data failed: %s", text)
+	}
+	if logins, requests := backend.counts(); logins != 2 || requests != 4 {
+		t.Errorf("after reusing the session: %d logins and %d requests, want 2 and 4", logins, requests)
+	}
+
+	// A request rejected again after logging in returns the 401 instead of retrying
+	backend.reject()
+	if text, isError = toolText(t, s, "get_data", nil); !isError || !strings.Contains(text, "401") {
+		t.Errorf("get_data returned %q (error %v), want the backend's 401", text, isError)
+	}
+	if logins, requests := backend.counts(); logins != 3 || requests != 6 {
+		t.Errorf("after the session was rejected: %d logins and %d requests, want 3 and 6", logins, requests)
+	}
+}
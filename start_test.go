@@ -0,0 +1,139 @@
+package proxy
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"sync"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// freeAddr returns a loopback address with a port nothing is listening on
+func freeAddr(t *testing.T) string {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to find a free port: %v", err)
+	}
+	defer listener.Close()
+	return listener.Addr().String()
+}
+
+// TestStartThenUse starts proxies and uses their internal client right away, which
+// failed intermittently when the client dialed before the server was listening
+func TestStartThenUse(t *testing.T) {
+	config := fmt.Sprintf(callsConfig, "http://localhost")
+
+	for _, transport := range []string{TransportSSE, TransportStreamableHTTP} {
+		t.Run(transport, func(t *testing.T) {
+			for i := 0; i < 20; i++ {
+				cfg, err := ParseConfigFromBytes([]byte(config))
+				if err != nil {
+					t.Fatalf("failed to parse config: %v", err)
+				}
+
+				addr := freeAddr(t)
+				s, err := NewServerFromConfig(cfg,
+					WithLogger(discardLogger()),
+					WithAddr(addr),
+					WithBaseURL("http://"+addr),
+					WithTransport(transport),
+				)
+				if err != nil {
+					t.Fatalf("failed to create proxy: %v", err)
+				}
+
+				ctx, cancel := context.WithCancel(context.Background())
+				if err := s.Start(ctx); err != nil {
+					cancel()
+					s.Close()
+					t.Fatalf("start %d failed: %v", i, err)
+				}
+
+				result, err := s.Client().ListTools(ctx, mcp.ListToolsRequest{})
+				cancel()
+				s.Close()
+				if err != nil {
+					t.Fatalf("start %d: ListTools failed: %v", i, err)
+				}
+				if len(result.Tools) != 1 {
+					t.Fatalf("start %d: ListTools returned %d tools, want 1", i, len(result.Tools))
+				}
+			}
+		})
+	}
+}
+
+// TestCloseReleasesResources creates, uses and closes many proxies, and checks none of
+// them leaves goroutines or backend connections behind
+func TestCloseReleasesResources(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		conns = make(map[net.Conn]bool)
+	)
+	backend := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"path":%q}`, r.URL.Path)
+	}))
+	backend.Config.ConnState = func(conn net.Conn, state http.ConnState) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch state {
+		case http.StateNew:
+			conns[conn] = true
+		case http.StateClosed, http.StateHijacked:
+			delete(conns, conn)
+		}
+	}
+	backend.Start()
+	defer backend.Close()
+
+	openConns := func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(conns)
+	}
+
+	baseline := runtime.NumGoroutine()
+	config := fmt.Sprintf(callsConfig, backend.URL)
+
+	for i := 0; i < 20; i++ {
+		cfg, err := ParseConfigFromBytes([]byte(config))
+		if err != nil {
+			t.Fatalf("failed to parse config: %v", err)
+		}
+
+		addr := freeAddr(t)
+		s, err := NewServerFromConfig(cfg,
+			WithLogger(discardLogger()),
+			WithAddr(addr),
+			WithBaseURL("http://"+addr),
+		)
+		if err != nil {
+			t.Fatalf("failed to create proxy: %v", err)
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		if err := s.Start(ctx); err != nil {
+			cancel()
+			s.Close()
+			t.Fatalf("start %d failed: %v", i, err)
+		}
+		text, isError := toolText(t, s, "get_user", map[string]any{"user_id": "1"})
+		cancel()
+		s.Close()
+		if isError {
+			t.Fatalf("proxy %d: tool call failed: %s", i, text)
+		}
+	}
+
+	waitFor(t, "backend connections to close", func() bool { return openConns() == 0 })
+
+	// Allow for goroutines of the test runtime that come and go
+	waitFor(t, "proxy goroutines to exit", func() bool { return runtime.NumGoroutine() <= baseline+2 })
+}
@@ -0,0 +1,144 @@
+package proxy
+
+import (
+	"net/http"
+	"sort"
+	"sync/atomic"
+	"time"
+)
+
+// backendHost is the request state ClientManager keeps for one backend host. Requests to
+// a host share its circuit breaker and retry budget, so one failing backend doesn't trip
+// the breaker for the others.
+type backendHost struct {
+	breaker  *CircuitBreaker
+	budget   *retryBudget
+	requests atomic.Int64
+	failures atomic.Int64
+
+	// lastUsed orders hosts by their latest request; guarded by ClientManager.hostsMu
+	lastUsed uint64
+}
+
+// maxArgumentHosts caps the hosts ClientManager keeps state for beyond the configured
+// backend hosts. Hosts filled in from arguments can be any host, so once the cap is
+// reached the least recently used of them is forgotten.
+const maxArgumentHosts = 256
+
+// count records the outcome of a request to the host
+func (h *backendHost) count(resp *http.Response, err error) {
+	h.requests.Add(1)
+	if err != nil || resp.StatusCode >= 500 {
+		h.failures.Add(1)
+	}
+}
+
+// host returns the state of a backend host, creating it on first use
+func (cm *ClientManager) host(name string) *backendHost {
+	cm.hostsMu.Lock()
+	defer cm.hostsMu.Unlock()
+
+	host, ok := cm.hosts[name]
+	if !ok {
+		if !cm.fixedHosts[name] {
+			cm.evictArgumentHosts(maxArgumentHosts - 1)
+		}
+		host = &backendHost{
+			breaker: NewCircuitBreaker(5, 30*time.Second),
+			budget:  newRetryBudget(),
+		}
+		cm.hosts[name] = host
+	}
+	cm.hostUses++
+	host.lastUsed = cm.hostUses
+	return host
+}
+
+// addFixedHost marks a configured backend's host, whose state is kept however many other
+// hosts are requested
+func (cm *ClientManager) addFixedHost(name string) {
+	cm.hostsMu.Lock()
+	defer cm.hostsMu.Unlock()
+	cm.fixedHosts[name] = true
+}
+
+// evictArgumentHosts forgets the least recently used hosts that aren't a configured
+// backend's until at most keep of them are tracked. The caller holds hostsMu.
+func (cm *ClientManager) evictArgumentHosts(keep int) {
+	var names []string
+	for name := range cm.hosts {
+		if !cm.fixedHosts[name] {
+			names = append(names, name)
+		}
+	}
+	if len(names) <= keep {
+		return
+	}
+
+	sort.Slice(names, func(i, j int) bool {
+		return cm.hosts[names[i]].lastUsed < cm.hosts[names[j]].lastUsed
+	})
+	for _, name := range names[:len(names)-keep] {
+		delete(cm.hosts, name)
+	}
+}
+
+// keepHosts carries over the host state of previous, so circuit breakers, retry budgets
+// and counters survive a reload. Hosts the new manager already tracks keep their state.
+func (cm *ClientManager) keepHosts(previous *ClientManager) {
+	previous.hostsMu.Lock()
+	defer previous.hostsMu.Unlock()
+	cm.hostsMu.Lock()
+	defer cm.hostsMu.Unlock()
+
+	for name, host := range previous.hosts {
+		if _, ok := cm.hosts[name]; !ok {
+			cm.hosts[name] = host
+		}
+	}
+	cm.hostUses = max(cm.hostUses, previous.hostUses)
+	cm.evictArgumentHosts(maxArgumentHosts)
+}
+
+// BackendStatus reports the circuit breaker and request counters of a backend host
+type BackendStatus struct {
+	Host           string                 `json:"host"`
+	CircuitBreaker CircuitBreakerSnapshot `json:"circuit_breaker"`
+
+	// Requests counts requests sent since the proxy started, and Failures those that
+	// failed with a connection error or a 5xx status after any retries
+	Requests int64 `json:"requests"`
+	Failures int64 `json:"failures"`
+}
+
+// Status returns the state of every backend host requests have been sent to, sorted by host
+func (cm *ClientManager) Status() []BackendStatus {
+	cm.hostsMu.Lock()
+	defer cm.hostsMu.Unlock()
+
+	statuses := make([]BackendStatus, 0, len(cm.hosts))
+	for name, host := range cm.hosts {
+		statuses = append(statuses, BackendStatus{
+			Host:           name,
+			CircuitBreaker: host.breaker.Snapshot(),
+			Requests:       host.requests.Load(),
+			Failures:       host.failures.Load(),
+		})
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Host < statuses[j].Host })
+	return statuses
+}
+
+// ResetCircuitBreaker closes the circuit breaker of a backend host and returns its new
+// state. It reports false if no requests have been sent to the host.
+func (cm *ClientManager) ResetCircuitBreaker(name string) (CircuitBreakerSnapshot, bool) {
+	cm.hostsMu.Lock()
+	host, ok := cm.hosts[name]
+	cm.hostsMu.Unlock()
+	if !ok {
+		return CircuitBreakerSnapshot{}, false
+	}
+
+	host.breaker.Reset()
+	return host.breaker.Snapshot(), true
+}
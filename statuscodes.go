@@ -0,0 +1,125 @@
+package proxy
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// StatusRange is an inclusive range of HTTP status codes, written as a single code
+// (418), a class ("2xx") or a range ("200-299")
+type StatusRange struct {
+	Min int
+	Max int
+}
+
+// String returns the range in the form it's written in config
+func (r StatusRange) String() string {
+	if r.Min == r.Max {
+		return strconv.Itoa(r.Min)
+	}
+	if r.Min%100 == 0 && r.Max == r.Min+99 {
+		return fmt.Sprintf("%dxx", r.Min/100)
+	}
+	return fmt.Sprintf("%d-%d", r.Min, r.Max)
+}
+
+// Contains reports whether status is in the range
+func (r StatusRange) Contains(status int) bool {
+	return status >= r.Min && status <= r.Max
+}
+
+// MarshalJSON writes the range as a string
+func (r StatusRange) MarshalJSON() ([]byte, error) {
+	return json.Marshal(r.String())
+}
+
+// UnmarshalJSON reads a range from a number or string
+func (r *StatusRange) UnmarshalJSON(data []byte) error {
+	var rawValue interface{}
+	if err := json.Unmarshal(data, &rawValue); err != nil {
+		return err
+	}
+
+	return r.parseValue(rawValue)
+}
+
+// MarshalYAML writes the range as a string
+func (r StatusRange) MarshalYAML() (interface{}, error) {
+	return r.String(), nil
+}
+
+// UnmarshalYAML reads a range from a number or string
+func (r *StatusRange) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var rawValue interface{}
+	if err := unmarshal(&rawValue); err != nil {
+		return err
+	}
+
+	return r.parseValue(rawValue)
+}
+
+// parseValue is a shared helper for parsing status ranges from JSON/YAML
+func (r *StatusRange) parseValue(rawValue interface{}) error {
+	switch v := rawValue.(type) {
+	case float64:
+		if v != float64(int(v)) {
+			return fmt.Errorf("invalid status code: %v", v)
+		}
+		*r = StatusRange{Min: int(v), Max: int(v)}
+
+	case int:
+		*r = StatusRange{Min: v, Max: v}
+
+	case string:
+		v = strings.TrimSpace(v)
+		if class, ok := strings.CutSuffix(strings.ToLower(v), "xx"); ok {
+			// A status class such as "2xx"
+			digit, err := strconv.Atoi(class)
+			if err != nil || len(class) != 1 {
+				return fmt.Errorf("invalid status class: %q", v)
+			}
+			*r = StatusRange{Min: digit * 100, Max: digit*100 + 99}
+		} else if low, high, ok := strings.Cut(v, "-"); ok {
+			// A range such as "200-299"
+			min, err := strconv.Atoi(strings.TrimSpace(low))
+			if err != nil {
+				return fmt.Errorf("invalid status range: %q", v)
+			}
+			max, err := strconv.Atoi(strings.TrimSpace(high))
+			if err != nil {
+				return fmt.Errorf("invalid status range: %q", v)
+			}
+			*r = StatusRange{Min: min, Max: max}
+		} else {
+			code, err := strconv.Atoi(v)
+			if err != nil {
+				return fmt.Errorf("invalid status code: %q", v)
+			}
+			*r = StatusRange{Min: code, Max: code}
+		}
+
+	default:
+		return fmt.Errorf("status code must be a number or string, got %T", rawValue)
+	}
+
+	if r.Min < 100 || r.Max > 599 || r.Min > r.Max {
+		return fmt.Errorf("invalid status range %s: codes must be between 100 and 599", r)
+	}
+	return nil
+}
+
+// isSuccessStatus reports whether a backend status counts as success for the endpoint:
+// one of its success_codes, or any 2xx status when it sets none
+func (e *Endpoint) isSuccessStatus(status int) bool {
+	if len(e.SuccessCodes) == 0 {
+		return status >= 200 && status < 300
+	}
+	for _, r := range e.SuccessCodes {
+		if r.Contains(status) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,103 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// defaultIdleTimeout bounds the silence between chunks of a streamed response
+// when the endpoint sets no idle timeout
+const defaultIdleTimeout = 30 * time.Second
+
+// errStreamIdle is the cause of a streamed request cancelled for going quiet
+var errStreamIdle = errors.New("stream idle timeout")
+
+// idleWatch cancels a streamed request when no data arrives within its timeout.
+// Unlike a response timeout it never expires while the backend keeps sending.
+type idleWatch struct {
+	ctx     context.Context
+	timeout time.Duration
+	timer   *time.Timer
+	cancel  context.CancelCauseFunc
+}
+
+// withIdleTimeout returns a context that is cancelled once timeout passes without
+// the returned watch being touched. Call stop to release it.
+func withIdleTimeout(ctx context.Context, timeout time.Duration) (context.Context, *idleWatch) {
+	if timeout <= 0 {
+		timeout = defaultIdleTimeout
+	}
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	w := &idleWatch{ctx: ctx, timeout: timeout, cancel: cancel}
+	w.timer = time.AfterFunc(timeout, func() {
+		cancel(fmt.Errorf("%w: no data received for %s", errStreamIdle, timeout))
+	})
+	return ctx, w
+}
+
+// touch restarts the idle timer
+func (w *idleWatch) touch() {
+	w.timer.Reset(w.timeout)
+}
+
+// stop releases the watch's timer and context
+func (w *idleWatch) stop() {
+	w.timer.Stop()
+	w.cancel(context.Canceled)
+}
+
+// wrap returns body with every read restarting the idle timer. Each chunk is passed to
+// onChunk, if set, as it arrives.
+func (w *idleWatch) wrap(body io.ReadCloser, onChunk func([]byte)) io.ReadCloser {
+	return &streamReader{ReadCloser: body, watch: w, onChunk: onChunk}
+}
+
+// streamReader is a response body read under an idle watch
+type streamReader struct {
+	io.ReadCloser
+	watch   *idleWatch
+	onChunk func([]byte)
+}
+
+// Read reads from the body, reporting the idle timeout rather than a bare
+// cancellation when the watch fired
+func (r *streamReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	if n > 0 {
+		r.watch.touch()
+		if r.onChunk != nil {
+			r.onChunk(p[:n])
+		}
+	}
+	if err != nil && err != io.EOF && r.watch.ctx.Err() != nil {
+		err = context.Cause(r.watch.ctx)
+	}
+	return n, err
+}
+
+// progressReporter returns a chunk callback that forwards streamed chunks to the
+// client as progress notifications, or nil when the request has no progress token
+func progressReporter(ctx context.Context, meta *mcp.Meta) func([]byte) {
+	mcpServer := server.ServerFromContext(ctx)
+	if meta == nil || meta.ProgressToken == nil || mcpServer == nil {
+		return nil
+	}
+
+	var received int
+	return func(chunk []byte) {
+		received += len(chunk)
+		// Progress is best effort; a client that can't receive it still gets the result
+		_ = mcpServer.SendNotificationToClient(ctx, "notifications/progress", map[string]any{
+			"progressToken": meta.ProgressToken,
+			"progress":      received,
+			"message":       string(chunk),
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package proxy
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Template is a reusable set of headers and parameters. Endpoints include templates by
+// name with uses, which keeps shared auth headers or paging parameters in one place.
+type Template struct {
+	// Headers are added to the endpoint's headers
+	Headers []*Header `json:"headers,omitempty" yaml:"headers,omitempty"`
+
+	// BodyParams, QueryParameters and PathParameters are added to the endpoint's parameters
+	BodyParams      []*Param `json:"body_params,omitempty" yaml:"body_params,omitempty"`
+	QueryParameters []*Param `json:"query_parameters,omitempty" yaml:"query_parameters,omitempty"`
+	PathParameters  []*Param `json:"path_parameters,omitempty" yaml:"path_parameters,omitempty"`
+}
+
+// validateTemplates checks each template's headers and parameters and that every
+// template an endpoint uses exists
+func validateTemplates(cfg *Config) error {
+	for name, template := range cfg.Templates {
+		if template == nil {
+			return fmt.Errorf("template '%s' is empty", name)
+		}
+		if err := validateTemplate(template); err != nil {
+			return fmt.Errorf("template '%s' validation failed: %w", name, err)
+		}
+	}
+
+	for _, backend := range cfg.Backends {
+		for _, endpoint := range backend.Endpoints {
+			for _, name := range endpoint.Uses {
+				if cfg.Templates[name] == nil {
+					return fmt.Errorf("endpoint '%s' uses unknown template '%s'", endpoint.Name, name)
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// validateTemplate checks the values of a template's headers and parameters. The rest is
+// checked on the endpoints that use the template, once it's merged into them.
+func validateTemplate(template *Template) error {
+	if err := validateConstantHeaders(template.Headers); err != nil {
+		return err
+	}
+
+	for _, params := range [][]*Param{template.PathParameters, template.QueryParameters, template.BodyParams} {
+		for _, param := range params {
+			// A raw body replaces the whole request body, so it can't be shared
+			if param.DataType == RAW_BODY {
+				return fmt.Errorf("raw_body parameter '%s' can't be used in a template", param.Identifier)
+			}
+			if err := validateParamDefault(param); err != nil {
+				return err
+			}
+			if param.ValueType == CONSTANT && param.Value == "" {
+				return fmt.Errorf("constant parameter '%s' has no value", param.Identifier)
+			}
+			if err := validateParamRange(param); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// expandTemplates merges the templates each endpoint uses into its headers and
+// parameters. Headers and parameters the endpoint already has are kept, so expanding
+// an already expanded config changes nothing.
+func expandTemplates(cfg *Config) {
+	for _, backend := range cfg.Backends {
+		for i := range backend.Endpoints {
+			endpoint := &backend.Endpoints[i]
+			for _, name := range endpoint.Uses {
+				template := cfg.Templates[name]
+				if template == nil {
+					continue
+				}
+				endpoint.Headers = mergeHeaders(endpoint.Headers, template.Headers)
+				endpoint.BodyParams = mergeParams(endpoint.BodyParams, template.BodyParams)
+				endpoint.QueryParameters = mergeParams(endpoint.QueryParameters, template.QueryParameters)
+				endpoint.PathParameters = mergeParams(endpoint.PathParameters, template.PathParameters)
+			}
+		}
+	}
+}
+
+// mergeHeaders appends copies of the template headers whose names aren't set yet
+func mergeHeaders(headers, template []*Header) []*Header {
+	for _, header := range template {
+		if !slices.ContainsFunc(headers, func(h *Header) bool { return strings.EqualFold(h.Name, header.Name) }) {
+			merged := *header
+			headers = append(headers, &merged)
+		}
+	}
+	return headers
+}
+
+// mergeParams appends copies of the template parameters whose identifiers aren't used yet
+func mergeParams(params, template []*Param) []*Param {
+	for _, param := range template {
+		if !slices.ContainsFunc(params, func(p *Param) bool { return p.Identifier == param.Identifier }) {
+			merged := *param
+			params = append(params, &merged)
+		}
+	}
+	return params
+}
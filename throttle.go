@@ -0,0 +1,103 @@
+package proxy
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// throttleState tracks when a log key was last emitted and how many records were dropped since
+type throttleState struct {
+	lastLogged time.Time
+	suppressed int
+}
+
+// throttledHandler is a slog.Handler that rate-limits error records per endpoint.
+// The first error for an endpoint is logged immediately; further errors with the same
+// message are logged at most once per interval, carrying a count of suppressed records.
+type throttledHandler struct {
+	next     slog.Handler
+	interval time.Duration
+
+	mu     *sync.Mutex
+	states map[string]*throttleState
+}
+
+// newThrottledHandler wraps next with per-endpoint error sampling
+func newThrottledHandler(next slog.Handler, interval time.Duration) *throttledHandler {
+	return &throttledHandler{
+		next:     next,
+		interval: interval,
+		mu:       &sync.Mutex{},
+		states:   make(map[string]*throttleState),
+	}
+}
+
+// Enabled reports whether the wrapped handler handles records at the given level
+func (h *throttledHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+// Handle emits the record unless it is an error that was already logged within the interval
+func (h *throttledHandler) Handle(ctx context.Context, record slog.Record) error {
+	if record.Level < slog.LevelError || h.interval <= 0 {
+		return h.next.Handle(ctx, record)
+	}
+
+	key := record.Message
+	record.Attrs(func(attr slog.Attr) bool {
+		switch attr.Key {
+		case "tool", "resource", "prompt", "endpoint":
+			key += "|" + attr.Value.String()
+			return false
+		}
+		return true
+	})
+
+	h.mu.Lock()
+	state, exists := h.states[key]
+	if !exists {
+		state = &throttleState{}
+		h.states[key] = state
+	}
+
+	now := time.Now()
+	if exists && now.Sub(state.lastLogged) < h.interval {
+		state.suppressed++
+		h.mu.Unlock()
+		return nil
+	}
+
+	suppressed := state.suppressed
+	state.lastLogged = now
+	state.suppressed = 0
+	h.mu.Unlock()
+
+	if suppressed > 0 {
+		record = record.Clone()
+		record.AddAttrs(slog.Int("suppressed", suppressed))
+	}
+
+	return h.next.Handle(ctx, record)
+}
+
+// WithAttrs returns a handler sharing the same throttle state
+func (h *throttledHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &throttledHandler{
+		next:     h.next.WithAttrs(attrs),
+		interval: h.interval,
+		mu:       h.mu,
+		states:   h.states,
+	}
+}
+
+// WithGroup returns a handler sharing the same throttle state
+func (h *throttledHandler) WithGroup(name string) slog.Handler {
+	return &throttledHandler{
+		next:     h.next.WithGroup(name),
+		interval: h.interval,
+		mu:       h.mu,
+		states:   h.states,
+	}
+}
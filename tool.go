@@ -8,14 +8,14 @@ import (
 	"log/slog"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/mark3labs/mcp-go/mcp"
 )
 
 // HTTPToolHandler handles tool execution by making HTTP requests
 type HTTPToolHandler struct {
-	endpoint      *Endpoint
-	backend       *Backend
+	requestBuilder
 	logger        *slog.Logger
 	clientManager *ClientManager
 }
@@ -23,26 +23,32 @@ type HTTPToolHandler struct {
 // NewHTTPToolHandler creates a new HTTP tool handler
 func NewHTTPToolHandler(endpoint *Endpoint, backend *Backend, logger *slog.Logger, clientManager *ClientManager) *HTTPToolHandler {
 	return &HTTPToolHandler{
-		endpoint:      endpoint,
-		backend:       backend,
-		logger:        logger,
-		clientManager: clientManager,
+		requestBuilder: requestBuilder{endpoint: endpoint, backend: backend},
+		logger:         logger,
+		clientManager:  clientManager,
 	}
 }
 
 // CreateMCPTool creates an MCP tool from endpoint configuration
 func (h *HTTPToolHandler) CreateMCPTool() mcp.Tool {
+	// A configured input schema is advertised as is
+	if h.endpoint.InputSchema != nil {
+		schema, _ := json.Marshal(h.endpoint.InputSchema)
+		return mcp.NewToolWithRawSchema(h.endpoint.Name, h.endpoint.Description, schema)
+	}
+
 	var toolOptions []mcp.ToolOption
 	toolOptions = append(toolOptions, mcp.WithDescription(h.endpoint.Description))
 
-	// Add parameters based on endpoint configuration
-	for _, param := range h.endpoint.BodyParams {
+	// Add parameters based on endpoint configuration; constant parameters are filled in
+	// by the proxy, so the LLM isn't asked for them
+	for _, param := range dynamicParams(h.endpoint.BodyParams) {
 		toolOptions = append(toolOptions, h.createParameterOption(param))
 	}
-	for _, param := range h.endpoint.QueryParameters {
+	for _, param := range dynamicParams(h.endpoint.QueryParameters) {
 		toolOptions = append(toolOptions, h.createParameterOption(param))
 	}
-	for _, param := range h.endpoint.PathParameters {
+	for _, param := range dynamicParams(h.endpoint.PathParameters) {
 		toolOptions = append(toolOptions, h.createParameterOption(param))
 	}
 
@@ -56,12 +62,38 @@ func (h *HTTPToolHandler) createParameterOption(param *Param) mcp.ToolOption {
 	if param.Required {
 		propertyOptions = append(propertyOptions, mcp.Required())
 	}
+	if len(param.Enum) > 0 {
+		propertyOptions = append(propertyOptions, mcp.Enum(param.Enum...))
+	}
+	if param.Minimum != nil {
+		propertyOptions = append(propertyOptions, mcp.Min(*param.Minimum))
+	}
+	if param.Maximum != nil {
+		propertyOptions = append(propertyOptions, mcp.Max(*param.Maximum))
+	}
+	if param.Default != "" {
+		// Advertise the default in the schema when it converts to the declared type
+		if value, err := coerceParamValue(param, param.Default); err == nil {
+			switch v := value.(type) {
+			case string:
+				propertyOptions = append(propertyOptions, mcp.DefaultString(v))
+			case float64:
+				propertyOptions = append(propertyOptions, mcp.DefaultNumber(v))
+			case int64:
+				propertyOptions = append(propertyOptions, mcp.DefaultNumber(float64(v)))
+			case bool:
+				propertyOptions = append(propertyOptions, mcp.DefaultBool(v))
+			}
+		}
+	}
 
 	switch strings.ToLower(string(param.DataType)) {
 	case "string":
 		return mcp.WithString(param.Identifier, propertyOptions...)
 	case "number":
 		return mcp.WithNumber(param.Identifier, propertyOptions...)
+	case "integer":
+		return mcp.WithNumber(param.Identifier, append(propertyOptions, integerType)...)
 	case "boolean":
 		return mcp.WithBoolean(param.Identifier, propertyOptions...)
 	case "object":
@@ -74,18 +106,53 @@ func (h *HTTPToolHandler) createParameterOption(param *Param) mcp.ToolOption {
 	}
 }
 
+// integerType marks a number property as accepting only integers
+func integerType(schema map[string]any) {
+	schema["type"] = "integer"
+}
+
+// forRequest returns a copy of the handler that logs with a call's request ID
+func (h *HTTPToolHandler) forRequest(requestID string) *HTTPToolHandler {
+	call := *h
+	call.logger = h.logger.With("request_id", requestID)
+	return &call
+}
+
 // Handler executes the tool by making an HTTP request
 func (h *HTTPToolHandler) Handler(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	arguments := req.GetArguments()
+	// Tag logs and backend requests with the call's request ID
+	ctx, requestID := startRequest(ctx, req.Params.Meta)
+	h = h.forRequest(requestID)
+
+	// Validate and coerce arguments before making any request
+	arguments, err := validateArguments(h.endpoint, req.GetArguments())
+	if err != nil {
+		return toolErrorResult("Tool '%s' received invalid arguments: %v", h.endpoint.Name, err), nil
+	}
+
+	// Let the endpoint's request transformer rewrite the arguments
+	arguments, err = h.transformArguments(ctx, arguments)
+	if err != nil {
+		return nil, err
+	}
+
+	// Serve the configured mock instead of calling the backend
+	if h.endpoint.MockResponse != nil {
+		return h.handleResponse(newMockHTTPResponse(h.endpoint.MockResponse))
+	}
 
-	// Build the URL with path parameters
+	// Build the URL with path parameters. Requests that can't be built from the
+	// arguments are reported to the LLM, which can retry with different ones
 	url, err := h.buildURL(arguments)
 	if err != nil {
-		return nil, fmt.Errorf("failed to build URL: %w", err)
+		return toolErrorResult("Tool '%s' failed to build URL: %v", h.endpoint.Name, err), nil
 	}
 
 	// Build query parameters
-	queryParams := h.buildQueryParams(arguments)
+	queryParams, err := h.buildQueryParams(arguments)
+	if err != nil {
+		return toolErrorResult("Tool '%s' failed to build query parameters: %v", h.endpoint.Name, err), nil
+	}
 	if len(queryParams) > 0 {
 		url += "?" + queryParams
 	}
@@ -93,9 +160,27 @@ func (h *HTTPToolHandler) Handler(ctx context.Context, req mcp.CallToolRequest)
 	// Build request body
 	body, err := h.buildRequestBody(arguments)
 	if err != nil {
-		return nil, fmt.Errorf("failed to build request body: %w", err)
+		return toolErrorResult("Tool '%s' failed to build request body: %v", h.endpoint.Name, err), nil
+	}
+	if err := checkRequestSize(h.endpoint, body); err != nil {
+		return toolErrorResult("Tool '%s' request body too large: %v", h.endpoint.Name, err), nil
+	}
+
+	// Bound the request, including reading the response, by the endpoint's response timeout.
+	// Streamed responses have no deadline and fail only when the backend goes quiet
+	var watch *idleWatch
+	if h.endpoint.Stream {
+		ctx, watch = withIdleTimeout(ctx, time.Duration(h.endpoint.IdleTimeout))
+		defer watch.stop()
+	} else {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(h.endpoint.ResponseTimeout))
+		defer cancel()
 	}
 
+	// Apply the endpoint's retry, limit and idempotency settings to the call
+	ctx, stats := h.callContext(ctx)
+
 	// Create HTTP request
 	httpReq, err := http.NewRequestWithContext(ctx, string(h.endpoint.Method), url, bytes.NewReader(body))
 	if err != nil {
@@ -112,156 +197,113 @@ func (h *HTTPToolHandler) Handler(ctx context.Context, req mcp.CallToolRequest)
 	)
 
 	// Make the HTTP request using client manager
-	resp, err := h.clientManager.DoRequest(ctx, httpReq, h.endpoint.Name)
+	resp, err := h.clientManager.DoHedgedRequest(ctx, httpReq, h.endpoint.Name, time.Duration(h.endpoint.HedgeAfter))
 	if err != nil {
 		return nil, fmt.Errorf("HTTP request failed: %w", err)
 	}
 	defer resp.Body.Close()
 
-	// Handle response
-	return h.handleResponse(resp)
-}
-
-// buildURL constructs the full URL with path parameters substituted
-func (h *HTTPToolHandler) buildURL(arguments map[string]any) (string, error) {
-	url := h.backend.BaseURL + h.endpoint.Path
-
-	// Replace path parameters
-	for _, param := range h.endpoint.PathParameters {
-		var value any
-		var exists bool
-
-		if param.ValueType == CONSTANT {
-			// Use the predefined value for constant parameters
-			value = param.Value
-			exists = param.Value != ""
-		} else {
-			// Use the value from arguments for dynamic parameters
-			value, exists = arguments[param.Identifier]
-		}
-
-		if !exists && param.Required {
-			return "", fmt.Errorf("required path parameter '%s' not provided", param.Identifier)
-		}
-		if exists {
-			placeholder := fmt.Sprintf("{%s}", param.Identifier)
-			url = strings.ReplaceAll(url, placeholder, fmt.Sprintf("%v", value))
-		}
+	// Forward streamed chunks to the client as they arrive
+	if watch != nil {
+		resp.Body = watch.wrap(resp.Body, progressReporter(ctx, req.Params.Meta))
 	}
 
-	return url, nil
-}
-
-// buildQueryParams constructs query parameters from arguments
-func (h *HTTPToolHandler) buildQueryParams(arguments map[string]any) string {
-	var params []string
-
-	for _, param := range h.endpoint.QueryParameters {
-		var value any
-		var exists bool
-
-		if param.ValueType == CONSTANT {
-			// Use the predefined value for constant parameters
-			value = param.Value
-			exists = param.Value != ""
-		} else {
-			// Use the value from arguments for dynamic parameters
-			value, exists = arguments[param.Identifier]
-		}
+	// Let the endpoint's response transformer rewrite the response
+	if err := h.transformResponseBody(ctx, resp); err != nil {
+		return nil, err
+	}
 
-		if exists {
-			params = append(params, fmt.Sprintf("%s=%v", param.Identifier, value))
-		}
+	// Handle response
+	result, err := h.handleResponse(resp)
+	if err != nil {
+		return nil, err
 	}
 
-	return strings.Join(params, "&")
+	// Include the configured response headers and backend diagnostics
+	h.addResponseHeaders(result, resp)
+	result.Meta = backendResultMeta(resp, stats)
+
+	return result, nil
 }
 
-// buildRequestBody constructs the JSON request body
-func (h *HTTPToolHandler) buildRequestBody(arguments map[string]any) ([]byte, error) {
-	if len(h.endpoint.BodyParams) == 0 {
-		return nil, nil
+// addResponseHeaders appends the endpoint's configured response headers to a tool result
+func (h *HTTPToolHandler) addResponseHeaders(result *mcp.CallToolResult, resp *http.Response) {
+	if len(h.endpoint.ResponseHeaders) == 0 {
+		return
 	}
 
-	body := make(map[string]any)
-	for _, param := range h.endpoint.BodyParams {
-		var value any
-		var exists bool
-
-		if param.ValueType == CONSTANT {
-			// Use the predefined value for constant parameters
-			value = param.Value
-			exists = param.Value != ""
-		} else {
-			// Use the value from arguments for dynamic parameters
-			value, exists = arguments[param.Identifier]
-		}
-
-		if exists {
-			body[param.Identifier] = value
-		} else if param.Required {
-			return nil, fmt.Errorf("required body parameter '%s' not provided", param.Identifier)
+	headers := make(map[string]string, len(h.endpoint.ResponseHeaders))
+	for _, name := range h.endpoint.ResponseHeaders {
+		if value := resp.Header.Get(name); value != "" {
+			headers[name] = value
 		}
 	}
+	if len(headers) == 0 {
+		return
+	}
 
-	if len(body) == 0 {
-		return nil, nil
+	encoded, err := json.Marshal(headers)
+	if err != nil {
+		return
 	}
 
-	return json.Marshal(body)
+	result.Content = append(result.Content, mcp.TextContent{
+		Type: "text",
+		Text: fmt.Sprintf("Response headers: %s", encoded),
+	})
 }
 
-// addHeaders adds headers to the HTTP request
-func (h *HTTPToolHandler) addHeaders(req *http.Request, arguments map[string]any) {
-	// Add default headers from backend
-	for _, header := range h.backend.DefaultHeaders {
-		req.Header.Set(header.Name, header.Value)
-	}
-
-	// Add endpoint-specific headers
-	for _, header := range h.endpoint.Headers {
-		if header.Type == CONSTANT {
-			req.Header.Set(header.Name, header.Value)
-		} else if header.Type == DYNAMIC {
-			// For dynamic headers, try to get value from arguments
-			// This is a simplified implementation - in practice you might want more sophisticated mapping
-			if value, exists := arguments[header.Name]; exists {
-				req.Header.Set(header.Name, fmt.Sprintf("%v", value))
-			}
-		}
+// backendResultMeta describes the backend exchange behind a tool result
+func backendResultMeta(resp *http.Response, stats *requestStats) map[string]any {
+	meta := map[string]any{
+		"backendStatus": resp.StatusCode,
+		"retryCount":    stats.retries.Load(),
 	}
-
-	// Set content type for JSON if we have body parameters
-	if len(h.endpoint.BodyParams) > 0 {
-		req.Header.Set("Content-Type", "application/json")
+	if resp.Request != nil {
+		meta["backendURL"] = resp.Request.URL.String()
 	}
+	return meta
 }
 
 // handleResponse processes the HTTP response and returns MCP result
 func (h *HTTPToolHandler) handleResponse(resp *http.Response) (*mcp.CallToolResult, error) {
 	// Read response body
 	var responseBody bytes.Buffer
-	if _, err := responseBody.ReadFrom(resp.Body); err != nil {
+	if _, err := responseBody.ReadFrom(limitBody(resp.Body, h.endpoint.MaxResponseBytes)); err != nil {
 		return nil, fmt.Errorf("failed to read response body: %w", err)
 	}
 
+	// HEAD and OPTIONS responses carry their information in the status and headers
+	if responseBody.Len() == 0 && isHeaderOnlyMethod(h.endpoint.Method) {
+		responseBody.Write(summarizeHeaderResponse(resp))
+	}
+
 	responseText := responseBody.String()
 
 	// Check if the request was successful
-	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+	if h.endpoint.isSuccessStatus(resp.StatusCode) {
 		h.logger.Debug("Tool execution successful",
 			"tool", h.endpoint.Name,
 			"status", resp.StatusCode,
 		)
 
-		return &mcp.CallToolResult{
-			Content: []mcp.Content{
-				mcp.TextContent{
-					Type: "text",
-					Text: fmt.Sprintf("Tool '%s' executed successfully. Response: %s", h.endpoint.Name, responseText),
-				},
-			},
-		}, nil
+		// Convert XML and CSV responses to JSON
+		body := responseBody.Bytes()
+		format := detectResponseFormat(h.endpoint.ResponseFormat, resp.Header.Get("Content-Type"))
+		mimeType := mimeTypeForFormat(format)
+		if converted, ok := convertResponseBody(format, body); ok {
+			body = converted
+			responseText = string(converted)
+			mimeType = "application/json"
+		}
+
+		// Reshape the response or reduce it to the configured fields
+		if transformed, ok := h.transformResponse(body); ok {
+			responseText = transformed
+			mimeType = "application/json"
+		}
+
+		return h.newSuccessResult(responseText, mimeType), nil
 	} else {
 		h.logger.Error("Tool execution failed",
 			"tool", h.endpoint.Name,
@@ -280,3 +322,106 @@ func (h *HTTPToolHandler) handleResponse(resp *http.Response) (*mcp.CallToolResu
 		}, nil
 	}
 }
+
+// newSuccessResult packages a successful response as configured by the endpoint's result_as
+func (h *HTTPToolHandler) newSuccessResult(responseText, mimeType string) *mcp.CallToolResult {
+	switch h.endpoint.ResultAs {
+	case RESULT_JSON:
+		var indented bytes.Buffer
+		if json.Indent(&indented, []byte(responseText), "", "  ") == nil {
+			return &mcp.CallToolResult{
+				Content: []mcp.Content{
+					mcp.TextContent{
+						Type: "text",
+						Text: indented.String(),
+					},
+				},
+			}
+		}
+	case RESULT_RESOURCE:
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				mcp.TextContent{
+					Type: "text",
+					Text: fmt.Sprintf("Tool '%s' executed successfully. The response is attached as a resource.", h.endpoint.Name),
+				},
+				mcp.EmbeddedResource{
+					Type: "resource",
+					Resource: mcp.TextResourceContents{
+						URI:      fmt.Sprintf("tool://%s/result", h.endpoint.Name),
+						MIMEType: mimeType,
+						Text:     responseText,
+					},
+				},
+			},
+		}
+	}
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.TextContent{
+				Type: "text",
+				Text: fmt.Sprintf("Tool '%s' executed successfully. Response: %s", h.endpoint.Name, responseText),
+			},
+		},
+	}
+}
+
+// transformResponse applies the endpoint's response_jq or response_fields to a JSON
+// response. It reports false when neither is configured or applies, so the caller keeps
+// the full response.
+func (h *HTTPToolHandler) transformResponse(body []byte) (string, bool) {
+	if h.endpoint.ResponseJQ != "" {
+		transformed, err := applyJQ(h.endpoint.ResponseJQ, body)
+		if err != nil {
+			h.logger.Warn("Failed to apply response_jq, returning full response", "tool", h.endpoint.Name, "error", err)
+			return "", false
+		}
+		return string(transformed), true
+	}
+	if len(h.endpoint.ResponseFields) > 0 {
+		return h.extractResponseFields(body)
+	}
+	return "", false
+}
+
+// extractResponseFields builds a compact JSON object from the configured response fields.
+// It reports false when the body isn't JSON or none of the paths match.
+func (h *HTTPToolHandler) extractResponseFields(body []byte) (string, bool) {
+	var data any
+	if err := json.Unmarshal(body, &data); err != nil {
+		return "", false
+	}
+
+	fields := make(map[string]any, len(h.endpoint.ResponseFields))
+	for name, path := range h.endpoint.ResponseFields {
+		if value, found := lookupJSONPath(data, path); found {
+			fields[name] = value
+		}
+	}
+
+	if len(fields) == 0 {
+		h.logger.Debug("No response fields matched, returning full response", "tool", h.endpoint.Name)
+		return "", false
+	}
+
+	extracted, err := json.Marshal(fields)
+	if err != nil {
+		return "", false
+	}
+
+	return string(extracted), true
+}
+
+// toolErrorResult returns a tool result reporting an error to the LLM
+func toolErrorResult(format string, args ...any) *mcp.CallToolResult {
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			mcp.TextContent{
+				Type: "text",
+				Text: fmt.Sprintf(format, args...),
+			},
+		},
+		IsError: true,
+	}
+}
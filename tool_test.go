@@ -0,0 +1,336 @@
+package proxy
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// echoBackend answers every request with its method, path and query, and records the
+// requests it served
+type echoBackend struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	requests []*http.Request
+}
+
+func newEchoBackend(t *testing.T) *echoBackend {
+	t.Helper()
+
+	backend := &echoBackend{}
+	backend.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		backend.mu.Lock()
+		backend.requests = append(backend.requests, r)
+		backend.mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"method":%q,"path":%q,"query":%q}`, r.Method, r.URL.Path, r.URL.RawQuery)
+	}))
+	t.Cleanup(backend.Close)
+	return backend
+}
+
+// received returns the requests served so far
+func (b *echoBackend) received() []*http.Request {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]*http.Request(nil), b.requests...)
+}
+
+func TestUpdateMethod(t *testing.T) {
+	backend := newEchoBackend(t)
+	s := newTestProxy(t, fmt.Sprintf(`
+backends:
+  - base_url: %s
+    endpoints:
+      - name: update_user
+        capability: tool
+        mode: client
+        method: UPDATE
+        path: /users/1
+`, backend.URL))
+
+	text, isError := toolText(t, s, "update_user", nil)
+	if isError {
+		t.Fatalf("update_user failed: %s", text)
+	}
+	if requests := backend.received(); len(requests) != 1 || requests[0].Method != "UPDATE" {
+		t.Errorf("backend received %d requests, want one UPDATE request", len(requests))
+	}
+}
+
+func TestHeaderTemplate(t *testing.T) {
+	backend := newEchoBackend(t)
+	s := newTestProxy(t, fmt.Sprintf(`
+backends:
+  - base_url: %s
+    endpoints:
+      - name: list_orders
+        capability: tool
+        mode: client
+        method: GET
+        path: /orders
+        headers:
+          - type: dynamic
+            name: Authorization
+            value: "Bearer {token}"
+          - type: dynamic
+            name: X-Tenant
+            value: "tenants/{tenant_id}/orders"
+        body_params:
+          - identifier: token
+            data_type: string
+            value_type: dynamic
+            required: true
+          - identifier: tenant_id
+            data_type: string
+            value_type: dynamic
+            required: true
+`, backend.URL))
+
+	text, isError := toolText(t, s, "list_orders", map[string]any{"token": "abc123", "tenant_id": "acme"})
+	if isError {
+		t.Fatalf("list_orders failed: %s", text)
+	}
+	requests := backend.received()
+	if len(requests) != 1 {
+		t.Fatalf("backend received %d requests, want 1", len(requests))
+	}
+	if got := requests[0].Header.Get("Authorization"); got != "Bearer abc123" {
+		t.Errorf("Authorization = %q, want %q", got, "Bearer abc123")
+	}
+	if got := requests[0].Header.Get("X-Tenant"); got != "tenants/acme/orders" {
+		t.Errorf("X-Tenant = %q, want %q", got, "tenants/acme/orders")
+	}
+}
+
+func TestArrayQueryStyles(t *testing.T) {
+	tests := []struct {
+		style string
+		want  string
+	}{
+		{"", "tag=a&tag=b+c"},
+		{"repeat", "tag=a&tag=b+c"},
+		{"csv", "tag=a%2Cb+c"},
+	}
+
+	for _, tt := range tests {
+		t.Run("style="+tt.style, func(t *testing.T) {
+			backend := newEchoBackend(t)
+			style := ""
+			if tt.style != "" {
+				style = "style: " + tt.style
+			}
+			s := newTestProxy(t, fmt.Sprintf(`
+backends:
+  - base_url: %s
+    endpoints:
+      - name: search
+        capability: tool
+        mode: client
+        method: GET
+        path: /search
+        query_parameters:
+          - identifier: tag
+            data_type: array
+            value_type: dynamic
+            %s
+`, backend.URL, style))
+
+			text, isError := toolText(t, s, "search", map[string]any{"tag": []any{"a", "b c"}})
+			if isError {
+				t.Fatalf("search failed: %s", text)
+			}
+			requests := backend.received()
+			if len(requests) != 1 {
+				t.Fatalf("backend received %d requests, want 1", len(requests))
+			}
+			if got := requests[0].URL.RawQuery; got != tt.want {
+				t.Errorf("query = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestToolBuildErrorsAreToolResults(t *testing.T) {
+	s := newTestProxy(t, `
+backends:
+  - base_url: http://{host}
+    endpoints:
+      - name: fetch
+        capability: tool
+        mode: client
+        method: GET
+        path: /data
+        path_parameters:
+          - identifier: host
+            data_type: string
+            value_type: dynamic
+            required: true
+  - base_url: http://localhost
+    endpoints:
+      - name: upload
+        capability: tool
+        mode: client
+        method: POST
+        path: /upload
+        max_request_bytes: 16
+        body_params:
+          - identifier: data
+            data_type: string
+            value_type: dynamic
+  - type: graphql
+    base_url: http://{host}/graphql
+    endpoints:
+      - name: graphql_fetch
+        capability: tool
+        mode: client
+        query: "{ data }"
+        path_parameters:
+          - identifier: host
+            data_type: string
+            value_type: dynamic
+            required: true
+  - type: graphql
+    base_url: http://localhost/graphql
+    endpoints:
+      - name: graphql_upload
+        capability: tool
+        mode: client
+        query: "mutation ($data: String!) { upload(data: $data) }"
+        max_request_bytes: 64
+        body_params:
+          - identifier: data
+            data_type: string
+            value_type: dynamic
+`)
+
+	tests := []struct {
+		tool      string
+		arguments map[string]any
+		want      string
+	}{
+		{"fetch", map[string]any{"host": "127.0.0.1"}, "failed to build URL"},
+		{"upload", map[string]any{"data": strings.Repeat("x", 64)}, "request body too large"},
+		{"graphql_fetch", map[string]any{"host": "127.0.0.1"}, "failed to build URL"},
+		{"graphql_upload", map[string]any{"data": strings.Repeat("x", 64)}, "request body too large"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.tool, func(t *testing.T) {
+			text, isError := toolText(t, s, tt.tool, tt.arguments)
+			if !isError || !strings.Contains(text, tt.want) {
+				t.Errorf("%s returned %q (error %v), want an error result containing %q", tt.tool, text, isError, tt.want)
+			}
+		})
+	}
+}
+
+func TestConstantParamsHiddenFromSchema(t *testing.T) {
+	backend := newEchoBackend(t)
+	s := newTestProxy(t, fmt.Sprintf(`
+backends:
+  - base_url: %s
+    endpoints:
+      - name: search
+        capability: tool
+        mode: client
+        method: GET
+        path: /search
+        query_parameters:
+          - identifier: q
+            data_type: string
+            value_type: dynamic
+            required: true
+          - identifier: api_version
+            data_type: string
+            value_type: constant
+            value: "2024-01"
+            required: true
+`, backend.URL))
+
+	tools, err := s.ListTools(context.Background())
+	if err != nil {
+		t.Fatalf("ListTools failed: %v", err)
+	}
+	if len(tools) != 1 {
+		t.Fatalf("ListTools returned %d tools, want 1", len(tools))
+	}
+
+	schema := tools[0].InputSchema
+	if _, ok := schema.Properties["q"]; !ok {
+		t.Error("dynamic parameter q is missing from the input schema")
+	}
+	if _, ok := schema.Properties["api_version"]; ok {
+		t.Error("constant parameter api_version appears in the input schema")
+	}
+	if slices.Contains(schema.Required, "api_version") {
+		t.Error("constant parameter api_version is listed as required")
+	}
+
+	// The constant is still sent to the backend
+	if text, isError := toolText(t, s, "search", map[string]any{"q": "shoes"}); isError {
+		t.Fatalf("search failed: %s", text)
+	}
+	if requests := backend.received(); len(requests) != 1 || requests[0].URL.Query().Get("api_version") != "2024-01" {
+		t.Error("backend didn't receive the constant api_version parameter")
+	}
+}
+
+func TestResponseTimeoutAndCallerDeadline(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(2 * time.Second):
+		case <-r.Context().Done():
+		}
+		fmt.Fprint(w, `{}`)
+	}))
+	defer backend.Close()
+
+	tests := []struct {
+		name            string
+		responseTimeout string
+		callerDeadline  time.Duration
+	}{
+		{"caller deadline shorter", "10s", 100 * time.Millisecond},
+		{"caller deadline longer", "100ms", 10 * time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestProxy(t, fmt.Sprintf(`
+backends:
+  - base_url: %s
+    endpoints:
+      - name: slow
+        capability: tool
+        mode: client
+        method: GET
+        path: /slow
+        response_timeout: %s
+`, backend.URL, tt.responseTimeout))
+
+			ctx, cancel := context.WithTimeout(context.Background(), tt.callerDeadline)
+			defer cancel()
+
+			start := time.Now()
+			result, err := s.CallTool(ctx, "slow", nil)
+			elapsed := time.Since(start)
+
+			if err == nil && !result.IsError {
+				t.Fatal("call to a backend slower than the deadline succeeded")
+			}
+			// Whichever deadline is earlier ends the call, well before the backend answers
+			if elapsed > time.Second {
+				t.Errorf("call took %v, want it to end at the earlier deadline", elapsed)
+			}
+		})
+	}
+}
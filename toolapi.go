@@ -0,0 +1,99 @@
+package proxy
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// toolAPIHandler serves POST /api/tools/{name}, which calls a configured tool with the
+// JSON object in the request body as its arguments and returns the tool result as JSON.
+// Calls go through the MCP server, so hooks, validation and handlers all apply.
+func (s *Proxy) toolAPIHandler(mcpServer *server.MCPServer) http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("POST /api/tools/{name}", func(w http.ResponseWriter, r *http.Request) {
+		name := r.PathValue("name")
+		if !s.hasTool(name) {
+			writeJSONError(w, http.StatusNotFound, fmt.Sprintf("tool '%s' not found", name))
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, s.maxRequestBytes()))
+		if err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				writeJSONError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("request body exceeds the %d byte limit", tooLarge.Limit))
+				return
+			}
+			writeJSONError(w, http.StatusBadRequest, "failed to read request body")
+			return
+		}
+
+		arguments := map[string]any{}
+		if len(body) > 0 {
+			if err := json.Unmarshal(body, &arguments); err != nil {
+				writeJSONError(w, http.StatusBadRequest, fmt.Sprintf("arguments must be a JSON object: %v", err))
+				return
+			}
+		}
+
+		message, err := json.Marshal(mcp.JSONRPCRequest{
+			JSONRPC: mcp.JSONRPC_VERSION,
+			ID:      mcp.NewRequestId(1),
+			Request: mcp.Request{Method: string(mcp.MethodToolsCall)},
+			Params: map[string]any{
+				"name":      name,
+				"arguments": arguments,
+			},
+		})
+		if err != nil {
+			writeJSONError(w, http.StatusInternalServerError, "failed to encode tool call")
+			return
+		}
+
+		switch response := mcpServer.HandleMessage(r.Context(), message).(type) {
+		case mcp.JSONRPCResponse:
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(response.Result)
+		case mcp.JSONRPCError:
+			status := http.StatusBadGateway
+			if response.Error.Code == mcp.INVALID_PARAMS {
+				status = http.StatusBadRequest
+			}
+			writeJSONError(w, status, response.Error.Message)
+		default:
+			writeJSONError(w, http.StatusInternalServerError, "unexpected response from MCP server")
+		}
+	})
+
+	return mux
+}
+
+// writeJSONError writes an error as a {"error": message} JSON body
+func writeJSONError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": message})
+}
+
+// hasTool reports whether the named tool is being served
+func (s *Proxy) hasTool(name string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.ContainsFunc(s.tools, func(tool server.ServerTool) bool { return tool.Tool.Name == name })
+}
+
+// maxRequestBytes returns the mcp max_request_bytes limit, or the default limit
+func (s *Proxy) maxRequestBytes() int64 {
+	if cfg := s.currentConfig(); cfg != nil && cfg.MCP != nil && cfg.MCP.MaxRequestBytes > 0 {
+		return cfg.MCP.MaxRequestBytes
+	}
+	return defaultMaxBodyBytes
+}
@@ -0,0 +1,103 @@
+package proxy
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// startToolAPI starts a proxy with the tool API on a free port and returns its address.
+// It's closed when the test ends.
+func startToolAPI(t *testing.T, config string, opts ...Option) string {
+	t.Helper()
+
+	cfg, err := ParseConfigFromBytes([]byte(config))
+	if err != nil {
+		t.Fatalf("failed to parse config: %v", err)
+	}
+
+	addr := freeAddr(t)
+	s, err := NewServerFromConfig(cfg, append([]Option{
+		WithLogger(discardLogger()),
+		WithAddr(addr),
+		WithBaseURL("http://" + addr),
+		WithToolAPI(true),
+	}, opts...)...)
+	if err != nil {
+		t.Fatalf("failed to create proxy: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(func() {
+		cancel()
+		s.Close()
+	})
+	if err := s.Start(ctx); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	return addr
+}
+
+func TestToolAPIChecksOrigin(t *testing.T) {
+	backend := newCallsBackend(t)
+	addr := startToolAPI(t, fmt.Sprintf(callsConfig, backend.URL), WithAllowedOrigins("https://ui.example.com"))
+
+	tests := []struct {
+		origin string
+		status int
+	}{
+		{"", http.StatusOK},
+		{"https://ui.example.com", http.StatusOK},
+		{"https://evil.example.com", http.StatusForbidden},
+	}
+	for _, test := range tests {
+		req, err := http.NewRequest(http.MethodPost, "http://"+addr+"/api/tools/get_user", strings.NewReader(`{"user_id":"42"}`))
+		if err != nil {
+			t.Fatalf("failed to create request: %v", err)
+		}
+		req.Header.Set("Content-Type", "text/plain")
+		if test.origin != "" {
+			req.Header.Set("Origin", test.origin)
+		}
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("POST /api/tools/get_user failed: %v", err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+
+		if resp.StatusCode != test.status {
+			t.Errorf("origin %q: got status %d (%s), want %d", test.origin, resp.StatusCode, body, test.status)
+		}
+		if got := resp.Header.Get("Access-Control-Allow-Origin"); test.status == http.StatusOK && test.origin != "" && got != test.origin {
+			t.Errorf("origin %q: got Access-Control-Allow-Origin %q", test.origin, got)
+		}
+	}
+}
+
+func TestToolAPILimitsRequestBody(t *testing.T) {
+	backend := newCallsBackend(t)
+	addr := startToolAPI(t, strings.Replace(fmt.Sprintf(callsConfig, backend.URL), "mcp: {}", "mcp:\n  max_request_bytes: 32", 1))
+
+	tests := []struct {
+		body   string
+		status int
+	}{
+		{`{"user_id":"42"}`, http.StatusOK},
+		{`{"user_id":"` + strings.Repeat("4", 64) + `"}`, http.StatusRequestEntityTooLarge},
+	}
+	for _, test := range tests {
+		resp, err := http.Post("http://"+addr+"/api/tools/get_user", "application/json", strings.NewReader(test.body))
+		if err != nil {
+			t.Fatalf("POST /api/tools/get_user failed: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != test.status {
+			t.Errorf("%d byte body: got status %d, want %d", len(test.body), resp.StatusCode, test.status)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+)
+
+// RequestTransformer rewrites a call's arguments before the backend request is built
+// from them. Endpoints use one registered with WithRequestTransformer by naming it in
+// transform_request.
+type RequestTransformer interface {
+	TransformRequest(ctx context.Context, endpoint *Endpoint, arguments map[string]any) (map[string]any, error)
+}
+
+// RequestTransformerFunc adapts a function to a RequestTransformer
+type RequestTransformerFunc func(ctx context.Context, endpoint *Endpoint, arguments map[string]any) (map[string]any, error)
+
+// TransformRequest calls f
+func (f RequestTransformerFunc) TransformRequest(ctx context.Context, endpoint *Endpoint, arguments map[string]any) (map[string]any, error) {
+	return f(ctx, endpoint, arguments)
+}
+
+// ResponseTransformer rewrites a backend response body before the endpoint handles it,
+// e.g. before response_fields or response_jq are applied. resp carries the status and
+// headers; its body has already been read into body. Endpoints use one registered with
+// WithResponseTransformer by naming it in transform_response.
+type ResponseTransformer interface {
+	TransformResponse(ctx context.Context, endpoint *Endpoint, resp *http.Response, body []byte) ([]byte, error)
+}
+
+// ResponseTransformerFunc adapts a function to a ResponseTransformer
+type ResponseTransformerFunc func(ctx context.Context, endpoint *Endpoint, resp *http.Response, body []byte) ([]byte, error)
+
+// TransformResponse calls f
+func (f ResponseTransformerFunc) TransformResponse(ctx context.Context, endpoint *Endpoint, resp *http.Response, body []byte) ([]byte, error) {
+	return f(ctx, endpoint, resp, body)
+}
+
+// WithRequestTransformer registers a request transformer under name, for endpoints
+// whose transform_request names it
+func WithRequestTransformer(name string, transformer RequestTransformer) Option {
+	return func(s *Proxy) {
+		if s.config.RequestTransformers == nil {
+			s.config.RequestTransformers = make(map[string]RequestTransformer)
+		}
+		s.config.RequestTransformers[name] = transformer
+	}
+}
+
+// WithResponseTransformer registers a response transformer under name, for endpoints
+// whose transform_response names it
+func WithResponseTransformer(name string, transformer ResponseTransformer) Option {
+	return func(s *Proxy) {
+		if s.config.ResponseTransformers == nil {
+			s.config.ResponseTransformers = make(map[string]ResponseTransformer)
+		}
+		s.config.ResponseTransformers[name] = transformer
+	}
+}
+
+// transformers are the registered transformers an endpoint names
+type transformers struct {
+	request  RequestTransformer
+	response ResponseTransformer
+}
+
+// endpointTransformers looks up the transformers an endpoint names. Names that aren't
+// registered fail the endpoint's setup.
+func (s *Proxy) endpointTransformers(endpoint *Endpoint) (transformers, error) {
+	var t transformers
+	if name := endpoint.TransformRequest; name != "" {
+		if t.request = s.config.RequestTransformers[name]; t.request == nil {
+			return t, fmt.Errorf("request transformer '%s' is not registered", name)
+		}
+	}
+	if name := endpoint.TransformResponse; name != "" {
+		if t.response = s.config.ResponseTransformers[name]; t.response == nil {
+			return t, fmt.Errorf("response transformer '%s' is not registered", name)
+		}
+	}
+	return t, nil
+}
+
+// transformArguments runs the endpoint's request transformer, if any, on a call's arguments
+func (b *requestBuilder) transformArguments(ctx context.Context, arguments map[string]any) (map[string]any, error) {
+	if b.transformers.request == nil {
+		return arguments, nil
+	}
+
+	transformed, err := b.transformers.request.TransformRequest(ctx, b.endpoint, arguments)
+	if err != nil {
+		return nil, fmt.Errorf("request transformer '%s' failed: %w", b.endpoint.TransformRequest, err)
+	}
+	return transformed, nil
+}
+
+// transformBody runs the endpoint's response transformer, if any, on a response body
+func (b *requestBuilder) transformBody(ctx context.Context, resp *http.Response, body []byte) ([]byte, error) {
+	if b.transformers.response == nil {
+		return body, nil
+	}
+
+	transformed, err := b.transformers.response.TransformResponse(ctx, b.endpoint, resp, body)
+	if err != nil {
+		return nil, fmt.Errorf("response transformer '%s' failed: %w", b.endpoint.TransformResponse, err)
+	}
+	return transformed, nil
+}
+
+// transformResponseBody replaces resp's body with the output of the endpoint's response
+// transformer, if any. The original body is left for the caller to close.
+func (b *requestBuilder) transformResponseBody(ctx context.Context, resp *http.Response) error {
+	if b.transformers.response == nil {
+		return nil
+	}
+
+	body, err := io.ReadAll(limitBody(resp.Body, b.endpoint.MaxResponseBytes))
+	if err != nil {
+		return fmt.Errorf("failed to read response body: %w", err)
+	}
+	if body, err = b.transformBody(ctx, resp, body); err != nil {
+		return err
+	}
+
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	resp.ContentLength = int64(len(body))
+	resp.Header.Set("Content-Length", strconv.Itoa(len(body)))
+	return nil
+}
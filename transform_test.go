@@ -0,0 +1,58 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestTransformersRewriteToolCall(t *testing.T) {
+	backend := newEchoBackend(t)
+	s := newTestProxy(t, fmt.Sprintf(`
+backends:
+  - base_url: %s
+    endpoints:
+      - name: list_orders
+        capability: tool
+        mode: client
+        method: GET
+        path: /orders
+        transform_request: add_tenant
+        transform_response: keep_query
+        query_parameters:
+          - identifier: tenant_id
+            data_type: string
+            value_type: dynamic
+`, backend.URL),
+		WithRequestTransformer("add_tenant", RequestTransformerFunc(
+			func(ctx context.Context, endpoint *Endpoint, arguments map[string]any) (map[string]any, error) {
+				arguments["tenant_id"] = "acme"
+				return arguments, nil
+			})),
+		WithResponseTransformer("keep_query", ResponseTransformerFunc(
+			func(ctx context.Context, endpoint *Endpoint, resp *http.Response, body []byte) ([]byte, error) {
+				var echoed struct{ Query string }
+				if err := json.Unmarshal(body, &echoed); err != nil {
+					return nil, err
+				}
+				return json.Marshal(map[string]string{"endpoint": endpoint.Name, "query": echoed.Query})
+			})),
+	)
+
+	text, isError := toolText(t, s, "list_orders", nil)
+	if isError {
+		t.Fatalf("list_orders failed: %s", text)
+	}
+
+	requests := backend.received()
+	if len(requests) != 1 || requests[0].URL.RawQuery != "tenant_id=acme" {
+		t.Fatalf("backend received %d requests, want one with the transformed query tenant_id=acme", len(requests))
+	}
+
+	if want := `{"endpoint":"list_orders","query":"tenant_id=acme"}`; !strings.Contains(text, want) || strings.Contains(text, `"method"`) {
+		t.Errorf("tool result %q doesn't hold the transformed response %s", text, want)
+	}
+}
@@ -0,0 +1,96 @@
+package proxy
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"syscall"
+)
+
+// checkTargetURL checks a backend URL built from call arguments before it is requested,
+// so a model can't redirect the request elsewhere. When the backend's base URL has a
+// fixed host, the URL must keep its scheme, host and credentials. When the host itself
+// is filled in from arguments, it must be http(s) and, unless the backend sets
+// allow_private_targets, must not resolve to a loopback, private or link-local address.
+func checkTargetURL(backend *Backend, target string) error {
+	u, err := url.Parse(target)
+	if err != nil {
+		return fmt.Errorf("invalid URL: %w", err)
+	}
+
+	if !backend.hostFromArguments() {
+		base, _ := url.Parse(backend.BaseURL)
+		if !strings.EqualFold(u.Scheme, base.Scheme) || !strings.EqualFold(u.Host, base.Host) || u.User.String() != base.User.String() {
+			return fmt.Errorf("URL '%s' leaves the backend's host %s", u.Redacted(), base.Host)
+		}
+		return nil
+	}
+
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("URL '%s' must use http or https", u.Redacted())
+	}
+	if u.Hostname() == "" {
+		return fmt.Errorf("URL '%s' has no host", u.Redacted())
+	}
+	if backend.AllowPrivateTargets {
+		return nil
+	}
+	return checkPublicTarget(u)
+}
+
+// hostFromArguments reports whether the backend's host is filled in from call
+// arguments, e.g. "https://{host}/api", rather than fixed by its base URL
+func (b *Backend) hostFromArguments() bool {
+	base, err := url.Parse(b.BaseURL)
+	return err != nil || base.Host == "" || strings.ContainsAny(base.Host, "{}")
+}
+
+// guardsPrivateTargets reports whether the backend's requests must not reach private
+// addresses: its host comes from arguments and allow_private_targets isn't set
+func (b *Backend) guardsPrivateTargets() bool {
+	return b.hostFromArguments() && !b.AllowPrivateTargets
+}
+
+// checkPublicTarget fails when u's host resolves to a private address
+func checkPublicTarget(u *url.URL) error {
+	ips, err := lookupTargetIPs(u.Hostname())
+	if err != nil {
+		return fmt.Errorf("failed to resolve '%s': %w", u.Hostname(), err)
+	}
+	for _, ip := range ips {
+		if isPrivateTarget(ip) {
+			return fmt.Errorf("URL '%s' targets private address %s; set allow_private_targets on the backend to allow it", u.Redacted(), ip)
+		}
+	}
+	return nil
+}
+
+// refusePrivateDial is a net.Dialer Control function that refuses connections to
+// private addresses. It checks the address actually dialed, so a host that resolved to
+// a public address for checkTargetURL can't resolve to a private one for the request.
+func refusePrivateDial(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if ip := net.ParseIP(host); ip != nil && isPrivateTarget(ip) {
+		return fmt.Errorf("refusing to connect to private address %s; set allow_private_targets on the backend to allow it", ip)
+	}
+	return nil
+}
+
+// lookupTargetIPs returns the addresses a host refers to
+func lookupTargetIPs(host string) ([]net.IP, error) {
+	if ip := net.ParseIP(host); ip != nil {
+		return []net.IP{ip}, nil
+	}
+	return net.LookupIP(host)
+}
+
+// isPrivateTarget reports whether an address is internal to the proxy's network, such
+// as a cloud metadata endpoint (169.254.169.254) or a service on localhost
+func isPrivateTarget(ip net.IP) bool {
+	return ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast()
+}
@@ -0,0 +1,89 @@
+package proxy
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestBlockPrivateTargetsRefusesDial(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "internal")
+	}))
+	defer backend.Close()
+
+	config := DefaultClientConfig()
+	config.BlockPrivateTargets = true
+	client := NewHTTPClient(config)
+	defer client.Close()
+
+	// The request goes straight to the client, as if its host had resolved to a public
+	// address when the URL was checked
+	req, _ := http.NewRequest(http.MethodGet, backend.URL, nil)
+	resp, err := client.client.Do(req)
+	if err == nil {
+		resp.Body.Close()
+		t.Fatal("request to a loopback address succeeded, want it refused")
+	}
+	if !strings.Contains(err.Error(), "private address") {
+		t.Errorf("request failed with %v, want a private address error", err)
+	}
+}
+
+func TestPublicRedirectRefusesPrivateTargets(t *testing.T) {
+	check := checkPublicRedirect(checkRedirect(REDIRECT_SAME_HOST, nil))
+	original, _ := http.NewRequest(http.MethodGet, "https://93.184.215.14/start", nil)
+
+	for _, target := range []string{"http://169.254.169.254/latest/meta-data", "http://127.0.0.1:8080/admin", "http://10.0.0.5/"} {
+		redirected, _ := http.NewRequest(http.MethodGet, target, nil)
+		if err := check(redirected, []*http.Request{original}); err == nil {
+			t.Errorf("redirect to %s was allowed, want it refused", target)
+		}
+	}
+
+	redirected, _ := http.NewRequest(http.MethodGet, "https://93.184.215.14/next", nil)
+	if err := check(redirected, []*http.Request{original}); err != nil {
+		t.Errorf("redirect to a public address failed: %v", err)
+	}
+}
+
+func TestArgumentHostBackendClients(t *testing.T) {
+	backend := newEchoBackend(t)
+	u, _ := url.Parse(backend.URL)
+
+	config := `
+backends:
+  - base_url: http://{host}
+    %s
+    endpoints:
+      - name: fetch
+        capability: tool
+        mode: client
+        method: GET
+        path: /data
+        path_parameters:
+          - identifier: host
+            data_type: string
+            value_type: dynamic
+            required: true
+`
+	// Without allow_private_targets the loopback backend is refused
+	s := newTestProxy(t, fmt.Sprintf(config, ""))
+	if _, isError := toolText(t, s, "fetch", map[string]any{"host": u.Host}); !isError {
+		t.Error("call to a loopback host succeeded, want an error result")
+	}
+
+	// With it, the backend is reached
+	s = newTestProxy(t, fmt.Sprintf(config, "allow_private_targets: true"))
+	result, err := s.CallTool(context.Background(), "fetch", map[string]any{"host": u.Host})
+	if err != nil || result.IsError {
+		t.Fatalf("call with allow_private_targets failed: %v %v", err, result)
+	}
+	if requests := backend.received(); len(requests) != 1 {
+		t.Errorf("backend received %d requests, want 1", len(requests))
+	}
+}